@@ -0,0 +1,172 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package cheats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/database"
+	"github.com/jetsetilly/gopher2600/errors"
+)
+
+// Kind indicates how a cheat is applied.
+type Kind string
+
+// List of valid cheat kinds.
+const (
+	// Freeze sets the RAM address to the value at the start of every frame
+	Freeze Kind = "freeze"
+
+	// Patch replaces the byte at the cartridge offset with the value
+	Patch Kind = "patch"
+)
+
+// ParseKind converts a string to a Kind. The string is not case sensitive.
+func ParseKind(s string) (Kind, error) {
+	switch Kind(strings.ToLower(s)) {
+	case Freeze:
+		return Freeze, nil
+	case Patch:
+		return Patch, nil
+	}
+	return "", errors.New(errors.CheatsError, fmt.Sprintf("unknown cheat type (%s)", s))
+}
+
+// Cheat is a single cheat. For Freeze cheats, Address is a VCS address. For
+// Patch cheats, Address is the offset from the start of cartridge data.
+type Cheat struct {
+	Kind        Kind
+	Address     uint16
+	Value       uint8
+	Enabled     bool
+	Description string
+}
+
+func (c Cheat) String() string {
+	s := strings.Builder{}
+
+	switch c.Kind {
+	case Freeze:
+		s.WriteString(fmt.Sprintf("freeze %#04x = %#02x", c.Address, c.Value))
+	case Patch:
+		s.WriteString(fmt.Sprintf("patch  %#04x = %#02x", c.Address, c.Value))
+	}
+
+	if !c.Enabled {
+		s.WriteString(" [disabled]")
+	}
+
+	if c.Description != "" {
+		s.WriteString(fmt.Sprintf(" (%s)", c.Description))
+	}
+
+	return s.String()
+}
+
+const cheatID = "cheat"
+
+const (
+	cheatFieldCartHash int = iota
+	cheatFieldKind
+	cheatFieldAddress
+	cheatFieldValue
+	cheatFieldEnabled
+	cheatFieldDescription
+	numCheatFields
+)
+
+// entry is the database representation of a Cheat.
+type entry struct {
+	cartHash string
+	cheat    Cheat
+}
+
+func deserialiseCheatEntry(fields database.SerialisedEntry) (database.Entry, error) {
+	ent := &entry{}
+
+	// basic sanity check
+	if len(fields) > numCheatFields {
+		return nil, errors.New(errors.CheatsError, "too many fields in cheat entry")
+	}
+	if len(fields) < numCheatFields {
+		return nil, errors.New(errors.CheatsError, "too few fields in cheat entry")
+	}
+
+	var err error
+
+	ent.cartHash = fields[cheatFieldCartHash]
+
+	ent.cheat.Kind, err = ParseKind(fields[cheatFieldKind])
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := strconv.ParseUint(fields[cheatFieldAddress], 16, 16)
+	if err != nil {
+		return nil, errors.New(errors.CheatsError, fmt.Sprintf("invalid address (%s)", fields[cheatFieldAddress]))
+	}
+	ent.cheat.Address = uint16(a)
+
+	v, err := strconv.ParseUint(fields[cheatFieldValue], 16, 8)
+	if err != nil {
+		return nil, errors.New(errors.CheatsError, fmt.Sprintf("invalid value (%s)", fields[cheatFieldValue]))
+	}
+	ent.cheat.Value = uint8(v)
+
+	ent.cheat.Enabled, err = strconv.ParseBool(fields[cheatFieldEnabled])
+	if err != nil {
+		return nil, errors.New(errors.CheatsError, fmt.Sprintf("invalid enabled flag (%s)", fields[cheatFieldEnabled]))
+	}
+
+	ent.cheat.Description = fields[cheatFieldDescription]
+
+	return ent, nil
+}
+
+// ID implements the database.Entry interface
+func (ent entry) ID() string {
+	return cheatID
+}
+
+// String implements the database.Entry interface
+func (ent entry) String() string {
+	return fmt.Sprintf("%s, %s", ent.cartHash, ent.cheat)
+}
+
+// Serialise implements the database.Entry interface
+func (ent *entry) Serialise() (database.SerialisedEntry, error) {
+	return database.SerialisedEntry{
+			ent.cartHash,
+			string(ent.cheat.Kind),
+			fmt.Sprintf("%04x", ent.cheat.Address),
+			fmt.Sprintf("%02x", ent.cheat.Value),
+			strconv.FormatBool(ent.cheat.Enabled),
+			ent.cheat.Description,
+		},
+		nil
+}
+
+// CleanUp implements the database.Entry interface
+func (ent entry) CleanUp() error {
+	// no cleanup necessary
+	return nil
+}
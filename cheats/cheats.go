@@ -0,0 +1,370 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package cheats
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/database"
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/paths"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// the location of the cheats database file
+const cheatsDBFile = "cheatsDB"
+
+func initDBSession(db *database.Session) error {
+	return db.RegisterEntryType(cheatID, deserialiseCheatEntry)
+}
+
+// Cheats is the list of cheats for the currently attached cartridge.
+//
+// Freezes are applied at the start of every frame. To do this, Cheats
+// implements the television.PixelRenderer interface and is added to the
+// VCS television by NewCheats(). Cheats is not otherwise interested in the
+// television output and so also implements the television.FrameRenderer
+// interface, which means it is not sent individual pixels.
+type Cheats struct {
+	vcs *hardware.VCS
+
+	// hash of the cartridge the cheats were loaded for
+	cartHash string
+
+	// copy of the original cartridge data. used to restore the bytes replaced
+	// by patch cheats. will be nil if the data could not be loaded, in which
+	// case patch cheats cannot be added
+	original []uint8
+
+	list []Cheat
+
+	// master switch. individual cheats are only applied if the master switch
+	// is also on
+	enabled bool
+}
+
+// NewCheats is the preferred method of initialisation for the Cheats type.
+func NewCheats(vcs *hardware.VCS) *Cheats {
+	ch := &Cheats{
+		vcs:     vcs,
+		list:    make([]Cheat, 0),
+		enabled: true,
+	}
+	vcs.TV.AddPixelRenderer(ch)
+	return ch
+}
+
+// Load the cheats for the currently attached cartridge from the cheats
+// database and apply them. Should be called whenever a new cartridge has been
+// attached. Any existing cheats are forgotten.
+func (ch *Cheats) Load() error {
+	ch.cartHash = ch.vcs.Mem.Cart.Hash
+	ch.list = ch.list[:0]
+	ch.original = nil
+
+	if ch.vcs.Mem.Cart.Filename == "" {
+		return nil
+	}
+
+	// keep a copy of the cartridge data so that patches can be undone. it's
+	// not an error for this to fail but patch cheats will not be available
+	data, err := cartridgeloader.Loader{Filename: ch.vcs.Mem.Cart.Filename}.Load()
+	if err == nil {
+		ch.original = data
+	}
+
+	dbPth, err := paths.ResourcePath("", cheatsDBFile)
+	if err != nil {
+		return errors.New(errors.CheatsError, err)
+	}
+
+	db, err := database.StartSession(dbPth, database.ActivityReading, initDBSession)
+	if err != nil {
+		if errors.Is(err, errors.DatabaseFileUnavailable) {
+			// silently ignore absence of cheats database
+			return nil
+		}
+		return errors.New(errors.CheatsError, err)
+	}
+	defer db.EndSession(false)
+
+	onSelect := func(ent database.Entry) (bool, error) {
+		if e, ok := ent.(*entry); ok && e.cartHash == ch.cartHash {
+			ch.list = append(ch.list, e.cheat)
+		}
+		return true, nil
+	}
+
+	_, err = db.SelectAll(onSelect)
+	if err != nil {
+		return errors.New(errors.CheatsError, err)
+	}
+
+	return ch.patchAll()
+}
+
+// Save the cheats for the current cartridge to the cheats database. Existing
+// entries for the cartridge are replaced.
+func (ch *Cheats) Save() error {
+	if ch.cartHash == "" {
+		return errors.New(errors.CheatsError, "no cartridge attached")
+	}
+
+	dbPth, err := paths.ResourcePath("", cheatsDBFile)
+	if err != nil {
+		return errors.New(errors.CheatsError, err)
+	}
+
+	db, err := database.StartSession(dbPth, database.ActivityCreating, initDBSession)
+	if err != nil {
+		return errors.New(errors.CheatsError, err)
+	}
+
+	// remove existing entries for this cartridge
+	for _, k := range db.SortedKeyList() {
+		ent, err := db.SelectKeys(nil, k)
+		if err != nil {
+			_ = db.EndSession(false)
+			return errors.New(errors.CheatsError, err)
+		}
+		if e, ok := ent.(*entry); ok && e.cartHash == ch.cartHash {
+			if err := db.Delete(k); err != nil {
+				_ = db.EndSession(false)
+				return errors.New(errors.CheatsError, err)
+			}
+		}
+	}
+
+	for i := range ch.list {
+		if err := db.Add(&entry{cartHash: ch.cartHash, cheat: ch.list[i]}); err != nil {
+			_ = db.EndSession(false)
+			return errors.New(errors.CheatsError, err)
+		}
+	}
+
+	err = db.EndSession(true)
+	if err != nil {
+		return errors.New(errors.CheatsError, err)
+	}
+
+	return nil
+}
+
+// Add a new cheat. Patch cheats are applied immediately.
+func (ch *Cheats) Add(c Cheat) error {
+	if strings.Contains(c.Description, ",") {
+		return errors.New(errors.CheatsError, "description cannot contain a comma")
+	}
+
+	switch c.Kind {
+	case Freeze:
+		_, area := memorymap.MapAddress(c.Address, false)
+		if area == memorymap.Undefined {
+			return errors.New(errors.CheatsError, fmt.Sprintf("cannot freeze address (%#04x)", c.Address))
+		}
+	case Patch:
+		if ch.original == nil {
+			return errors.New(errors.CheatsError, "cartridge data is not available for patching")
+		}
+		if int(c.Address) >= len(ch.original) {
+			return errors.New(errors.CheatsError, fmt.Sprintf("patch offset is too large (%#04x)", c.Address))
+		}
+	default:
+		return errors.New(errors.CheatsError, fmt.Sprintf("unknown cheat type (%s)", c.Kind))
+	}
+
+	ch.list = append(ch.list, c)
+
+	if c.Kind == Patch {
+		return ch.patch(c.Address)
+	}
+
+	return nil
+}
+
+// Remove the numbered cheat. Patch cheats are undone, unless another patch
+// cheat is for the same offset.
+func (ch *Cheats) Remove(num int) error {
+	if num < 0 || num >= len(ch.list) {
+		return errors.New(errors.CheatsError, fmt.Sprintf("no cheat #%d", num))
+	}
+
+	c := ch.list[num]
+	ch.list = append(ch.list[:num], ch.list[num+1:]...)
+
+	if c.Kind == Patch {
+		return ch.patch(c.Address)
+	}
+
+	return nil
+}
+
+// Toggle the numbered cheat on or off.
+func (ch *Cheats) Toggle(num int) error {
+	if num < 0 || num >= len(ch.list) {
+		return errors.New(errors.CheatsError, fmt.Sprintf("no cheat #%d", num))
+	}
+
+	ch.list[num].Enabled = !ch.list[num].Enabled
+
+	if ch.list[num].Kind == Patch {
+		return ch.patch(ch.list[num].Address)
+	}
+
+	return nil
+}
+
+// SetEnabled sets the master switch. Patch cheats are applied or undone as
+// appropriate.
+func (ch *Cheats) SetEnabled(enabled bool) error {
+	ch.enabled = enabled
+	return ch.patchAll()
+}
+
+// IsEnabled returns the state of the master switch.
+func (ch *Cheats) IsEnabled() bool {
+	return ch.enabled
+}
+
+// Len returns the number of cheats for the current cartridge.
+func (ch *Cheats) Len() int {
+	return len(ch.list)
+}
+
+// List writes a numbered list of cheats to io.Writer.
+func (ch *Cheats) List(output io.Writer) {
+	for i := range ch.list {
+		output.Write([]byte(fmt.Sprintf("% 2d: %s\n", i, ch.list[i])))
+	}
+}
+
+// patch the cartridge at the offset. the value is that of the most recently
+// added patch cheat for the offset that is enabled. if there is no such cheat,
+// or if the master switch is off, the original value is restored.
+func (ch *Cheats) patch(offset uint16) error {
+	if ch.original == nil || int(offset) >= len(ch.original) {
+		return errors.New(errors.CheatsError, fmt.Sprintf("cannot patch cartridge at offset %#04x", offset))
+	}
+
+	v := ch.original[offset]
+	if ch.enabled {
+		for i := range ch.list {
+			c := ch.list[i]
+			if c.Kind == Patch && c.Address == offset && c.Enabled {
+				v = c.Value
+			}
+		}
+	}
+
+	err := ch.vcs.Mem.Cart.Patch(offset, v)
+	if err != nil {
+		return errors.New(errors.CheatsError, err)
+	}
+
+	return nil
+}
+
+// patchAll calls patch() for every patch cheat.
+func (ch *Cheats) patchAll() error {
+	for i := range ch.list {
+		if ch.list[i].Kind == Patch {
+			if err := ch.patch(ch.list[i].Address); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// freeze applies every enabled freeze cheat.
+func (ch *Cheats) freeze() error {
+	if !ch.enabled {
+		return nil
+	}
+
+	for i := range ch.list {
+		c := ch.list[i]
+		if c.Kind != Freeze || !c.Enabled {
+			continue // for loop
+		}
+
+		addr, area := memorymap.MapAddress(c.Address, false)
+		ar, err := ch.vcs.Mem.GetArea(area)
+		if err != nil {
+			return errors.New(errors.CheatsError, err)
+		}
+
+		err = ar.Poke(addr, c.Value)
+		if err != nil {
+			return errors.New(errors.CheatsError, err)
+		}
+	}
+
+	return nil
+}
+
+// Resize implements the television.PixelRenderer interface
+func (ch *Cheats) Resize(_, _ int) error {
+	return nil
+}
+
+// NewFrame implements the television.PixelRenderer interface
+func (ch *Cheats) NewFrame(_ int) error {
+	return ch.freeze()
+}
+
+// NewScanline implements the television.PixelRenderer interface
+//
+// Cheats implements the television.FrameRenderer interface so NewScanline()
+// is never called.
+func (ch *Cheats) NewScanline(_ int) error {
+	return nil
+}
+
+// SetPixel implements the television.PixelRenderer interface
+//
+// Cheats implements the television.FrameRenderer interface so SetPixel() is
+// never called.
+func (ch *Cheats) SetPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// SetAltPixel implements the television.PixelRenderer interface
+//
+// Cheats implements the television.FrameRenderer interface so SetAltPixel()
+// is never called.
+func (ch *Cheats) SetAltPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// RenderFrame implements the television.FrameRenderer interface
+func (ch *Cheats) RenderFrame(_ *television.Frame) error {
+	return nil
+}
+
+// EndRendering implements the television.PixelRenderer interface
+func (ch *Cheats) EndRendering() error {
+	return nil
+}
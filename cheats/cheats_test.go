@@ -0,0 +1,302 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package cheats_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/cheats"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// a 4k cartridge that does nothing but increment RAM address 0x81
+func testCartridge(t *testing.T, dir string) string {
+	t.Helper()
+
+	data := make([]byte, 4096)
+	for i := range data {
+		data[i] = 0xea // NOP
+	}
+
+	// INC $81 ; JMP $f000
+	copy(data, []byte{0xe6, 0x81, 0x4c, 0x00, 0xf0})
+
+	// reset vector
+	data[0xffc] = 0x00
+	data[0xffd] = 0xf0
+
+	fn := filepath.Join(dir, "test.bin")
+	err := ioutil.WriteFile(fn, data, 0600)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	return fn
+}
+
+func TestCheats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheats")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	// cheats database is located in the resources path, which is relative to
+	// the working directory. change to the temporary directory so that we
+	// don't leave anything behind
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.Chdir(wd)
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: testCartridge(t, dir), Format: "AUTO"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ch := cheats.NewCheats(vcs)
+	err = ch.Load()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// freeze is applied at the start of every frame
+	err = ch.Add(cheats.Cheat{Kind: cheats.Freeze, Address: 0x80, Value: 0x42, Enabled: true})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	err = vcs.RunForFrameCount(2, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	v, _ := vcs.Mem.Read(0x80)
+	test.Equate(t, int(v), 0x42)
+
+	// patch is applied immediately. the patch offset is from the start of
+	// cartridge data, which in the case of a 4k cartridge is also the start
+	// of the cartridge address space
+	err = ch.Add(cheats.Cheat{Kind: cheats.Patch, Address: 0x0001, Value: 0x82, Enabled: true})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x82)
+
+	// original byte is restored when cheat is toggled off
+	err = ch.Toggle(1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x81)
+
+	err = ch.Toggle(1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x82)
+
+	// and when the master switch is turned off
+	err = ch.SetEnabled(false)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x81)
+
+	// freeze is no longer applied. poke a new value into RAM and check that
+	// it is not overwritten
+	vcs.Mem.Write(0x80, 0x10)
+	err = vcs.RunForFrameCount(2, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	v, _ = vcs.Mem.Read(0x80)
+	test.Equate(t, int(v), 0x10)
+
+	// removing a patch restores the original byte
+	err = ch.SetEnabled(true)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	err = ch.Remove(1)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x81)
+	test.Equate(t, ch.Len(), 1)
+
+	// two patches for the same offset. the most recently added patch is
+	// applied
+	test.ExpectedSuccess(t, ch.Add(cheats.Cheat{Kind: cheats.Patch, Address: 0x0001, Value: 0x82, Enabled: true}))
+	test.ExpectedSuccess(t, ch.Add(cheats.Cheat{Kind: cheats.Patch, Address: 0x0001, Value: 0x83, Enabled: true}))
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x83)
+
+	// toggling the second patch off applies the first patch
+	test.ExpectedSuccess(t, ch.Toggle(2))
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x82)
+	test.ExpectedSuccess(t, ch.Toggle(2))
+
+	// removing the second patch leaves the first patch applied. removing the
+	// first patch restores the original byte
+	test.ExpectedSuccess(t, ch.Remove(2))
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x82)
+	test.ExpectedSuccess(t, ch.Remove(1))
+	v, _ = vcs.Mem.Cart.Read(0x1001)
+	test.Equate(t, int(v), 0x81)
+
+	// descriptions cannot contain the database field separator
+	err = ch.Add(cheats.Cheat{Kind: cheats.Freeze, Address: 0x80, Value: 0x01, Description: "a, b"})
+	test.ExpectedFailure(t, err)
+}
+
+func TestCheatsPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cheats")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	// see comment in TestCheats()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.Chdir(wd)
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// a second cartridge that differs from the test cartridge by a single
+	// byte and therefore has a different hash
+	cartA := testCartridge(t, dir)
+	data, err := ioutil.ReadFile(cartA)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	data[0x100] = 0x00
+	cartB := filepath.Join(dir, "other.bin")
+	err = ioutil.WriteFile(cartB, data, 0600)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// attach a cartridge and load its cheats into a new instance of Cheats
+	load := func(filename string) *cheats.Cheats {
+		t.Helper()
+		err := vcs.AttachCartridge(cartridgeloader.Loader{Filename: filename, Format: "AUTO"})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		ch := cheats.NewCheats(vcs)
+		err = ch.Load()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return ch
+	}
+
+	list := func(ch *cheats.Cheats) string {
+		s := &strings.Builder{}
+		ch.List(s)
+		return s.String()
+	}
+
+	// saving with no cheats is allowed
+	ch := load(cartA)
+	test.Equate(t, ch.Len(), 0)
+	test.ExpectedSuccess(t, ch.Save())
+
+	test.ExpectedSuccess(t, ch.Add(cheats.Cheat{Kind: cheats.Freeze, Address: 0x80, Value: 0x42, Enabled: true, Description: "lives"}))
+	test.ExpectedSuccess(t, ch.Add(cheats.Cheat{Kind: cheats.Patch, Address: 0x0001, Value: 0x82, Enabled: false}))
+	test.ExpectedSuccess(t, ch.Save())
+	saved := list(ch)
+
+	// the cheats are restored by a new instance of Cheats
+	ch = load(cartA)
+	test.Equate(t, ch.Len(), 2)
+	test.Equate(t, list(ch), saved)
+
+	// a cartridge with a different hash does not see the cheats
+	ch = load(cartB)
+	test.Equate(t, ch.Len(), 0)
+
+	// saving the cheats for the other cartridge does not affect the cheats
+	// of the first cartridge
+	test.ExpectedSuccess(t, ch.Add(cheats.Cheat{Kind: cheats.Freeze, Address: 0x81, Value: 0x01, Enabled: true}))
+	test.ExpectedSuccess(t, ch.Save())
+
+	ch = load(cartA)
+	test.Equate(t, list(ch), saved)
+
+	// removing a cheat and saving replaces the existing entries
+	test.ExpectedSuccess(t, ch.Remove(1))
+	test.ExpectedSuccess(t, ch.Save())
+	saved = list(ch)
+
+	ch = load(cartA)
+	test.Equate(t, ch.Len(), 1)
+	test.Equate(t, list(ch), saved)
+
+	ch = load(cartB)
+	test.Equate(t, ch.Len(), 1)
+}
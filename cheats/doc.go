@@ -0,0 +1,50 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package cheats implements RAM freezes and ROM byte substitutions. Cheats are
+// stored per cartridge in the cheatsDB file in the resources path and are
+// applied automatically when the cartridge is attached.
+//
+// Two types of cheat are supported:
+//
+//	Freeze
+//
+// A RAM address is set to a fixed value at the start of every frame. This is
+// the type of cheat used to give a player infinite lives, for example.
+//
+//	Patch
+//
+// A byte in the cartridge ROM is replaced. The location is given as an offset
+// from the start of the cartridge data, in the same way as patch files (see
+// the patch package). Patches are applied with the Cartridge.Patch() function
+// and the original byte is restored when the patch is disabled. If more than one
+// patch is for the same offset then the most recently added patch that is
+// enabled is applied.
+//
+// Cheats can be toggled individually or with the master switch. The master
+// switch is not stored in the database.
+//
+// For reference the following describes the format of the entries in the
+// cheatsDB file:
+//
+//	<DB Key>, cheat, <SHA-1 Hash>, <freeze|patch>, <address (hex)>, <value (hex)>, <enabled (bool)>, <description>
+//
+// Entries can be edited by hand but the Cheats.Save() function will rewrite all
+// entries for a cartridge.
+package cheats
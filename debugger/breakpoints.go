@@ -55,11 +55,19 @@ type breaker struct {
 	value       interface{}
 	ignoreValue interface{}
 
+	// a conditional breaker has a target that is a compiled expression. the
+	// breaker matches when the value of the expression changes to true
+	conditional bool
+
 	// single linked list ANDs breakers together
 	next *breaker
 }
 
 func (bk breaker) String() string {
+	if bk.conditional {
+		return bk.target.Label()
+	}
+
 	s := strings.Builder{}
 	s.WriteString(fmt.Sprintf("%s->%s", bk.target.Label(), bk.target.FormatValue(bk.value)))
 	n := bk.next
@@ -128,6 +136,19 @@ const (
 // the break target
 func (bk *breaker) check() checkResult {
 	currVal := bk.target.TargetValue()
+
+	if bk.conditional {
+		prevVal := bk.ignoreValue
+		bk.ignoreValue = currVal
+		if currVal != bk.value {
+			return checkNoMatch
+		}
+		if prevVal == currVal {
+			return checkIgnoredValue
+		}
+		return checkMatch
+	}
+
 	m := currVal == bk.value
 	if !m {
 		return checkNoMatch
@@ -148,6 +169,19 @@ func (bk *breaker) check() checkResult {
 	return checkMatch
 }
 
+// holds is true if the break condition, and the condition of any ANDed
+// breakers, is currently met. unlike check() the ignore value is neither
+// consulted nor changed
+func (bk *breaker) holds() bool {
+	if bk.target.TargetValue() != bk.value {
+		return false
+	}
+	if bk.next != nil {
+		return bk.next.holds()
+	}
+	return true
+}
+
 // add a new breaker by linking it to the end of an existing breaker
 func (bk *breaker) add(nbk *breaker) {
 	n := bk
@@ -212,6 +246,30 @@ func (bp *breakpoints) check(previousResult string) string {
 	return checkString.String()
 }
 
+// matching returns a string listing every breakpoint whose condition is
+// currently met, in the same format as check(). the state of the breakpoints
+// is not changed so it can be used when the emulation is being re-executed
+// out of sequence (eg. RUN BACK)
+func (bp *breakpoints) matching() string {
+	checkString := strings.Builder{}
+	for i := range bp.breaks {
+		if bp.breaks[i].holds() {
+			checkString.WriteString(fmt.Sprintf("break on %s\n", bp.breaks[i]))
+		}
+	}
+	return checkString.String()
+}
+
+// ignoreMatching prevents breakpoints whose conditions are currently met
+// from halting the emulation until the condition changes
+func (bp *breakpoints) ignoreMatching() {
+	for i := range bp.breaks {
+		if bp.breaks[i].holds() {
+			bp.breaks[i].ignoreValue = bp.breaks[i].target.TargetValue()
+		}
+	}
+}
+
 // list currently defined breakpoints
 func (bp breakpoints) list() {
 	if len(bp.breaks) == 0 {
@@ -241,8 +299,30 @@ func (bp breakpoints) list() {
 //
 //	& SL 100 HP 0 X 10
 //
+// if the tokens form an expression (see expressions.go) then the breakpoint
+// halts execution when the expression changes to true. for example:
+//
+//	[$80] > 5 && X == 0
+//
 // !!TODO: simplify breakpoints parser to match help description
 func (bp *breakpoints) parseBreakpoint(tokens *commandline.Tokens) error {
+	// an expression is compiled and added as a single conditional breaker
+	if isExpression(tokens.Remainder()) {
+		ex, err := compileExpression(bp.dbg, tokens.Remainder())
+		if err != nil {
+			return err
+		}
+		tokens.End()
+
+		nb := breaker{target: ex.conditionTarget(), value: true, conditional: true}
+		if i := bp.checkBreaker(nb); i != noBreakEqualivalent {
+			return errors.New(errors.CommandError, fmt.Sprintf("already exists (%s)", bp.breaks[i]))
+		}
+		bp.breaks = append(bp.breaks, nb)
+
+		return nil
+	}
+
 	andBreaks := false
 
 	// default target of CPU PC. meaning that "BREAK n" will cause a breakpoint
@@ -276,6 +356,37 @@ func (bp *breakpoints) parseBreakpoint(tokens *commandline.Tokens) error {
 		var val interface{}
 		var err error
 
+		// a PC target can also be given as a source location of the form
+		// file:line. the source location is resolved to an address and, for
+		// cartridges with more than one bank, the bank in which the code for
+		// that line can be found
+		if tgt.Label() == "PC" && isSourceLocation(tok) {
+			sl, banks, err := parseSourceLocation(bp.dbg, tok)
+			if err != nil {
+				return err
+			}
+
+			ai := bp.dbg.dbgmem.mapAddress(sl.Address, true)
+			nb := &breaker{target: tgt, value: int(ai.mappedAddress)}
+			if bp.dbg.vcs.Mem.Cart.NumBanks() > 1 && len(banks) == 1 {
+				nb.next = &breaker{target: bankTarget(bp.dbg), value: banks[0]}
+			}
+
+			if andBreaks {
+				newBreaks[len(newBreaks)-1].add(nb)
+			} else {
+				newBreaks = append(newBreaks, *nb)
+			}
+			resolvedTarget = true
+
+			// the bank condition has already been decided. we don't want an
+			// automatic BANK condition to be added (see below)
+			explicitPCTarget = true
+
+			tok, present = tokens.Get()
+			continue // for loop
+		}
+
 		// try to interpret the token depending on the type of value the target
 		// expects
 		switch tgt.TargetValue().(type) {
@@ -57,4 +57,25 @@ func (trm *mockTerm) testBreakpoints() {
 
 	trm.sndInput("BREAK HP 100")
 	trm.cmpOutput("")
+
+	// add an expression break. the expression is normalised
+	trm.sndInput("BREAK [$80]>5 && x==0")
+	trm.cmpOutput("")
+
+	trm.sndInput("LIST BREAKS")
+	trm.cmpOutput(" 3: [$80] > 5 && x == 0")
+
+	// the same expression written differently is the same break
+	trm.sndInput("BREAK [$80] > 5 && x == 0")
+	trm.cmpOutput("already exists ([$80] > 5 && x == 0)")
+
+	// invalid expressions
+	trm.sndInput("BREAK [$80] >")
+	trm.cmpOutput("expression error: unexpected end of expression")
+
+	trm.sndInput("BREAK (A + 1 == 2")
+	trm.cmpOutput("expression error: expected )")
+
+	trm.sndInput("BREAK NOSUCHSYMBOL == 1")
+	trm.cmpOutput("expression error: unrecognised identifier (NOSUCHSYMBOL)")
 }
@@ -0,0 +1,193 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"fmt"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/hardware/cpu/instructions"
+)
+
+// CallFrame records a single subroutine call. Addresses are recorded as they
+// were seen by the CPU, along with the cartridge bank that was selected at the
+// time.
+type CallFrame struct {
+	// address of the JSR (or BRK) instruction and the bank it was executed in
+	CallAddress uint16
+	CallBank    int
+
+	// the address the CPU jumped to and the bank selected after the jump
+	Subroutine     uint16
+	SubroutineBank int
+
+	// the address the subroutine will return to
+	ReturnAddress uint16
+
+	// value of the stack pointer after the return address was pushed. the
+	// frame is considered to have returned as soon as the stack pointer
+	// rises above this value
+	SP uint8
+
+	// call was the result of a BRK instruction rather than a JSR
+	Interrupt bool
+}
+
+// callStack tracks subroutine calls made by the 6507.
+//
+// the stack is maintained by looking at the stack pointer rather than by
+// matching JSR instructions with RTS instructions. this means that stack
+// manipulation (eg. PLA PLA to discard a return address or TXS to reset the
+// stack) is handled correctly, as are the bank-switching trampolines used by
+// many cartridges to make calls across banks. a trampoline will often return
+// to a different address, or even bank, than the one recorded in the frame but
+// the stack pointer is a reliable indicator of the call depth.
+type callStack struct {
+	dbg    *Debugger
+	frames []CallFrame
+
+	// the depth at which a STEP OVER or STEP OUT is considered complete.
+	// a value of -1 indicates that no such step is in progress.
+	stepDepth int
+
+	// stepDepth has been reached. the value is consumed by stepComplete()
+	stepHalt bool
+}
+
+func newCallStack(dbg *Debugger) *callStack {
+	return &callStack{
+		dbg:       dbg,
+		frames:    make([]CallFrame, 0),
+		stepDepth: -1,
+	}
+}
+
+// clear call stack and cancel any step that is in progress. should be called
+// whenever the machine is reset.
+func (cs *callStack) clear() {
+	cs.frames = cs.frames[:0]
+	cs.cancelStep()
+}
+
+// depth returns the number of subroutines currently entered.
+func (cs *callStack) depth() int {
+	return len(cs.frames)
+}
+
+// update the call stack. should be called after every completed CPU
+// instruction.
+func (cs *callStack) update() {
+	res := cs.dbg.vcs.CPU.LastResult
+	if !res.Final || res.Defn == nil {
+		return
+	}
+
+	sp := cs.dbg.vcs.CPU.SP.Value()
+
+	// remove frames that have returned. this covers RTS and RTI as well as
+	// any other instruction that has moved the stack pointer past the return
+	// address
+	for len(cs.frames) > 0 && cs.frames[len(cs.frames)-1].SP < sp {
+		cs.frames = cs.frames[:len(cs.frames)-1]
+	}
+
+	// execution resumes three bytes after a JSR and, because BRK skips the
+	// padding byte that follows it, two bytes after a BRK
+	var ret uint16
+	switch res.Defn.Mnemonic {
+	case "JSR":
+		ret = res.Address + 3
+	case "BRK":
+		ret = res.Address + 2
+	}
+
+	if ret != 0 {
+		pc := cs.dbg.vcs.CPU.PC.Address()
+		cs.frames = append(cs.frames, CallFrame{
+			CallAddress:    res.Address,
+			CallBank:       cs.dbg.lastBank,
+			Subroutine:     pc,
+			SubroutineBank: cs.dbg.vcs.Mem.Cart.GetBank(pc),
+			ReturnAddress:  ret,
+			SP:             sp,
+			Interrupt:      res.Defn.Effect == instructions.Interrupt,
+		})
+	}
+
+	if cs.stepDepth >= 0 && len(cs.frames) <= cs.stepDepth {
+		cs.stepHalt = true
+	}
+}
+
+// stepTo prepares the call stack for a STEP OVER or STEP OUT. the step is
+// complete when the call stack has returned to the specified depth.
+func (cs *callStack) stepTo(depth int) {
+	cs.stepDepth = depth
+	cs.stepHalt = false
+}
+
+// cancelStep forgets about any step that is in progress.
+func (cs *callStack) cancelStep() {
+	cs.stepDepth = -1
+	cs.stepHalt = false
+}
+
+// stepComplete returns true if a STEP OVER or STEP OUT has reached its
+// target depth.
+func (cs *callStack) stepComplete() bool {
+	return cs.stepHalt
+}
+
+// chain returns a copy of the current call stack. the outermost call is the
+// first entry in the returned array.
+func (cs *callStack) chain() []CallFrame {
+	c := make([]CallFrame, len(cs.frames))
+	copy(c, cs.frames)
+	return c
+}
+
+// list the call stack. the innermost call is listed first.
+func (cs callStack) list() {
+	if len(cs.frames) == 0 {
+		cs.dbg.printLine(terminal.StyleFeedback, "no subroutines entered")
+		return
+	}
+
+	label := func(bank int, addr uint16) string {
+		if l, ok := cs.dbg.disasm.Symtable.Locations.Get(bank, addr); ok {
+			return fmt.Sprintf(" (%s)", l)
+		}
+		return ""
+	}
+
+	for i := len(cs.frames) - 1; i >= 0; i-- {
+		f := cs.frames[i]
+		call := "JSR"
+		if f.Interrupt {
+			call = "BRK"
+		}
+		cs.dbg.printLine(terminal.StyleFeedback, "% 2d: %#04x%s [bank %d] %s from %#04x%s [bank %d] returns to %#04x",
+			len(cs.frames)-1-i,
+			f.Subroutine, label(f.SubroutineBank, f.Subroutine), f.SubroutineBank,
+			call,
+			f.CallAddress, label(f.CallBank, f.CallAddress), f.CallBank,
+			f.ReturnAddress)
+	}
+}
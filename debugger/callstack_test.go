@@ -0,0 +1,224 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/debugger"
+)
+
+func (trm *mockTerm) testCallStack() {
+	// no subroutines have been entered at startup
+	trm.sndInput("STACK")
+	trm.cmpOutput("no subroutines entered")
+
+	trm.sndInput("BACKTRACE")
+	trm.cmpOutput("no subroutines entered")
+
+	// cannot step out of a subroutine that hasn't been entered
+	trm.sndInput("STEP OUT")
+	trm.cmpOutput("not in a subroutine")
+}
+
+// an 8k (F8) cartridge that exercises the call stack. the same code is in
+// both banks so execution can continue after a bank switch
+var callStackProgram = map[uint16][]uint8{
+	0x000: {
+		0xa2, 0xff, // LDX #$ff
+		0x9a,             // TXS
+		0x20, 0x40, 0xf0, // JSR outer
+		0x20, 0x50, 0xf0, // JSR pull
+		0xea, 0xea, // NOP NOP (skipped by pull)
+		0x20, 0x60, 0xf0, // f00b: JSR reset
+		0xea, 0xea, 0xea, // NOP NOP NOP (skipped by reset)
+		0x20, 0x70, 0xf0, // f011: JSR tramp
+		0xad, 0xf8, 0xff, // f014: LDA $fff8 (select bank 0)
+		0x20, 0x40, 0xf0, // f017: JSR outer
+		0x4c, 0x1a, 0xf0, // f01a: JMP f01a
+	},
+
+	// outer: calls inner and returns
+	0x040: {0x20, 0x48, 0xf0, 0x60},
+
+	// inner: returns after two NOPs
+	0x048: {0xea, 0xea, 0x60},
+
+	// pull: discards the return address and jumps back to the main program
+	0x050: {0x68, 0x68, 0x4c, 0x0b, 0xf0},
+
+	// reset: calls reset2, which resets the stack pointer and jumps back to
+	// the main program
+	0x060: {0x20, 0x68, 0xf0},
+	0x068: {0xa2, 0xff, 0x9a, 0x4c, 0x11, 0xf0},
+
+	// tramp: selects bank 1 and returns
+	0x070: {0xad, 0xf9, 0xff, 0x60},
+}
+
+// cmpStack checks the number of subroutines in the call stack
+func (trm *mockTerm) cmpStack(depth int) {
+	trm.t.Helper()
+
+	trm.sndInput("STACK")
+	trm.rcvOutput()
+
+	if depth == 0 {
+		if len(trm.output) != 1 || trm.output[0] != "no subroutines entered" {
+			trm.t.Errorf("unexpected call stack (%v) should be empty", trm.output)
+		}
+		return
+	}
+
+	if len(trm.output) != depth {
+		trm.t.Errorf("unexpected call stack depth (%v) should be %d", trm.output, depth)
+	}
+}
+
+// cmpPC checks the value of the program counter
+func (trm *mockTerm) cmpPC(pc string) {
+	trm.t.Helper()
+
+	trm.sndInput("CPU")
+	trm.rcvOutput()
+
+	if len(trm.output) == 0 || !strings.HasPrefix(trm.output[len(trm.output)-1], fmt.Sprintf("PC=%s ", pc)) {
+		trm.t.Errorf("unexpected CPU state (%v) should have PC=%s", trm.output, pc)
+	}
+}
+
+func (trm *mockTerm) testCallStackProgram() {
+	defer func() { trm.sndInput("QUIT") }()
+
+	// the symbols file is missing. ignore the error
+	trm.rcvOutput()
+
+	// LDX and TXS
+	trm.sndInput("STEP")
+	trm.sndInput("STEP")
+	trm.cmpStack(0)
+
+	// JSR to outer
+	trm.sndInput("STEP")
+	trm.sndInput("STACK")
+	trm.cmpOutput(" 0: 0xf040 [bank 0] JSR from 0xf003 [bank 0] returns to 0xf006")
+
+	// JSR to inner
+	trm.sndInput("STEP")
+	trm.cmpStack(2)
+	trm.cmpPC("f048")
+
+	// STEP OUT returns from inner but not from outer
+	trm.sndInput("STEP OUT")
+	trm.cmpStack(1)
+	trm.cmpPC("f043")
+
+	trm.sndInput("STEP OUT")
+	trm.cmpStack(0)
+	trm.cmpPC("f006")
+
+	// JSR to pull. the subroutine has returned as soon as the return address
+	// starts to be pulled from the stack
+	trm.sndInput("STEP")
+	trm.cmpStack(1)
+	trm.sndInput("STEP")
+	trm.cmpStack(0)
+
+	// second PLA and JMP back to the main program
+	trm.sndInput("STEP")
+	trm.sndInput("STEP")
+	trm.cmpStack(0)
+	trm.cmpPC("f00b")
+
+	// JSR to reset and to reset2
+	trm.sndInput("STEP")
+	trm.sndInput("STEP")
+	trm.cmpStack(2)
+
+	// LDX and TXS. resetting the stack pointer forgets both subroutines
+	trm.sndInput("STEP")
+	trm.cmpStack(2)
+	trm.sndInput("STEP")
+	trm.cmpStack(0)
+
+	// JMP back to the main program and JSR to tramp
+	trm.sndInput("STEP")
+	trm.sndInput("STEP")
+	trm.sndInput("STACK")
+	trm.cmpOutput(" 0: 0xf070 [bank 0] JSR from 0xf011 [bank 0] returns to 0xf014")
+
+	// the bank is switched before the return. the subroutine has still
+	// returned
+	trm.sndInput("STEP")
+	trm.cmpStack(1)
+	trm.sndInput("STEP")
+	trm.cmpStack(0)
+	trm.cmpPC("f014")
+
+	// LDA to select bank 0 again. STEP OVER the JSR to outer runs until
+	// outer, and the inner subroutine it calls, have returned
+	trm.sndInput("STEP")
+	trm.sndInput("STEP OVER")
+	trm.cmpStack(0)
+	trm.cmpPC("f01a")
+}
+
+func TestCallStack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "callstack")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	rom := make([]uint8, 8192)
+	for b := 0; b < 2; b++ {
+		bank := rom[b*4096 : (b+1)*4096]
+		for a, code := range callStackProgram {
+			copy(bank[a:], code)
+		}
+		bank[0xffc] = 0x00
+		bank[0xffd] = 0xf0
+	}
+	filename := filepath.Join(dir, "callstack.bin")
+	err = ioutil.WriteFile(filename, rom, 0644)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	trm := newMockTerm(t)
+
+	dbg, err := debugger.NewDebugger(&mockTV{}, &mockGUI{}, trm)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	go trm.testCallStackProgram()
+
+	err = dbg.Start("", cartridgeloader.Loader{Filename: filename, Format: "F8"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+}
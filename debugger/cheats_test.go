@@ -0,0 +1,43 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+func (trm *mockTerm) testCheats() {
+	trm.sndInput("CHEAT")
+	trm.cmpOutput("no cheats")
+
+	trm.sndInput("CHEAT ADD FREEZE 0x80 3 infinite lives")
+	trm.cmpOutput("freeze 0x0080 = 0x03 (infinite lives)")
+
+	trm.sndInput("CHEAT TOGGLE 1")
+	trm.cmpOutput("cheats error: no cheat #1")
+
+	trm.sndInput("CHEAT TOGGLE 0")
+	trm.cmpOutput(" 0: freeze 0x0080 = 0x03 [disabled] (infinite lives)")
+
+	trm.sndInput("CHEAT OFF")
+	trm.cmpOutput("cheats are turned off")
+
+	trm.sndInput("CHEAT ON")
+	trm.cmpOutput(" 0: freeze 0x0080 = 0x03 [disabled] (infinite lives)")
+
+	trm.sndInput("CHEAT REMOVE 0")
+	trm.cmpOutput("no cheats")
+}
@@ -27,17 +27,24 @@ import (
 	"strings"
 
 	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/cheats"
 	"github.com/jetsetilly/gopher2600/debugger/script"
 	"github.com/jetsetilly/gopher2600/debugger/terminal"
 	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
 	"github.com/jetsetilly/gopher2600/disassembly"
 	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/gifwriter"
 	"github.com/jetsetilly/gopher2600/gui"
 	"github.com/jetsetilly/gopher2600/hardware/cpu/registers"
 	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
 	"github.com/jetsetilly/gopher2600/hardware/riot/input"
 	"github.com/jetsetilly/gopher2600/patch"
+	"github.com/jetsetilly/gopher2600/playmode"
+	"github.com/jetsetilly/gopher2600/screenshot"
 	"github.com/jetsetilly/gopher2600/symbols"
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/television/colors"
+	"github.com/jetsetilly/gopher2600/videowriter"
 )
 
 var debuggerCommands *commandline.Commands
@@ -179,9 +186,29 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 		if err != nil {
 			return false, err
 		}
+		dbg.callStack.clear()
+		dbg.rewind.reset()
 		dbg.printLine(terminal.StyleFeedback, "machine reset")
 
 	case cmdRun:
+		back, _ := tokens.Get()
+		if strings.ToUpper(back) == "BACK" {
+			found, err := dbg.rewind.searchBack(dbg.rewind.breakCondition)
+			if err != nil {
+				return false, err
+			}
+			if !found {
+				dbg.printLine(terminal.StyleFeedback, "no breakpoint found. rewound as far as possible")
+				return false, nil
+			}
+
+			// the breakpoints have been met but we don't want them to halt
+			// the emulation again as soon as it continues
+			dbg.printLine(terminal.StyleFeedback, dbg.breakpoints.matching())
+			dbg.breakpoints.ignoreMatching()
+			return false, nil
+		}
+
 		dbg.runUntilHalt = true
 		return true, nil
 
@@ -200,6 +227,28 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 		case "VIDEO":
 			// changes quantum
 			dbg.quantum = QuantumVideo
+		case "OVER":
+			// run until the call stack is no deeper than it is now. if the
+			// next instruction is not a JSR this is the same as stepping one
+			// instruction
+			dbg.callStack.stepTo(dbg.callStack.depth())
+			dbg.runUntilHalt = true
+		case "OUT":
+			// run until the current subroutine has returned
+			if dbg.callStack.depth() == 0 {
+				return false, errors.New(errors.CommandError, "not in a subroutine")
+			}
+			dbg.callStack.stepTo(dbg.callStack.depth() - 1)
+			dbg.runUntilHalt = true
+		case "BACK":
+			// rewinding happens immediately. there is no need to continue
+			// the emulation
+			frame, _ := tokens.Get()
+			if strings.ToUpper(frame) == "FRAME" {
+				_, err := dbg.rewind.searchBack(dbg.rewind.frameCondition)
+				return false, err
+			}
+			return false, dbg.rewind.stepBack()
 		default:
 			// does not change quantum
 			tokens.Unget()
@@ -321,21 +370,28 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 
 	case cmdDisassembly:
 		bytecode := false
+		profile := false
 		bank := -1
 
 		arg, ok := tokens.Get()
-		if ok {
-			switch arg {
+		for ok {
+			switch strings.ToUpper(arg) {
 			case "BYTECODE":
 				bytecode = true
+			case "PROFILE":
+				profile = true
 			default:
 				bank, _ = strconv.Atoi(arg)
 			}
+			arg, ok = tokens.Get()
 		}
 
 		var err error
 
 		attr := disassembly.WriteAttr{ByteCode: bytecode}
+		if profile {
+			attr.Annotate = dbg.profiler.annotate
+		}
 		s := &bytes.Buffer{}
 
 		if bank == -1 {
@@ -375,6 +431,30 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			dbg.printLine(terminal.StyleFeedback, output.String())
 		}
 
+	case cmdSource:
+		count := sourceContext
+
+		var sl *symbols.SourceLine
+		var err error
+
+		arg, ok := tokens.Get()
+		if ok && isSourceLocation(arg) {
+			sl, _, err = parseSourceLocation(dbg, arg)
+		} else {
+			if ok {
+				count, err = strconv.Atoi(arg)
+				if err != nil {
+					return false, errors.New(errors.CommandError, fmt.Sprintf("invalid number of lines (%s)", arg))
+				}
+			}
+			sl, err = currentSource(dbg)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		dbg.printSource(sl, count)
+
 	case cmdSymbol:
 		tok, _ := tokens.Get()
 		switch strings.ToUpper(tok) {
@@ -398,6 +478,103 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 				dbg.disasm.Symtable.ListSymbols(dbg.printStyle(terminal.StyleFeedback))
 			}
 
+		case "ADD":
+			var tables []symbols.TableType
+
+			option, _ := tokens.Get()
+			switch strings.ToUpper(option) {
+			case "LOCATION":
+				tables = []symbols.TableType{symbols.LocationSymTable}
+			case "READ":
+				tables = []symbols.TableType{symbols.ReadSymTable}
+			case "WRITE":
+				tables = []symbols.TableType{symbols.WriteSymTable}
+			default:
+				tokens.Unget()
+			}
+
+			symbol, _ := tokens.Get()
+
+			a, _ := tokens.Get()
+			address, err := strconv.ParseUint(a, 0, 16)
+			if err != nil {
+				return false, errors.New(errors.CommandError, fmt.Sprintf("invalid address (%s)", a))
+			}
+
+			bank := symbols.AllBanks
+			if b, ok := tokens.Get(); ok {
+				bank, err = strconv.Atoi(b)
+				if err != nil || bank < 0 || bank >= dbg.vcs.Mem.Cart.NumBanks() {
+					return false, errors.New(errors.CommandError, fmt.Sprintf("invalid bank (%s)", b))
+				}
+			}
+
+			// if no table has been specified then the tables are chosen
+			// according to the area of memory the address is in
+			if tables == nil {
+				if memorymap.IsArea(uint16(address), memorymap.Cartridge) {
+					tables = []symbols.TableType{symbols.LocationSymTable, symbols.ReadSymTable}
+				} else {
+					tables = []symbols.TableType{symbols.ReadSymTable, symbols.WriteSymTable}
+				}
+			}
+
+			for _, t := range tables {
+				err = dbg.disasm.Symtable.AddBankSymbol(t, bank, uint16(address), symbol)
+				if err != nil {
+					return false, err
+				}
+			}
+
+			err = dbg.disasm.Reformat()
+			if err != nil {
+				return false, err
+			}
+
+			if bank == symbols.AllBanks {
+				dbg.printLine(terminal.StyleFeedback, "%s -> %#04x", symbol, address)
+			} else {
+				dbg.printLine(terminal.StyleFeedback, "%s -> %#04x [bank %d]", symbol, address, bank)
+			}
+
+		case "REMOVE":
+			table := symbols.UnspecifiedSymTable
+
+			option, _ := tokens.Get()
+			switch strings.ToUpper(option) {
+			case "LOCATION":
+				table = symbols.LocationSymTable
+			case "READ":
+				table = symbols.ReadSymTable
+			case "WRITE":
+				table = symbols.WriteSymTable
+			default:
+				tokens.Unget()
+			}
+
+			symbol, _ := tokens.Get()
+			err := dbg.disasm.Symtable.RemoveSymbol(table, symbol)
+			if err != nil {
+				return false, err
+			}
+
+			err = dbg.disasm.Reformat()
+			if err != nil {
+				return false, err
+			}
+
+			dbg.printLine(terminal.StyleFeedback, "%s removed", symbol)
+
+		case "SAVE":
+			if dbg.vcs.Mem.Cart.IsEjected() {
+				return false, errors.New(errors.CommandError, "no cartridge attached")
+			}
+			filename, err := dbg.disasm.Symtable.WriteUserSymbols(dbg.vcs.Mem.Cart.Filename)
+			if err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "%d user symbols saved to %s", dbg.disasm.Symtable.NumUserSymbols(), filename)
+
 		default:
 			symbol := tok
 			table, symbol, address, err := dbg.disasm.Symtable.SearchSymbol(symbol, symbols.UnspecifiedSymTable)
@@ -557,6 +734,37 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 
 		return false, nil
 
+	case cmdProfile:
+		arg, _ := tokens.Get()
+		switch strings.ToUpper(arg) {
+		case "ON":
+			dbg.profiler.enabled = true
+		case "OFF":
+			dbg.profiler.enabled = false
+		case "CLEAR":
+			dbg.profiler.clear()
+		case "REPORT":
+			n := 10
+			if arg, ok := tokens.Get(); ok {
+				var err error
+				n, err = strconv.Atoi(arg)
+				if err != nil || n <= 0 {
+					return false, errors.New(errors.CommandError, fmt.Sprintf("invalid number of entries (%s)", arg))
+				}
+			}
+			dbg.profiler.report(n)
+			return false, nil
+		}
+
+		if dbg.profiler.enabled {
+			dbg.printLine(terminal.StyleFeedback, "profiling on (%d frames)", dbg.profiler.frames())
+		} else {
+			dbg.printLine(terminal.StyleFeedback, "profiling off")
+		}
+
+	case cmdStack, cmdBacktrace:
+		dbg.callStack.list()
+
 	case cmdLast:
 		s := strings.Builder{}
 
@@ -743,6 +951,201 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			dbg.printInstrument(dbg.vcs.Mem.RAM)
 		}
 
+	case cmdCheat:
+		arg, _ := tokens.Get()
+		switch strings.ToUpper(arg) {
+		case "ADD":
+			k, _ := tokens.Get()
+			kind, err := cheats.ParseKind(k)
+			if err != nil {
+				return false, err
+			}
+
+			c := cheats.Cheat{Kind: kind, Enabled: true}
+
+			a, _ := tokens.Get()
+			if kind == cheats.Freeze {
+				// freeze addresses can be symbolic
+				ai := dbg.dbgmem.mapAddress(a, false)
+				if ai == nil {
+					return false, errors.New(errors.CommandError, fmt.Sprintf("invalid freeze address (%s)", a))
+				}
+				c.Address = ai.address
+			} else {
+				offset, err := strconv.ParseUint(a, 0, 16)
+				if err != nil {
+					return false, errors.New(errors.CommandError, fmt.Sprintf("invalid patch offset (%s)", a))
+				}
+				c.Address = uint16(offset)
+			}
+
+			v, _ := tokens.Get()
+			val, err := strconv.ParseUint(v, 0, 8)
+			if err != nil {
+				return false, errors.New(errors.CommandError, fmt.Sprintf("invalid cheat value (%s)", v))
+			}
+			c.Value = uint8(val)
+
+			c.Description = strings.TrimSpace(tokens.Remainder())
+			tokens.End()
+
+			err = dbg.cheats.Add(c)
+			if err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "%s", c)
+			return false, nil
+		case "REMOVE", "TOGGLE":
+			n, _ := tokens.Get()
+			num, err := strconv.Atoi(n)
+			if err != nil {
+				return false, errors.New(errors.CommandError, fmt.Sprintf("invalid cheat number (%s)", n))
+			}
+			if strings.ToUpper(arg) == "REMOVE" {
+				err = dbg.cheats.Remove(num)
+			} else {
+				err = dbg.cheats.Toggle(num)
+			}
+			if err != nil {
+				return false, err
+			}
+		case "ON":
+			err := dbg.cheats.SetEnabled(true)
+			if err != nil {
+				return false, err
+			}
+		case "OFF":
+			err := dbg.cheats.SetEnabled(false)
+			if err != nil {
+				return false, err
+			}
+		case "SAVE":
+			err := dbg.cheats.Save()
+			if err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "%d cheats saved", dbg.cheats.Len())
+			return false, nil
+		}
+
+		// list cheats after any change to the list
+		if dbg.cheats.Len() == 0 {
+			dbg.printLine(terminal.StyleFeedback, "no cheats")
+		} else {
+			dbg.cheats.List(dbg.printStyle(terminal.StyleFeedback))
+		}
+		if !dbg.cheats.IsEnabled() {
+			dbg.printLine(terminal.StyleFeedback, "cheats are turned off")
+		}
+
+	case cmdSearch:
+		// the number of candidates at or below which the candidates are listed
+		// automatically after the search has been narrowed
+		const autoList = 8
+
+		var cmp searchComparison
+
+		arg, _ := tokens.Get()
+		switch strings.ToUpper(arg) {
+		case "START":
+			dbg.search.start()
+		case "CLEAR":
+			dbg.search.clear()
+		case "LIST":
+			dbg.search.list()
+			return false, nil
+		case "EQUAL":
+			if v, ok := tokens.Get(); ok {
+				val, err := strconv.ParseUint(v, 0, 8)
+				if err != nil {
+					return false, errors.New(errors.CommandError, fmt.Sprintf("invalid search value (%s)", v))
+				}
+				cmp = func(_ uint8, c uint8) bool { return c == uint8(val) }
+			} else {
+				cmp = func(p uint8, c uint8) bool { return c == p }
+			}
+		case "UNCHANGED":
+			cmp = func(p uint8, c uint8) bool { return c == p }
+		case "CHANGED":
+			cmp = func(p uint8, c uint8) bool { return c != p }
+		case "INCREASED":
+			cmp = func(p uint8, c uint8) bool { return c > p }
+		case "DECREASED":
+			cmp = func(p uint8, c uint8) bool { return c < p }
+		case "SYMBOL", "WATCH":
+			n, _ := tokens.Get()
+			num, err := strconv.Atoi(n)
+			if err != nil {
+				return false, errors.New(errors.CommandError, fmt.Sprintf("invalid search result (%s)", n))
+			}
+			c, err := dbg.search.candidate(num)
+			if err != nil {
+				return false, err
+			}
+
+			if strings.ToUpper(arg) == "SYMBOL" {
+				symbol, _ := tokens.Get()
+				err = dbg.search.promoteSymbol(num, symbol)
+				if err != nil {
+					return false, err
+				}
+				dbg.printLine(terminal.StyleFeedback, "%s -> %#04x", symbol, c.readAddress)
+			} else {
+				err = dbg.search.promoteWatch(num)
+				if err != nil {
+					return false, err
+				}
+				dbg.printLine(terminal.StyleFeedback, "watching writes to %#04x", c.writeAddress)
+			}
+			return false, nil
+		}
+
+		if cmp != nil {
+			err := dbg.search.narrow(cmp)
+			if err != nil {
+				return false, err
+			}
+			if len(dbg.search.candidates) <= autoList {
+				dbg.search.list()
+				return false, nil
+			}
+		}
+
+		dbg.printLine(terminal.StyleFeedback, "%s", dbg.search)
+
+	case cmdSnapshot:
+		arg, ok := tokens.Get()
+		if !ok {
+			dbg.snapshots.list()
+			return false, nil
+		}
+
+		switch strings.ToUpper(arg) {
+		case "LIST":
+			dbg.snapshots.list()
+		case "DROP":
+			name, _ := tokens.Get()
+			err := dbg.snapshots.drop(name)
+			if err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "snapshot %s dropped", name)
+		case "CLEAR":
+			dbg.snapshots.clear()
+			dbg.printLine(terminal.StyleFeedback, "snapshots cleared")
+		default:
+			s := dbg.snapshots.add(arg)
+			dbg.printLine(terminal.StyleFeedback, "snapshot %s", s)
+		}
+
+	case cmdDiff:
+		from, _ := tokens.Get()
+		to, _ := tokens.Get()
+		err := dbg.snapshots.diff(from, to)
+		if err != nil {
+			return false, err
+		}
+
 	case cmdTimer:
 		dbg.printInstrument(dbg.vcs.RIOT.Timer)
 
@@ -774,7 +1177,96 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			option = strings.ToUpper(option)
 			switch option {
 			case "SPEC":
+				spec, ok := tokens.Get()
+				if ok {
+					err := dbg.tv.SetSpec(spec)
+					if err != nil {
+						return false, err
+					}
+				}
 				dbg.printLine(terminal.StyleInstrument, dbg.tv.GetSpec().ID)
+			case "CRT":
+				arg, _ := tokens.Get()
+				switch strings.ToUpper(arg) {
+				case "ON":
+					dbg.tv.SetCRTMode(true)
+				case "OFF":
+					dbg.tv.SetCRTMode(false)
+				}
+				s, _ := dbg.tv.GetState(television.ReqSyncState)
+				if television.SyncState(s) == 0 {
+					dbg.printLine(terminal.StyleInstrument, "in sync")
+				} else {
+					dbg.printLine(terminal.StyleInstrument, "%s", television.SyncState(s))
+				}
+			case "PALETTE":
+				spec := dbg.tv.GetSpec()
+
+				params, ok := dbg.paletteParams[spec.ID]
+				if !ok {
+					params, ok = spec.DefaultPaletteParams()
+				}
+
+				arg, _ := tokens.Get()
+				arg = strings.ToUpper(arg)
+				switch arg {
+				case "LOAD":
+					filename, _ := tokens.Get()
+					p, err := colors.LoadPalette(filename)
+					if err != nil {
+						return false, err
+					}
+					err = dbg.tv.SetPalette(p)
+					if err != nil {
+						return false, err
+					}
+					dbg.printLine(terminal.StyleFeedback, "palette loaded for %s", spec.ID)
+					return false, nil
+				case "DEFAULT":
+					err := dbg.tv.SetPalette(nil)
+					if err != nil {
+						return false, err
+					}
+					delete(dbg.paletteParams, spec.ID)
+					dbg.printLine(terminal.StyleFeedback, "default palette restored for %s", spec.ID)
+					return false, nil
+				case "":
+				default:
+					v, _ := tokens.Get()
+					f, err := strconv.ParseFloat(v, 64)
+					if err != nil {
+						return false, errors.New(errors.CommandError, fmt.Sprintf("%s value not valid (%s)", arg, v))
+					}
+
+					switch arg {
+					case "PHASE":
+						params.Phase = f
+					case "SATURATION":
+						params.Saturation = f
+					case "CONTRAST":
+						params.Contrast = f
+					case "BRIGHTNESS":
+						params.Brightness = f
+					case "GAMMA":
+						params.Gamma = f
+					}
+
+					p, err := spec.GeneratePalette(params)
+					if err != nil {
+						return false, err
+					}
+					err = dbg.tv.SetPalette(p)
+					if err != nil {
+						return false, err
+					}
+					dbg.paletteParams[spec.ID] = params
+				}
+
+				if !ok {
+					dbg.printLine(terminal.StyleInstrument, "%s palette can not be generated", spec.ID)
+				} else {
+					dbg.printLine(terminal.StyleInstrument, "%s", params)
+				}
 			default:
 				// already caught by command line ValidateTokens()
 			}
@@ -782,6 +1274,176 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 			dbg.printInstrument(dbg.tv)
 		}
 
+	case cmdScreenshot:
+		var opts screenshot.Options
+		var filename string
+
+		arg, ok := tokens.Get()
+		for ok {
+			switch strings.ToUpper(arg) {
+			case "OVERSCAN":
+				opts.Overscan = true
+			case "HBLANK":
+				opts.HBlank = true
+			case "ALT":
+				opts.Alt = true
+			default:
+				filename = arg
+			}
+			arg, ok = tokens.Get()
+		}
+
+		if filename == "" {
+			filename = screenshot.UniqueFilename(cartridgeloader.Loader{Filename: dbg.vcs.Mem.Cart.Filename}.ShortName())
+		}
+
+		err := dbg.screenshot.Save(filename, opts)
+		if err != nil {
+			return false, err
+		}
+		dbg.printLine(terminal.StyleFeedback, "screenshot saved to %s", filename)
+
+	case cmdVideo:
+		arg, _ := tokens.Get()
+		switch strings.ToUpper(arg) {
+		case "START":
+			if dbg.video != nil {
+				return false, errors.New(errors.CommandError, fmt.Sprintf("already recording to %s", dbg.video.Filename()))
+			}
+
+			filename, ok := tokens.Get()
+			if !ok {
+				filename = videowriter.UniqueFilename(cartridgeloader.Loader{Filename: dbg.vcs.Mem.Cart.Filename}.ShortName())
+			}
+
+			// the screenshot renderer already has a copy of the frame so
+			// we share it with the videowriter
+			vw, err := videowriter.New(dbg.tv, dbg.screenshot, filename)
+			if err != nil {
+				return false, err
+			}
+			dbg.video = vw
+			dbg.printLine(terminal.StyleFeedback, "recording video to %s", filename)
+		case "STOP":
+			if dbg.video == nil {
+				return false, errors.New(errors.CommandError, "not recording video")
+			}
+
+			// the videowriter can not be removed from the television but it
+			// will do nothing once it has ended
+			err := dbg.video.End()
+			if err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "%d frames recorded to %s", dbg.video.Frames(), dbg.video.Filename())
+			dbg.video = nil
+		default:
+			if dbg.video == nil {
+				dbg.printLine(terminal.StyleInstrument, "not recording video")
+			} else {
+				dbg.printLine(terminal.StyleInstrument, "recording to %s (%d frames)", dbg.video.Filename(), dbg.video.Frames())
+			}
+		}
+
+	case cmdGIF:
+		n, _ := tokens.Get()
+		frames, err := strconv.Atoi(n)
+		if err != nil || frames <= 0 {
+			return false, errors.New(errors.CommandError, fmt.Sprintf("number of frames not valid (%s)", n))
+		}
+
+		filename, ok := tokens.Get()
+		if !ok {
+			filename = gifwriter.UniqueFilename(cartridgeloader.Loader{Filename: dbg.vcs.Mem.Cart.Filename}.ShortName())
+		}
+
+		// the screenshot renderer already has a copy of the frame so we
+		// share it with the gifwriter
+		if dbg.gif == nil {
+			dbg.gif, err = gifwriter.New(dbg.tv, dbg.screenshot)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		dbg.gif.Start(frames)
+		dbg.gifFilename = filename
+		dbg.printLine(terminal.StyleFeedback, "capturing %d frames to %s", frames, filename)
+
+		// run emulation until the capture is complete
+		dbg.runUntilHalt = true
+		return true, nil
+
+	case cmdTrace:
+		arg, _ := tokens.Get()
+		switch strings.ToUpper(arg) {
+		case "START":
+			filename, ok := tokens.Get()
+			if !ok {
+				filename = uniqueTraceFilename(cartridgeloader.Loader{Filename: dbg.vcs.Mem.Cart.Filename}.ShortName())
+			}
+			err := dbg.trace.start(filename)
+			if err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "tracing to %s", filename)
+		case "STOP":
+			lines := dbg.trace.lines
+			err := dbg.trace.stop()
+			if err != nil {
+				return false, err
+			}
+			dbg.printLine(terminal.StyleFeedback, "%d lines written to %s", lines, dbg.trace.filename)
+		case "FILTER":
+			filter, _ := tokens.Get()
+			switch strings.ToUpper(filter) {
+			case "ADDRESS":
+				var r traceRange
+				for i, v := range []*int{&r.from, &r.to} {
+					a, _ := tokens.Get()
+					ai := dbg.dbgmem.mapAddress(a, true)
+					if ai == nil {
+						return false, errors.New(errors.CommandError, fmt.Sprintf("invalid address (%s)", a))
+					}
+					*v = int(ai.mappedAddress)
+					if i == 1 && r.to < r.from {
+						return false, errors.New(errors.CommandError, "address range is backwards")
+					}
+				}
+				dbg.trace.address = &r
+			case "BANK":
+				b, _ := tokens.Get()
+				bank, err := strconv.Atoi(b)
+				if err != nil || bank < 0 || bank >= dbg.vcs.Mem.Cart.NumBanks() {
+					return false, errors.New(errors.CommandError, fmt.Sprintf("invalid bank (%s)", b))
+				}
+				dbg.trace.bank = &traceRange{from: bank, to: bank}
+			case "FRAME":
+				var r traceRange
+				for _, v := range []*int{&r.from, &r.to} {
+					f, _ := tokens.Get()
+					n, err := strconv.Atoi(f)
+					if err != nil || n < 0 {
+						return false, errors.New(errors.CommandError, fmt.Sprintf("invalid frame number (%s)", f))
+					}
+					*v = n
+				}
+				if r.to < r.from {
+					return false, errors.New(errors.CommandError, "frame range is backwards")
+				}
+				dbg.trace.frame = &r
+			case "CLEAR":
+				dbg.trace.clearFilters()
+			}
+			if f := dbg.trace.filters(); f != "" {
+				dbg.printLine(terminal.StyleFeedback, "trace filter: %s", f)
+			} else {
+				dbg.printLine(terminal.StyleFeedback, "no trace filter")
+			}
+		default:
+			dbg.printLine(terminal.StyleInstrument, "%s", dbg.trace)
+		}
+
 	// information about the machine (sprites, playfield)
 	case cmdPlayer:
 		plyr := -1
@@ -975,6 +1637,15 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 		case "RIGHT":
 			event = input.Right
 			value = true
+		case "BUTTONC":
+			event = input.GenesisButtonC
+			value = true
+		case "TRIGGER":
+			event = input.BoosterGripTrigger
+			value = true
+		case "BOOSTER":
+			event = input.BoosterGripBooster
+			value = true
 
 		case "NOFIRE":
 			event = input.Fire
@@ -991,6 +1662,15 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 		case "NORIGHT":
 			event = input.Right
 			value = false
+		case "NOBUTTONC":
+			event = input.GenesisButtonC
+			value = false
+		case "NOTRIGGER":
+			event = input.BoosterGripTrigger
+			value = false
+		case "NOBOOSTER":
+			event = input.BoosterGripBooster
+			value = false
 		}
 
 		n, _ := strconv.Atoi(stick)
@@ -1011,22 +1691,134 @@ func (dbg *Debugger) processTokens(tokens *commandline.Tokens) (bool, error) {
 		pad, _ := tokens.Get()
 		key, _ := tokens.Get()
 
+		var hc *input.HandController
+
 		n, _ := strconv.Atoi(pad)
 		switch n {
 		case 0:
-			if strings.ToUpper(key) == "NONE" {
-				err = dbg.vcs.HandController0.Handle(input.KeypadUp, nil)
+			hc = dbg.vcs.RIOT.Input.HandController0
+		case 1:
+			hc = dbg.vcs.RIOT.Input.HandController1
+		}
+
+		switch strings.ToUpper(key) {
+		case "NONE":
+			err = hc.Handle(input.KeypadUp, nil)
+		case "LAYOUT":
+			layout, ok := tokens.Get()
+			if ok {
+				var l input.KeypadLayout
+				l, err = input.NewKeypadLayout(strings.ToUpper(layout))
+				if err == nil {
+					hc.SetKeypadLayout(l)
+				}
 			} else {
-				err = dbg.vcs.HandController0.Handle(input.KeypadDown, rune(key[0]))
+				dbg.printLine(terminal.StyleInstrument, "%s", hc.KeypadLayout())
 			}
+		case "UP":
+			key, _ = tokens.Get()
+			err = hc.Handle(input.KeypadUp, rune(key[0]))
+		default:
+			err = hc.Handle(input.KeypadDown, rune(key[0]))
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+	case cmdLightGun:
+		var err error
+
+		pad, _ := tokens.Get()
+
+		var hc *input.HandController
+
+		n, _ := strconv.Atoi(pad)
+		switch n {
+		case 0:
+			hc = dbg.vcs.RIOT.Input.HandController0
 		case 1:
-			if strings.ToUpper(key) == "NONE" {
-				err = dbg.vcs.HandController1.Handle(input.KeypadUp, nil)
+			hc = dbg.vcs.RIOT.Input.HandController1
+		}
+
+		action, _ := tokens.Get()
+		switch strings.ToUpper(action) {
+		case "AIM":
+			var x, y float64
+
+			v, _ := tokens.Get()
+			x, err = strconv.ParseFloat(v, 32)
+			if err != nil {
+				return false, errors.New(errors.CommandError, fmt.Sprintf("%s %s value not valid (%s)", cmdLightGun, action, v))
+			}
+			v, _ = tokens.Get()
+			y, err = strconv.ParseFloat(v, 32)
+			if err != nil {
+				return false, errors.New(errors.CommandError, fmt.Sprintf("%s %s value not valid (%s)", cmdLightGun, action, v))
+			}
+
+			err = hc.Handle(input.LightGunX, float32(x))
+			if err == nil {
+				err = hc.Handle(input.LightGunY, float32(y))
+			}
+		case "TRIGGER":
+			err = hc.Handle(input.LightGunTrigger, true)
+		case "NOTRIGGER":
+			err = hc.Handle(input.LightGunTrigger, false)
+		default:
+			x, y, ok := hc.LightGunAim()
+			if ok {
+				dbg.printLine(terminal.StyleInstrument, "aim: %.03f, %.03f", x, y)
 			} else {
-				err = dbg.vcs.HandController1.Handle(input.KeypadDown, rune(key[0]))
+				dbg.printLine(terminal.StyleFeedback, "light gun not plugged in")
+			}
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+	case cmdBindings:
+		if dbg.keyboard.Bindings == nil {
+			b, err := playmode.LoadBindings()
+			if err != nil {
+				return false, err
+			}
+			dbg.keyboard.Bindings = b
+		}
+
+		// underscores in key names stand in for spaces because the
+		// tokeniser splits arguments at every space
+		keyName := func() string {
+			k, _ := tokens.Get()
+			return strings.ReplaceAll(k, "_", " ")
+		}
+
+		option, _ := tokens.Get()
+		switch strings.ToUpper(option) {
+		case "SET":
+			key := playmode.ParseKey(keyName())
+			err := dbg.keyboard.Bindings.Set(key, tokens.Remainder())
+			if err != nil {
+				return false, err
 			}
+			tokens.End()
+		case "REMOVE":
+			key := playmode.ParseKey(keyName())
+			if !dbg.keyboard.Bindings.Remove(key) {
+				dbg.printLine(terminal.StyleFeedback, "%s is not bound", key)
+				return false, nil
+			}
+		case "DEFAULT":
+			dbg.keyboard.Bindings = playmode.DefaultBindings()
+		default:
+			for _, l := range dbg.keyboard.Bindings.List() {
+				dbg.printLine(terminal.StyleFeedback, "%s", l)
+			}
+			return false, nil
 		}
 
+		err := dbg.keyboard.Bindings.Save()
 		if err != nil {
 			return false, err
 		}
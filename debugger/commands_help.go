@@ -29,7 +29,13 @@ debugger itself (breakpoints, etc.) will not be reset.`,
 recording of the script and not cause the debugger to exit.`,
 
 	cmdRun: `Run emulator until next halt state. A halt state is one triggered by either
-a BREAK, TRAP or WATCH condition.`,
+a BREAK, TRAP or WATCH condition.
+
+RUN BACK runs the emulation in reverse until the most recent point at which
+a BREAK condition was met. If there is no such point in the recorded history
+then the emulation is returned to the earliest available point. TRAP and WATCH
+conditions are not considered when running in reverse. See STEP BACK for
+details of how reverse execution works.`,
 
 	cmdHalt: `Halt emulation. Does nothing if emulation is already halted.`,
 
@@ -45,7 +51,29 @@ command.
 
 In the above example, the emulation will run until the next frame is reached.
 Think of target stepping as a single use trap. Note that breakpoints, watches
-and traps still trigger a halt during a target step.`,
+and traps still trigger a halt during a target step.
+
+The OVER and OUT arguments step by subroutine. STEP OVER executes the next
+instruction and, if that instruction is a JSR, continues until the subroutine
+returns. STEP OUT continues until the current subroutine returns. Both work by
+watching the stack pointer so subroutines that return by way of a bank
+switching trampoline are handled correctly. Use the STACK command to see which
+subroutines have been entered.
+
+STEP BACK returns the emulation to the state it was in before the most recent
+CPU instruction. STEP BACK FRAME returns to the start of the most recent frame
+(or the previous frame if the emulation is already at the start of a frame).
+
+Reverse execution works by returning the machine to a checkpoint and then
+re-executing up to the required point. Checkpoints are taken every frame and
+the most recent 300 are kept. Joystick, panel and other input is recorded
+along with the checkpoints and delivered again during re-execution, so the
+emulation will follow the same path. Changes made directly with the debugger
+(eg. POKE) are not recorded. Once the emulation has been rewound, the recorded
+history after that point is forgotten.
+
+Frames produced during re-execution are not added to a VIDEO recording or to
+a GIF capture that is in progress.`,
 
 	cmdQuantum: `Change or view stepping quantum. The stepping quantum defines the frequency
 at which the emulation is checked and reported upon by the debugger.
@@ -88,7 +116,7 @@ argument meanwhile can be used to switch banks (if possible).`,
 
 	cmdDisassembly: `Display cartridge disassembly. By default, all banks will be displayed. Single
 banks can be displayed by specifying the bank number. Use BYTECODE to display raw bytes alongside
-the disassembly.`,
+the disassembly. Use PROFILE to display the cycle totals collected by the PROFILE command.`,
 
 	cmdGrep: `Simple string search (case insensitive) of the disassembly. Prints all matching lines
 in the disassembly to the termain.
@@ -96,7 +124,23 @@ in the disassembly to the termain.
 The scope of the GREP can be restricted to the MNEMONIC and OPERAND columns. By
 default GREP will consider the entire line.`,
 
-	cmdSymbol: `The SYMBOL command has two modes of operation. The first mode returns the address of
+	cmdSource: `Display the source code surrounding the instruction at the current PC address.
+The source code is taken from the listing file produced by DASM. The listing
+file should be in the same directory as the cartridge file and have the same
+name but with the .lst extension.
+
+By default, five lines either side of the current line are displayed. A
+different number of lines can be specified. Alternatively, a source location
+of the form file:line can be given, in which case the source code surrounding
+the first line of code at or after that line is displayed.
+
+	SOURCE 10
+	SOURCE kernel.asm:120
+
+Source locations can also be used to set breakpoints. See the help for the
+BREAK command.`,
+
+	cmdSymbol: `The SYMBOL command has several modes of operation. The first mode returns the address of
 the specified symbol. For example:
 
 	SYMBOL CXM1P
@@ -116,8 +160,32 @@ The above example will return every address that mirrors the primary address.
 The second mode of operation allows you to view all the symbols in each symbol
 table. There are three symbol tables: READ, WRITE and LOCATION.
 
+Symbols are read from the symbols file that accompanies the cartridge. The
+following formats are supported and are looked for in this order:
+
+	.sym	DASM symbols file (Stella exports symbols in the same format)
+	.dbg	ld65 debug file (created with the --dbgfile option)
+	.lbl	VICE style labels file (created by ld65 with the -Ln option)
+
 Note that a cartridge without an accompanying symbols file will only have the
-canonical Atari VCS symbols defined.`,
+canonical Atari VCS symbols defined.
+
+New symbols can be added with the ADD argument. By default, an address in
+cartridge space is added to the LOCATION and READ tables and any other address
+is added to the READ and WRITE tables. A specific table can be given instead.
+An optional bank number means that the symbol applies only to that cartridge
+bank. For example:
+
+	SYMBOL ADD lives 0x80
+	SYMBOL ADD LOCATION kernel 0xf100 1
+
+A symbol can be removed with the REMOVE argument. Without a table argument the
+symbol is removed from every table.
+
+Symbols that have been added with the ADD argument (or with the SEARCH command)
+are saved with the SAVE argument. They are saved in a file alongside the
+cartridge with the .user.sym extension and are read automatically the next
+time the cartridge is inserted.`,
 
 	cmdOnHalt: `Define commands to run whenever emulation is halted. A halt is
 caused by a BREAK, a TRAP, a WATCH or a manual interrupt. Specify multiple
@@ -141,6 +209,30 @@ By default the ONSTEP command is
 to display the raw bytes alongside the disassembly. The DEFN argument meanwhile
 will display the definition of the opcode that was used during execution.`,
 
+	cmdStack: `Display the subroutine call stack, innermost call first. Each entry shows the
+address and bank of the subroutine, the address and bank of the instruction
+that called it and the address it will return to.
+
+Calls are tracked by following the stack pointer, so return addresses that
+are discarded with PLA or a reset of the stack pointer are removed from the
+call stack as expected.`,
+
+	cmdBacktrace: `Synonym for the STACK command.`,
+
+	cmdProfile: `Count the number of CPU cycles consumed by each address, subroutine and
+cartridge bank. Profiling is turned on and off with the ON and OFF arguments.
+Counts accumulate until the CLEAR argument is used or a new cartridge is
+inserted.
+
+The REPORT argument prints the cycle totals for each bank, followed by the
+subroutines and addresses that have consumed the most cycles. By default, the
+ten busiest subroutines and addresses are shown. Values are also shown as an
+average per frame. Subroutine totals include the cycles spent in any nested
+subroutine. Addresses where an instruction crossed a page boundary (and so
+took an extra cycle) are noted.
+
+Per-instruction totals can also be seen with DISASSEMBLY PROFILE.`,
+
 	cmdMemMap: "Display high-level VCS memory map.",
 
 	cmdCPU: `Display the current state of the CPU. The SET argument can be used to change the
@@ -155,6 +247,83 @@ or numerically. Mulptiple data values will be poked into consecutive addresses.`
 	cmdRAM: `Display the current contents of RAM. The optional CART argument will display any
 additional RAM in the cartridge.`,
 
+	cmdCheat: `Add, remove and toggle cheats for the current cartridge. There are two types
+of cheat. A FREEZE cheat sets a RAM address to a value at the start of every
+frame. A PATCH cheat replaces a byte in the cartridge ROM. The location of a
+patch is the offset from the start of the cartridge file, in the same way as
+patch files.
+
+	CHEAT ADD FREEZE lives 0x03 infinite lives
+	CHEAT ADD PATCH 0x0123 0xea
+
+The optional description follows the value. Cheats are numbered in the list
+printed by the LIST argument and can be referred to by that number with the
+REMOVE and TOGGLE arguments. All cheats can be turned off and on with the OFF
+and ON arguments. This setting is also toggled with the CHEATS key binding.
+
+Cheats are loaded automatically when a cartridge is inserted. The SAVE
+argument stores the current list of cheats for the cartridge so that they are
+available next time.`,
+
+	cmdSearch: `Search RAM for addresses that behave in a particular way. This is useful for
+finding the addresses used by a game to store the number of lives or the score.
+Both VCS RAM and any cartridge RAM is searched.
+
+A new search is begun with the START argument. Every RAM address is a
+candidate at this point. The list of candidates is narrowed by comparing the
+current value of each candidate with its value when the search was started or
+last narrowed. The comparisons are:
+
+	CHANGED		value is different
+	UNCHANGED	value is the same
+	EQUAL		value is the same (or the value given)
+	INCREASED	value is larger
+	DECREASED	value is smaller
+
+For example, to find the address storing the number of lives:
+
+	SEARCH START
+	(play the game and lose a life)
+	SEARCH DECREASED
+	(play the game without losing a life)
+	SEARCH UNCHANGED
+
+Candidates are listed with the LIST argument and are listed automatically
+when there are only a few remaining. A candidate can be promoted to a symbol
+with the SYMBOL argument or to a WATCH (on write) with the WATCH argument.
+Candidates are referred to by their number in the list.
+
+	SEARCH SYMBOL 0 lives
+	SEARCH WATCH 0`,
+
+	cmdSnapshot: `Record the current contents of memory under the given name. VCS RAM, the
+TIA and RIOT registers and any cartridge RAM are recorded. A snapshot
+with the same name as an existing snapshot will replace it. The frame, scanline
+and horizontal position at which each snapshot was taken are noted.
+
+Snapshots are listed with the LIST argument (or with no argument at all).
+A snapshot can be removed with the DROP argument and all snapshots can be
+removed with the CLEAR argument. Snapshots are forgotten when a new cartridge
+is inserted.
+
+Snapshots are compared with the DIFF command.`,
+
+	cmdDiff: `List the addresses that differ between two snapshots taken with the SNAPSHOT
+command. If only one snapshot is named then it is compared with the current
+contents of memory. The old and new values are shown for each address along
+with the symbol for that address, if there is one.
+
+For the write-only TIA and RIOT registers (eg. COLUBK, GRP0, TIM64T) the value
+last written by the CPU is compared. Strobe registers (eg. WSYNC, RESP0, HMOVE)
+are not compared.
+
+For example, to see which addresses change during a single frame:
+
+	SNAPSHOT a
+	STEP FRAME
+	SNAPSHOT b
+	DIFF a b`,
+
 	cmdTimer: "Display the current state of the RIOT Timer.",
 
 	cmdTIA: `Display current state of the TIA. Without an arugment the command will display
@@ -188,7 +357,26 @@ current delay information for all TIA video components.`,
                               |
            volume ------------+`,
 
-	cmdTV: "Display the current TV state.",
+	cmdTV: `Display the current TV state.
+
+The SPEC argument shows the current TV specification. The specification can be
+changed by naming one of: AUTO, NTSC, PAL, SECAM, PAL60 or NTSC50. The PAL60
+specification is the PAL palette with the NTSC frame; and the NTSC50
+specification is the NTSC palette with the PAL frame.
+
+The CRT argument turns on (or off) the CRT mode of the television. In CRT mode
+the television behaves like a real CRT television when the signal is out of
+spec: the picture rolls when VSYNC is missing or late, and horizontal lock is
+lost when HSYNC occurs at an unexpected time. The current synchronisation state
+is shown.
+
+The PALETTE argument changes the palette used by the current specification.
+LOAD reads a palette file in the .pal format (128 or 256 RGB triplets) and
+DEFAULT restores the specification's normal palette. The remaining arguments
+generate a palette by adjusting one of the generation parameters: PHASE (in
+degrees) and SATURATION, CONTRAST, BRIGHTNESS and GAMMA. The SECAM palette can
+not be generated. With no further arguments the current generation parameters
+are shown.`,
 
 	cmdPlayer: `Display the current state of the player sprites. The player information to
 display can be selected with 0 or 1 arguments. Omitting this argument will show
@@ -356,6 +544,59 @@ The OVERLAY ON and OVERLAY OFF arguments toggle a debugging overlay. This
 overlay decorates the display with markers showing when during the drawing
 process key video events were triggered.`,
 
+	cmdScreenshot: `Save the current TV frame to a PNG file. The filename is generated
+from the name of the cartridge and the current time if it is not given.
+
+By default, only the visible area of the screen is saved. The OVERSCAN argument
+includes the scanlines above and below the visible area and the HBLANK argument
+includes the horizontal blanking period. The ALT argument saves the frame using
+the alternative, debugging colors.
+
+Note that if the emulation has halted part way through a frame, the screenshot
+will be of the previous, complete frame.`,
+
+	cmdVideo: `Record the TV output, video and audio, to an uncompressed AVI file. The
+filename is generated from the name of the cartridge and the current time if it
+is not given. Recording continues until the STOP argument is given or the
+debugger is quit. With no arguments, the recording state is shown.
+
+Recording begins when the TV is stable and the video is paced according to the
+TV specification. Note that the emulation speed of the debugger does not affect
+the playback speed of the video.`,
+
+	cmdGIF: `Run the emulation for the specified number of frames and save those frames
+as an animated GIF. The filename is generated from the name of the cartridge
+and the current time if it is not given.
+
+Capture begins with the next complete frame. If the emulation is halted before
+the capture is complete (by a breakpoint for example) then capture will continue
+when the emulation is resumed.`,
+
+	cmdTrace: `Write a trace of every executed instruction to a file. A filename will be
+generated if one is not specified with the START argument. Tracing continues
+until the STOP argument is used or the debugger exits.
+
+Each line of the file describes one instruction and has the following columns:
+
+	frame scanline horizpos bank address bytecode mnemonic operand registers flags
+
+Registers and flags are the values after the instruction has executed. The
+columns are fixed width, so trace files from two sessions can be compared with
+a standard diff tool.
+
+The FILTER argument restricts which instructions are written. Instructions can
+be filtered by ADDRESS range, cartridge BANK or FRAME range. Filters can be
+combined, in which case an instruction must pass every filter to be written.
+Use FILTER CLEAR to remove all filters. Filters can be set before or during a
+trace.
+
+	TRACE FILTER ADDRESS 0xf000 0xf0ff
+	TRACE FILTER FRAME 100 110
+	TRACE START trace.txt
+	TRACE STOP
+
+With no argument, the current state of the tracer is displayed.`,
+
 	// user input
 	cmdPanel: "Inspect and set front panel settings. Switches can be set or toggled..",
 
@@ -365,12 +606,53 @@ subsequent video cycles.
 Specify the player with the 0 or 1 arguments.
 
 Note that it is possible to set the stick combinations that would normally not
-be possible with a joystick. For example, LEFT and RIGHT set at the same time.`,
+be possible with a joystick. For example, LEFT and RIGHT set at the same time.
+
+The BUTTONC argument presses the C button of a Sega Genesis gamepad. The
+TRIGGER and BOOSTER arguments press the extra buttons of a CBS Booster Grip.
+Using any of these arguments will cause the controller to be treated as that
+type of controller from then on.`,
 
 	cmdKeypad: `Set keyboard input for Player 0 or Player 1 for the next and subsequent
 video cycles.
 
-Specify the player with the 0 or 1 arguments.`,
+Specify the player with the 0 or 1 arguments.
+
+More than one key can be held down at once. Release a single key with the UP
+argument or release all keys with the NONE argument.
+
+The LAYOUT argument sets the physical layout of the keypad: the standard
+keyboard controller, the Star Raiders touch pad or the kid's controller. The
+layout decides how the host keyboard maps to the keypad but otherwise makes no
+difference to the emulation.`,
+
+	cmdLightGun: `Aim or pull the trigger of the light gun plugged into the Player 0 or
+Player 1 port. Using the command will plug the light gun into the port if it is
+not already plugged in. Without any arguments the current aim point is shown.
+
+The AIM arguments are fractions of the visible screen, in the range 0.0 to
+1.0, with 0.0 being the left (or top) of the screen. Once plugged in, the
+light gun for Player 0 can also be aimed with the mouse.`,
+
+	cmdBindings: `List or change the key bindings. The key bindings decide how the host
+keyboard controls the emulation and are shared with the play mode.
+
+Keys are named as they are reported by the GUI, optionally prefixed with
+CTRL+, SHIFT+ or ALT+. Use an underscore in place of a space in the key name.
+For example:
+
+	BINDINGS SET CTRL+Keypad_5 P1 FIRE
+
+Actions are one of:
+
+	P0|P1 UP, DOWN, LEFT, RIGHT, FIRE, BUTTONC, TRIGGER, BOOSTER, PADDLEFIRE
+	PANEL SELECT, RESET, COLOR, P0PRO, P1PRO
+	PAUSE, RESET, SCREENSHOT, SAVE, LOAD
+	CROPPING, ALTCOLORS, OVERLAY, SCALEUP, SCALEDOWN
+
+The REMOVE argument removes the binding for a key and the DEFAULT argument
+restores the default bindings. Changes are saved to the keyBindings file in
+the resource path.`,
 
 	// halt conditions
 	cmdBreak: `Halt execution of the emulation when a specific value is "loaded" into a named
@@ -391,6 +673,14 @@ Becomes:
 
 	BREAK PC <address> & BANK <current bank>
 
+If the cartridge has a DASM listing file (see the help for the SOURCE command)
+the address can be given as a source location of the form file:line. The
+breakpoint is set on the first instruction at or after that line. For
+cartridges with more than one bank, the bank in which the code for that line
+can be found is added as a condition.
+
+	BREAK kernel.asm:120
+
 A break can depend on the condition of more than one target. Specify complex
 conditions with the & operative. For example:
 
@@ -410,6 +700,25 @@ execution breaks on SL 10 & X 255. After resumption, the break will not apply
 until X changes from 255 to something else and then back again, or SL is hit on
 the next frame and X again (or still) has a value of 255.i
 
+For more complex conditions, a break can be specified with an expression. The
+break will halt execution when the value of the expression changes to true
+(non-zero). For example:
+
+	BREAK [$80] > 5 && X == 0
+
+Expressions can contain numbers (decimal, or hexadecimal and binary with the $
+and % prefixes), arithmetic, comparison and logical operators, and brackets.
+Memory is read with square brackets. The following values can also be used:
+
+	the CPU registers (PC, A, X, Y and SP)
+	the CPU status flags (N, V, B, D, I, Z and C) which have a value of 0 or 1
+	the TV state (FRAMENUM, SCANLINE, HORIZPOS and abbreviations)
+	cartidge BANK
+	symbol names, which have the value of the address they represent
+
+Expressions are compiled when the break is added and so have very little
+effect on the speed of the emulation.
+
 Existing breakpoints can be reviewed with the LIST command and deleted with the
 DROP or CLEAR commands`,
 
@@ -418,6 +727,13 @@ to any other value. Traps are very similar to breakpoints in some ways.  They
 can be applied to the same set of targets as BREAK (see help for BREAK command
 for details).
 
+A trap can also be specified with an expression, in which case execution will
+halt when the value of the expression changes. For example:
+
+	TRAP [$80] & $0f
+
+See the help for BREAK for a description of expressions.
+
 Existing traps can be reviewed with the LIST command and deleted with the
 DROP or CLEAR commands`,
 
@@ -459,6 +775,16 @@ address.
 The above example will watch for the value 10 (decimal) to be written to memory
 address 0x80.
 
+A watch can also be given a condition in the form of an expression, either
+instead of or after a value. The watch will only halt execution if the
+condition is true at the time of the memory access. For example:
+
+	WATCH 0x80 SL > 100
+
+	WATCH 0x80 10 SL > 100
+
+See the help for BREAK for a description of expressions.
+
 Existing watches can be reviewed with the LIST command and deleted with the
 DROP or CLEAR commands`,
 
@@ -35,15 +35,23 @@ const (
 	cmdPatch       = "PATCH"
 	cmdDisassembly = "DISASSEMBLY"
 	cmdGrep        = "GREP"
+	cmdSource      = "SOURCE"
 	cmdSymbol      = "SYMBOL"
 	cmdOnHalt      = "ONHALT"
 	cmdOnStep      = "ONSTEP"
 	cmdLast        = "LAST"
+	cmdStack       = "STACK"
+	cmdBacktrace   = "BACKTRACE"
+	cmdProfile     = "PROFILE"
 	cmdMemMap      = "MEMMAP"
 	cmdCPU         = "CPU"
 	cmdPeek        = "PEEK"
 	cmdPoke        = "POKE"
 	cmdRAM         = "RAM"
+	cmdSearch      = "SEARCH"
+	cmdCheat       = "CHEAT"
+	cmdSnapshot    = "SNAPSHOT"
+	cmdDiff        = "DIFF"
 	cmdTimer       = "TIMER"
 	cmdTIA         = "TIA"
 	cmdAudio       = "AUDIO"
@@ -53,11 +61,17 @@ const (
 	cmdBall        = "BALL"
 	cmdPlayfield   = "PLAYFIELD"
 	cmdDisplay     = "DISPLAY"
+	cmdScreenshot  = "SCREENSHOT"
+	cmdVideo       = "VIDEO"
+	cmdGIF         = "GIF"
+	cmdTrace       = "TRACE"
 
 	// user input
-	cmdPanel  = "PANEL"
-	cmdStick  = "STICK"
-	cmdKeypad = "KEYPAD"
+	cmdPanel    = "PANEL"
+	cmdStick    = "STICK"
+	cmdKeypad   = "KEYPAD"
+	cmdLightGun = "LIGHTGUN"
+	cmdBindings = "BINDINGS"
 
 	// halt conditions
 	cmdBreak = "BREAK"
@@ -74,8 +88,8 @@ var commandTemplate = []string{
 	cmdReset,
 	cmdQuit,
 
-	cmdRun,
-	cmdStep + " (CPU|VIDEO|%<target>S)",
+	cmdRun + " (BACK)",
+	cmdStep + " (CPU|VIDEO|OVER|OUT|BACK (FRAME)|%<target>S)",
 	cmdHalt,
 	cmdQuantum + " (CPU|VIDEO)",
 	cmdScript + " [RECORD %<new file>F|END|%<file>F]",
@@ -83,36 +97,50 @@ var commandTemplate = []string{
 	cmdInsert + " %<cartridge>F",
 	cmdCartridge + " (BANK %<number>N)",
 	cmdPatch + " %<patch file>S",
-	cmdDisassembly + " (BYTECODE) (%<bank num>N)",
+	cmdDisassembly + " (BYTECODE) (PROFILE) (%<bank num>N)",
 	cmdGrep + " (MNEMONIC|OPERAND) %<search>S",
-	cmdSymbol + " [%<symbol>S (ALL|MIRRORS)|LIST (LOCATIONS|READ|WRITE)]",
+	cmdSource + " (%<lines>N|%<location>S)",
+	cmdSymbol + " [%<symbol>S (ALL|MIRRORS)|LIST (LOCATIONS|READ|WRITE)|ADD (LOCATION|READ|WRITE) %<symbol>S %<address>N (%<bank>N)|REMOVE (LOCATION|READ|WRITE) %<symbol>S|SAVE]",
 	cmdOnHalt + " (OFF|ON|%<command>S {%<commands>S})",
 	cmdOnStep + " (OFF|ON|%<command>S {%<commands>S})",
 	cmdLast + " (DEFN|BYTECODE)",
+	cmdStack,
+	cmdBacktrace,
+	cmdProfile + " (ON|OFF|CLEAR|REPORT (%<number>N))",
 	cmdMemMap,
 	cmdCPU + " (SET [PC|A|X|Y|SP] [%<register value>N])",
 	cmdPeek + " [%<address>S] {%<addresses>S}",
 	cmdPoke + " %<address>S [%<value>N] {%<values>N}",
 	cmdRAM + " (CART)",
+	cmdCheat + " (LIST|ADD [FREEZE|PATCH] %<address>S %<value>N {%<description>S}|REMOVE %<number>N|TOGGLE %<number>N|ON|OFF|SAVE)",
+	cmdSearch + " (START|CLEAR|LIST|EQUAL (%<value>N)|CHANGED|UNCHANGED|INCREASED|DECREASED|SYMBOL %<result>N %<symbol>S|WATCH %<result>N)",
+	cmdSnapshot + " (LIST|DROP %<name>S|CLEAR|%<name>S)",
+	cmdDiff + " [%<from>S] (%<to>S)",
 	cmdTimer,
 	cmdTIA + " (DELAYS)",
 	cmdAudio,
-	cmdTV + " (SPEC)",
+	cmdTV + " (SPEC (AUTO|NTSC|PAL|SECAM|PAL60|NTSC50)|CRT (ON|OFF)|PALETTE (LOAD %<file>F|DEFAULT|PHASE %<value>P|SATURATION %<value>P|CONTRAST %<value>P|BRIGHTNESS %<value>P|GAMMA %<value>P))",
 	cmdPlayer + " (0|1)",
 	cmdMissile + " (0|1)",
 	cmdBall,
 	cmdPlayfield,
 	cmdDisplay + " (ON|OFF|SCALE [%<scale value>P]|MASKING (ON|OFF)|ALT (ON|OFF)|OVERLAY (ON|OFF))", // see notes
+	cmdScreenshot + " {OVERSCAN|HBLANK|ALT} (%<file>F)",
+	cmdVideo + " (START (%<file>F)|STOP)",
+	cmdGIF + " [%<frames>N] (%<file>F)",
+	cmdTrace + " (START (%<file>F)|STOP|FILTER (ADDRESS %<from>S %<to>S|BANK %<bank>N|FRAME %<from>N %<to>N|CLEAR))",
 
 	// user input
 	cmdPanel + " (SET [P0PRO|P1PRO|P0AM|P1AM|COL|BW]|TOGGLE [P0|P1|COL])",
-	cmdStick + " [0|1] [LEFT|RIGHT|UP|DOWN|FIRE|NOLEFT|NORIGHT|NOUP|NODOWN|NOFIRE]",
-	cmdKeypad + " [0|1] [none|0|1|2|3|4|5|6|7|8|9|*|#]",
+	cmdStick + " [0|1] [LEFT|RIGHT|UP|DOWN|FIRE|BUTTONC|TRIGGER|BOOSTER|NOLEFT|NORIGHT|NOUP|NODOWN|NOFIRE|NOBUTTONC|NOTRIGGER|NOBOOSTER]",
+	cmdKeypad + " [0|1] [none|LAYOUT (STANDARD|TOUCHPAD|KIDS)|UP [0|1|2|3|4|5|6|7|8|9|*|#]|0|1|2|3|4|5|6|7|8|9|*|#]",
+	cmdLightGun + " [0|1] (AIM %<x>P %<y>P|TRIGGER|NOTRIGGER)",
+	cmdBindings + " (LIST|SET %<key>S %<action>S {%<action>S}|REMOVE %<key>S|DEFAULT)",
 
 	// halt conditions
-	cmdBreak + " [%<target>S %<value>N|%<pc value>S] {& %<target>S %<value>S|& %<value>S}",
+	cmdBreak + " [%<target>S %<value>N|%<pc value>S|%<expression>S] {& %<target>S %<value>S|& %<value>S|%<expression>S}",
 	cmdTrap + " [%<target>S] {%<targets>S}",
-	cmdWatch + " (READ|WRITE) [%<address>S] (%<value>S)",
+	cmdWatch + " (READ|WRITE) [%<address>S] (%<value>S) {%<condition>S}",
 	cmdList + " [BREAKS|TRAPS|WATCHES|ALL]",
 	cmdDrop + " [BREAK|TRAP|WATCH] %<number in list>N",
 	cmdClear + " [BREAKS|TRAPS|WATCHES|ALL]",
@@ -25,17 +25,23 @@ import (
 	"strings"
 
 	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/cheats"
 	"github.com/jetsetilly/gopher2600/debugger/script"
 	"github.com/jetsetilly/gopher2600/debugger/terminal"
 	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
 	"github.com/jetsetilly/gopher2600/disassembly"
 	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/gifwriter"
 	"github.com/jetsetilly/gopher2600/gui"
 	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/playmode"
 	"github.com/jetsetilly/gopher2600/reflection"
+	"github.com/jetsetilly/gopher2600/screenshot"
 	"github.com/jetsetilly/gopher2600/setup"
 	"github.com/jetsetilly/gopher2600/symbols"
 	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/television/colors"
+	"github.com/jetsetilly/gopher2600/videowriter"
 )
 
 const defaultOnHalt = "CPU; TV"
@@ -69,6 +75,26 @@ type Debugger struct {
 	// frame limiter
 	lmtr *limiter
 
+	// routes gamepad and keyboard events to the vcs
+	gamepads *playmode.Gamepads
+	keyboard *playmode.Keyboard
+
+	// keeps a copy of the tv frame for the SCREENSHOT command
+	screenshot *screenshot.Screenshot
+
+	// the current video recording started with the VIDEO command. nil if
+	// there is no recording in progress
+	video *videowriter.VideoWriter
+
+	// captures frames for the GIF command. created on first use. the
+	// filename is empty if there is no GIF capture in progress
+	gif         *gifwriter.GifWriter
+	gifFilename string
+
+	// palette generation parameters for each tv specification, indexed by
+	// specification ID. the parameters are changed with the TV PALETTE command
+	paletteParams map[string]colors.PaletteParams
+
 	// halt conditions
 	breakpoints *breakpoints
 	traps       *traps
@@ -78,6 +104,32 @@ type Debugger struct {
 	// things like "STEP FRAME".
 	stepTraps *traps
 
+	// subroutine call tracking. used by the STACK command and by STEP OVER
+	// and STEP OUT
+	callStack *callStack
+
+	// instruction trace started with the TRACE command
+	trace *tracer
+
+	// cycle profiler controlled with the PROFILE command
+	profiler *profiler
+
+	// RAM search started with the SEARCH command
+	search *ramSearch
+
+	// named memory snapshots taken with the SNAPSHOT command
+	snapshots *memSnapshots
+
+	// cheats for the attached cartridge
+	cheats *cheats.Cheats
+
+	// checkpoints and input history for the STEP BACK and RUN BACK commands
+	rewind *rewind
+
+	// the state saved by the SAVE key binding and restored by the LOAD key
+	// binding. nil if no state has been saved for the current cartridge
+	savedState *hardware.State
+
 	// commandOnHalt is the sequence of commands that runs when emulation
 	// halts. the string is parsed every time it's required, this is
 	// inefficient but it gives us enough flexibility to store multiple
@@ -148,6 +200,9 @@ func NewDebugger(tv television.Television, scr gui.GUI, term terminal.Terminal)
 		return nil, errors.New(errors.DebuggerError, err)
 	}
 
+	// cheats are reapplied every frame once they have been loaded
+	dbg.cheats = cheats.NewCheats(dbg.vcs)
+
 	// create instance of disassembly -- the same base structure is used
 	// for disassemblies subseuquent to the first one.
 	dbg.disasm, err = disassembly.FromMemory(dbg.vcs.Mem.Cart, nil)
@@ -176,6 +231,17 @@ func NewDebugger(tv television.Television, scr gui.GUI, term terminal.Terminal)
 		dbg.reflect = reflection.NewMonitor(dbg.vcs, mpx)
 	}
 
+	// screenshot renderer
+	dbg.screenshot, err = screenshot.NewScreenshot(dbg.tv)
+	if err != nil {
+		return nil, errors.New(errors.DebuggerError, err)
+	}
+
+	// gamepads and key bindings are handled the same way as in playmode
+	dbg.gamepads = playmode.NewGamepads()
+	dbg.keyboard = playmode.NewKeyboard()
+	dbg.paletteParams = make(map[string]colors.PaletteParams)
+
 	// set up breakpoints/traps
 	dbg.breakpoints, err = newBreakpoints(dbg)
 	if err != nil {
@@ -184,6 +250,18 @@ func NewDebugger(tv television.Television, scr gui.GUI, term terminal.Terminal)
 	dbg.traps = newTraps(dbg)
 	dbg.watches = newWatches(dbg)
 	dbg.stepTraps = newTraps(dbg)
+	dbg.callStack = newCallStack(dbg)
+	dbg.trace = newTracer(dbg)
+	dbg.profiler = newProfiler(dbg)
+	dbg.search = newRAMSearch(dbg)
+	dbg.snapshots = newMemSnapshots(dbg)
+
+	// input events are noted so that they can be delivered again when the
+	// emulation is rewound and re-executed
+	dbg.rewind = newRewind(dbg)
+	dbg.vcs.HandController0.AttachEventRecorder(dbg.rewind)
+	dbg.vcs.HandController1.AttachEventRecorder(dbg.rewind)
+	dbg.vcs.Panel.AttachEventRecorder(dbg.rewind)
 
 	// make synchronisation channels
 	dbg.events = &terminal.ReadEvents{
@@ -256,6 +334,13 @@ func (dbg *Debugger) Start(initScript string, cartload cartridgeloader.Loader) e
 		}
 	}()
 
+	// close any trace file that is still open
+	defer func() {
+		if dbg.trace.active() {
+			_ = dbg.trace.stop()
+		}
+	}()
+
 	// prepare and run main input loop. inputLoop will not return until
 	// debugging session is to be terminated
 	err = dbg.inputLoop(dbg.term, false)
@@ -296,11 +381,26 @@ func (dbg *Debugger) loadCartridge(cartload cartridgeloader.Loader) error {
 	// repoint debug memory's symbol table
 	dbg.dbgmem.symtable = dbg.disasm.Symtable
 
+	dbg.callStack.clear()
+	dbg.profiler.clear()
+	dbg.search.clear()
+	dbg.snapshots.clear()
+	dbg.savedState = nil
+
+	err = dbg.cheats.Load()
+	if err != nil {
+		dbg.printLine(terminal.StyleError, "%s", err)
+		// continuing because the cartridge is still usable without cheats
+	}
+
 	err = dbg.vcs.TV.Reset()
 	if err != nil {
 		return err
 	}
 
+	// the first checkpoint is the freshly loaded cartridge
+	dbg.rewind.reset()
+
 	return nil
 }
 
@@ -365,3 +465,21 @@ func (dbg *Debugger) SetFPS(fps float32) {
 func (dbg *Debugger) GetReqFPS() float32 {
 	return dbg.lmtr.getReqFPS()
 }
+
+// gifDone checks whether the capture started by the GIF command has completed.
+// if it has then the GIF is saved and the function returns true
+func (dbg *Debugger) gifDone() bool {
+	if dbg.gifFilename == "" || !dbg.gif.Done() {
+		return false
+	}
+
+	err := dbg.gif.Save(dbg.gifFilename)
+	if err != nil {
+		dbg.printLine(terminal.StyleError, "%s", err)
+	} else {
+		dbg.printLine(terminal.StyleFeedback, "%d frames saved to %s", dbg.gif.Frames(), dbg.gifFilename)
+	}
+	dbg.gifFilename = ""
+
+	return true
+}
@@ -22,6 +22,9 @@ package debugger_test
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -30,6 +33,7 @@ import (
 	"github.com/jetsetilly/gopher2600/debugger/terminal"
 	"github.com/jetsetilly/gopher2600/gui"
 	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/television/colors"
 )
 
 type mockTV struct{}
@@ -95,6 +99,21 @@ func (t *mockTV) GetLastSignal() television.SignalAttributes {
 	return television.SignalAttributes{}
 }
 
+func (t *mockTV) SetCRTMode(_ bool) {
+}
+
+func (t *mockTV) SetPalette(_ colors.Palette) error {
+	return nil
+}
+
+func (t *mockTV) SaveState() interface{} {
+	return nil
+}
+
+func (t *mockTV) RestoreState(_ interface{}) error {
+	return nil
+}
+
 func (g *mockGUI) Destroy(_ io.Writer) {
 }
 
@@ -193,6 +212,13 @@ func (trm *mockTerm) prtOutput() {
 func (trm *mockTerm) cmpOutput(s string) bool {
 	trm.rcvOutput()
 
+	// a running cartridge can delay the debugger's response beyond the
+	// rcvOutput() timeout. wait for up to a second for output that we are
+	// expecting
+	for i := 0; i < 100 && len(trm.output) == 0 && len(s) != 0; i++ {
+		trm.rcvOutput()
+	}
+
 	if len(trm.output) == 0 {
 		if len(s) != 0 {
 			trm.t.Errorf(fmt.Sprintf("unexpected debugger output (nothing) should be (%s)", s))
@@ -216,6 +242,15 @@ func (trm *mockTerm) testSequence() {
 	trm.testBreakpoints()
 	trm.testTraps()
 	trm.testWatches()
+	trm.testCallStack()
+	trm.testTrace()
+	trm.testProfiler()
+	trm.testRAMSearch()
+	trm.testCheats()
+	trm.testRewind()
+	trm.testSnapshot()
+	trm.testSource()
+	trm.testSymbols()
 }
 
 func TestDebugger_withNonExistantInitScript(t *testing.T) {
@@ -249,3 +284,81 @@ func TestDebugger(t *testing.T) {
 		t.Fatalf(err.Error())
 	}
 }
+
+// a 4k cartridge that produces a stable NTSC frame. the frame counter at
+// address 0x80 is incremented by a subroutine once per frame
+var frameProgram = map[uint16][]uint8{
+	0x000: {
+		0xa2, 0xff, // LDX #$ff
+		0x9a,       // TXS
+		0xa9, 0x02, // f003: LDA #$02
+		0x85, 0x00, // STA VSYNC
+		0x85, 0x02, // STA WSYNC
+		0x85, 0x02, // STA WSYNC
+		0x85, 0x02, // STA WSYNC
+		0xa9, 0x00, // LDA #$00
+		0x85, 0x00, // STA VSYNC
+		0xa2, 0x00, // LDX #$00
+		0x85, 0x02, // f013: STA WSYNC
+		0xca,       // DEX
+		0xd0, 0xfb, // BNE f013
+		0xa2, 0x03, // LDX #$03
+		0x85, 0x02, // f01a: STA WSYNC
+		0xca,       // DEX
+		0xd0, 0xfb, // BNE f01a
+		0x20, 0x40, 0xf0, // JSR count
+		0x4c, 0x03, 0xf0, // JMP f003
+	},
+
+	// count: increments the frame counter
+	0x040: {
+		0xe6, 0x80, // INC $80
+		0x60, // RTS
+	},
+}
+
+// runProgram writes the program to a temporary 4k cartridge file and starts
+// the debugger with that cartridge and a real television. the test function
+// is run in its own goroutine and should send the QUIT command when it has
+// finished.
+func runProgram(t *testing.T, program map[uint16][]uint8, test func(trm *mockTerm)) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "program")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	rom := make([]uint8, 4096)
+	for a, code := range program {
+		copy(rom[a:], code)
+	}
+	rom[0xffc] = 0x00
+	rom[0xffd] = 0xf0
+
+	filename := filepath.Join(dir, "program.bin")
+	err = ioutil.WriteFile(filename, rom, 0644)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	trm := newMockTerm(t)
+
+	dbg, err := debugger.NewDebugger(tv, &mockGUI{}, trm)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	go test(trm)
+
+	err = dbg.Start("", cartridgeloader.Loader{Filename: filename, Format: "AUTO"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+}
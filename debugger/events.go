@@ -37,38 +37,7 @@ func (dbg *Debugger) guiEventHandler(ev gui.Event) error {
 		return errors.New(errors.UserInterrupt)
 
 	case gui.EventKeyboard:
-		var handled bool
-
-		// check playmode key presses first
-		handled, err = playmode.KeyboardEventHandler(ev, dbg.vcs)
-		if err != nil {
-			break // switch ev.(type)
-		}
-
-		if !handled {
-			if ev.Down && ev.Mod == gui.KeyModNone {
-				switch ev.Key {
-				case "F12":
-					err = dbg.scr.SetFeature(gui.ReqToggleCropping)
-
-				case "F11":
-					// toggle debugging colours
-					err = dbg.scr.SetFeature(gui.ReqToggleAltColors)
-				case "F10":
-					// toggle overlay
-					err = dbg.scr.SetFeature(gui.ReqToggleOverlay)
-
-				case "=":
-					fallthrough // equal sign is the same as plus, for convenience
-				case "+":
-					// increase scaling
-					err = dbg.scr.SetFeature(gui.ReqIncScale)
-				case "-":
-					// decrease window scanling
-					err = dbg.scr.SetFeature(gui.ReqDecScale)
-				}
-			}
-		}
+		_, err = dbg.keyboard.EventHandler(ev, dbg.vcs, dbg.actionHandler)
 
 	case gui.EventDbgMouseButton:
 		switch ev.Button {
@@ -88,6 +57,9 @@ func (dbg *Debugger) guiEventHandler(ev gui.Event) error {
 	case gui.EventMouseMotion:
 		_, err := playmode.MouseMotionEventHandler(ev, dbg.vcs)
 		return err
+
+	case gui.EventGamepadConnected, gui.EventGamepadDisconnected, gui.EventGamepadButton, gui.EventGamepadAxis:
+		_, err = dbg.gamepads.EventHandler(ev, dbg.vcs)
 	}
 
 	// wrap error in GUIEventError
@@ -99,6 +71,66 @@ func (dbg *Debugger) guiEventHandler(ev gui.Event) error {
 
 }
 
+// actionHandler performs the emulator actions of the key bindings that are
+// meaningful in the debugger
+func (dbg *Debugger) actionHandler(act playmode.BindingAction) (bool, error) {
+	switch act {
+	case playmode.BindPause:
+		// halt emulation in the same way as ctrl-c
+		dbg.runUntilHalt = false
+		return true, nil
+	case playmode.BindReset:
+		err := dbg.vcs.Reset()
+		if err != nil {
+			return true, err
+		}
+		dbg.callStack.clear()
+		err = dbg.tv.Reset()
+		if err != nil {
+			return true, err
+		}
+		dbg.rewind.reset()
+		return true, nil
+	case playmode.BindSave:
+		state, err := dbg.vcs.SaveState()
+		if err != nil {
+			return true, err
+		}
+		dbg.savedState = state
+		return true, nil
+	case playmode.BindLoad:
+		if dbg.savedState == nil {
+			return true, nil
+		}
+		err := dbg.vcs.RestoreState(dbg.savedState)
+		if err != nil {
+			return true, err
+		}
+		// the call stack and the rewind history no longer describe the
+		// emulation
+		dbg.callStack.clear()
+		dbg.rewind.reset()
+		return true, nil
+	case playmode.BindCheats:
+		return true, dbg.cheats.SetEnabled(!dbg.cheats.IsEnabled())
+	case playmode.BindScreenshot:
+		_, err := dbg.parseCommand(cmdScreenshot, false, false)
+		return true, err
+	case playmode.BindCropping:
+		return true, dbg.scr.SetFeature(gui.ReqToggleCropping)
+	case playmode.BindAltColors:
+		return true, dbg.scr.SetFeature(gui.ReqToggleAltColors)
+	case playmode.BindOverlay:
+		return true, dbg.scr.SetFeature(gui.ReqToggleOverlay)
+	case playmode.BindScaleUp:
+		return true, dbg.scr.SetFeature(gui.ReqIncScale)
+	case playmode.BindScaleDown:
+		return true, dbg.scr.SetFeature(gui.ReqDecScale)
+	}
+
+	return false, nil
+}
+
 // returns true if the terminal needs reading
 func (dbg *Debugger) checkEvents(inputter terminal.Input) (bool, error) {
 	var err error
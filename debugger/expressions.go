@@ -0,0 +1,564 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// expressions are used by breakpoints, traps and watches to describe
+// conditions that are more complex than a single target/value pair. for
+// example:
+//
+//	[$80] > 5 && X == 0
+//
+// expressions are compiled once, when the breakpoint (or trap, or watch) is
+// added, into a tree of functions. evaluating the expression is then a matter
+// of calling the function at the root of the tree.
+
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/symbols"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// expression is a compiled expression
+type expression struct {
+	// the normalised source of the expression. two expressions with the
+	// same source are the same expression
+	source string
+
+	eval func() int
+}
+
+func (ex *expression) String() string {
+	if ex == nil {
+		return ""
+	}
+	return ex.source
+}
+
+// conditionTarget returns a target with a value of true if the expression
+// evaluates to a non-zero value
+func (ex *expression) conditionTarget() *target {
+	return &target{
+		label: ex.source,
+		currentValue: func() interface{} {
+			return ex.eval() != 0
+		},
+	}
+}
+
+// valueTarget returns a target with the value of the expression
+func (ex *expression) valueTarget() *target {
+	return &target{
+		label: ex.source,
+		currentValue: func() interface{} {
+			return ex.eval()
+		},
+	}
+}
+
+// isExpression returns true if the input looks like an expression rather
+// than a list of targets and values. the single character & and | tokens used
+// to join breakpoint conditions are not considered to be expression operators.
+func isExpression(input string) bool {
+	for _, s := range strings.Fields(input) {
+		if s == "&" || s == "|" || isSourceLocation(s) {
+			continue
+		}
+		if strings.ContainsAny(s, "[]()=<>!~+*/^&|") {
+			return true
+		}
+	}
+	return false
+}
+
+// the binary operators in order of precedence, lowest first. operators in
+// the same group have the same precedence
+var binaryOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// the list of operator tokens recognised by the lexer. longer operators must
+// come before the shorter operators they begin with
+var operatorTokens = []string{
+	"||", "&&", "==", "!=", "<=", ">=", "<<", ">>",
+	"|", "^", "&", "<", ">", "+", "-", "*", "/", "%", "!", "~", "[", "]", "(", ")",
+}
+
+type exprTokenType int
+
+const (
+	exprNumber exprTokenType = iota
+	exprIdentifier
+	exprOperator
+)
+
+type exprToken struct {
+	typ   exprTokenType
+	text  string
+	value int
+}
+
+// lexExpression divides the input into tokens
+func lexExpression(input string) ([]exprToken, error) {
+	var toks []exprToken
+
+	// whether the previous token was a value (a number, an identifier or a
+	// closing bracket). used to decide whether % is the modulo operator or the
+	// beginning of a binary number
+	value := false
+
+	i := 0
+	for i < len(input) {
+		c := input[i]
+
+		if c == ' ' || c == '\t' {
+			i++
+			continue
+		}
+
+		// numbers
+		if (c >= '0' && c <= '9') || c == '$' || (c == '%' && !value) {
+			j := i + 1
+			for j < len(input) && isIdentifierChar(input[j]) {
+				j++
+			}
+			s := input[i:j]
+
+			var v uint64
+			var err error
+			switch c {
+			case '$':
+				v, err = strconv.ParseUint(s[1:], 16, 32)
+			case '%':
+				v, err = strconv.ParseUint(s[1:], 2, 32)
+			default:
+				v, err = strconv.ParseUint(s, 0, 32)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid number (%s)", s)
+			}
+
+			toks = append(toks, exprToken{typ: exprNumber, text: s, value: int(v)})
+			value = true
+			i = j
+			continue
+		}
+
+		// identifiers
+		if isIdentifierChar(c) {
+			j := i + 1
+			for j < len(input) && isIdentifierChar(input[j]) {
+				j++
+			}
+			toks = append(toks, exprToken{typ: exprIdentifier, text: input[i:j]})
+			value = true
+			i = j
+			continue
+		}
+
+		// operators
+		found := false
+		for _, op := range operatorTokens {
+			if strings.HasPrefix(input[i:], op) {
+				toks = append(toks, exprToken{typ: exprOperator, text: op})
+				value = op == ")" || op == "]"
+				i += len(op)
+				found = true
+				break // for loop
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unexpected character (%c)", c)
+		}
+	}
+
+	return toks, nil
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// exprNode is a node in the compiled expression tree. constant nodes are
+// folded during compilation
+type exprNode struct {
+	eval     func() int
+	constant bool
+	value    int
+}
+
+func constantNode(v int) exprNode {
+	return exprNode{
+		eval:     func() int { return v },
+		constant: true,
+		value:    v,
+	}
+}
+
+// exprCompiler is a recursive descent parser that turns tokens into a tree of
+// exprNodes
+type exprCompiler struct {
+	dbg  *Debugger
+	toks []exprToken
+	curr int
+}
+
+// compileExpression compiles the input into an expression. Returns an error
+// if the input is not a valid expression.
+func compileExpression(dbg *Debugger, input string) (*expression, error) {
+	toks, err := lexExpression(input)
+	if err != nil {
+		return nil, errors.New(errors.ExpressionError, err)
+	}
+	if len(toks) == 0 {
+		return nil, errors.New(errors.ExpressionError, "empty expression")
+	}
+
+	cmp := &exprCompiler{dbg: dbg, toks: toks}
+
+	n, err := cmp.binary(0)
+	if err != nil {
+		return nil, errors.New(errors.ExpressionError, err)
+	}
+
+	if cmp.curr < len(cmp.toks) {
+		return nil, errors.New(errors.ExpressionError, fmt.Sprintf("unexpected %s", cmp.toks[cmp.curr].text))
+	}
+
+	// normalise source by joining the tokens with single spaces. brackets
+	// are not separated from their contents
+	s := strings.Builder{}
+	for i, t := range toks {
+		if i > 0 && t.text != "]" && t.text != ")" && toks[i-1].text != "[" && toks[i-1].text != "(" &&
+			!(toks[i-1].typ == exprOperator && (toks[i-1].text == "!" || toks[i-1].text == "~")) {
+			s.WriteString(" ")
+		}
+		s.WriteString(t.text)
+	}
+
+	return &expression{source: s.String(), eval: n.eval}, nil
+}
+
+func (cmp *exprCompiler) peek() (exprToken, bool) {
+	if cmp.curr >= len(cmp.toks) {
+		return exprToken{}, false
+	}
+	return cmp.toks[cmp.curr], true
+}
+
+func (cmp *exprCompiler) expect(op string) error {
+	t, ok := cmp.peek()
+	if !ok || t.typ != exprOperator || t.text != op {
+		return fmt.Errorf("expected %s", op)
+	}
+	cmp.curr++
+	return nil
+}
+
+// binary parses binary operators of the precedence level and above
+func (cmp *exprCompiler) binary(level int) (exprNode, error) {
+	if level >= len(binaryOperators) {
+		return cmp.unary()
+	}
+
+	lhs, err := cmp.binary(level + 1)
+	if err != nil {
+		return exprNode{}, err
+	}
+
+	for {
+		t, ok := cmp.peek()
+		if !ok || t.typ != exprOperator {
+			return lhs, nil
+		}
+
+		var op string
+		for _, o := range binaryOperators[level] {
+			if t.text == o {
+				op = o
+				break // for loop
+			}
+		}
+		if op == "" {
+			return lhs, nil
+		}
+		cmp.curr++
+
+		rhs, err := cmp.binary(level + 1)
+		if err != nil {
+			return exprNode{}, err
+		}
+
+		lhs = binaryNode(op, lhs, rhs)
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func binaryNode(op string, lhs exprNode, rhs exprNode) exprNode {
+	l := lhs.eval
+	r := rhs.eval
+
+	var f func() int
+
+	switch op {
+	case "||":
+		f = func() int { return boolToInt(l() != 0 || r() != 0) }
+	case "&&":
+		f = func() int { return boolToInt(l() != 0 && r() != 0) }
+	case "|":
+		f = func() int { return l() | r() }
+	case "^":
+		f = func() int { return l() ^ r() }
+	case "&":
+		f = func() int { return l() & r() }
+	case "==":
+		f = func() int { return boolToInt(l() == r()) }
+	case "!=":
+		f = func() int { return boolToInt(l() != r()) }
+	case "<":
+		f = func() int { return boolToInt(l() < r()) }
+	case "<=":
+		f = func() int { return boolToInt(l() <= r()) }
+	case ">":
+		f = func() int { return boolToInt(l() > r()) }
+	case ">=":
+		f = func() int { return boolToInt(l() >= r()) }
+	case "<<":
+		f = func() int { return l() << uint(r()&0x1f) }
+	case ">>":
+		f = func() int { return l() >> uint(r()&0x1f) }
+	case "+":
+		f = func() int { return l() + r() }
+	case "-":
+		f = func() int { return l() - r() }
+	case "*":
+		f = func() int { return l() * r() }
+	case "/":
+		// division by zero results in zero
+		f = func() int {
+			d := r()
+			if d == 0 {
+				return 0
+			}
+			return l() / d
+		}
+	case "%":
+		f = func() int {
+			d := r()
+			if d == 0 {
+				return 0
+			}
+			return l() % d
+		}
+	}
+
+	if lhs.constant && rhs.constant {
+		return constantNode(f())
+	}
+
+	return exprNode{eval: f}
+}
+
+func (cmp *exprCompiler) unary() (exprNode, error) {
+	t, ok := cmp.peek()
+	if ok && t.typ == exprOperator {
+		var f func(int) int
+
+		switch t.text {
+		case "-":
+			f = func(v int) int { return -v }
+		case "!":
+			f = func(v int) int { return boolToInt(v == 0) }
+		case "~":
+			f = func(v int) int { return ^v }
+		}
+
+		if f != nil {
+			cmp.curr++
+			n, err := cmp.unary()
+			if err != nil {
+				return exprNode{}, err
+			}
+			if n.constant {
+				return constantNode(f(n.value)), nil
+			}
+			e := n.eval
+			return exprNode{eval: func() int { return f(e()) }}, nil
+		}
+	}
+
+	return cmp.primary()
+}
+
+func (cmp *exprCompiler) primary() (exprNode, error) {
+	t, ok := cmp.peek()
+	if !ok {
+		return exprNode{}, fmt.Errorf("unexpected end of expression")
+	}
+	cmp.curr++
+
+	switch t.typ {
+	case exprNumber:
+		return constantNode(t.value), nil
+
+	case exprIdentifier:
+		return cmp.identifier(t.text)
+	}
+
+	switch t.text {
+	case "(":
+		n, err := cmp.binary(0)
+		if err != nil {
+			return exprNode{}, err
+		}
+		return n, cmp.expect(")")
+
+	case "[":
+		n, err := cmp.binary(0)
+		if err != nil {
+			return exprNode{}, err
+		}
+		if err := cmp.expect("]"); err != nil {
+			return exprNode{}, err
+		}
+		return cmp.dereference(n), nil
+	}
+
+	return exprNode{}, fmt.Errorf("unexpected %s", t.text)
+}
+
+// dereference returns a node that reads the memory at the address given by
+// the node
+func (cmp *exprCompiler) dereference(n exprNode) exprNode {
+	mem := cmp.dbg.vcs.Mem
+
+	peek := func(address uint16) int {
+		mappedAddress, area := memorymap.MapAddress(address, true)
+		ar, err := mem.GetArea(area)
+		if err != nil {
+			return 0
+		}
+		d, err := ar.Peek(mappedAddress)
+		if err != nil {
+			return 0
+		}
+		return int(d)
+	}
+
+	if n.constant {
+		address := uint16(n.value)
+		return exprNode{eval: func() int {
+			return peek(address)
+		}}
+	}
+
+	e := n.eval
+	return exprNode{eval: func() int {
+		return peek(uint16(e()))
+	}}
+}
+
+// identifier returns a node for the named register, status flag, television
+// value or symbol
+func (cmp *exprCompiler) identifier(name string) (exprNode, error) {
+	dbg := cmp.dbg
+
+	var f func() int
+
+	switch strings.ToUpper(name) {
+	// cpu registers
+	case "A":
+		f = func() int { return int(dbg.vcs.CPU.A.Value()) }
+	case "X":
+		f = func() int { return int(dbg.vcs.CPU.X.Value()) }
+	case "Y":
+		f = func() int { return int(dbg.vcs.CPU.Y.Value()) }
+	case "SP":
+		f = func() int { return int(dbg.vcs.CPU.SP.Value()) }
+	case "PC":
+		f = func() int { return int(dbg.vcs.CPU.PC.Address()) }
+
+	// status flags
+	case "N":
+		f = func() int { return boolToInt(dbg.vcs.CPU.Status.Sign) }
+	case "V":
+		f = func() int { return boolToInt(dbg.vcs.CPU.Status.Overflow) }
+	case "B":
+		f = func() int { return boolToInt(dbg.vcs.CPU.Status.Break) }
+	case "D":
+		f = func() int { return boolToInt(dbg.vcs.CPU.Status.DecimalMode) }
+	case "I":
+		f = func() int { return boolToInt(dbg.vcs.CPU.Status.InterruptDisable) }
+	case "Z":
+		f = func() int { return boolToInt(dbg.vcs.CPU.Status.Zero) }
+	case "C":
+		f = func() int { return boolToInt(dbg.vcs.CPU.Status.Carry) }
+
+	// tv state
+	case "FRAMENUM", "FRAME", "FR":
+		f = func() int {
+			v, _ := dbg.vcs.TV.GetState(television.ReqFramenum)
+			return v
+		}
+	case "SCANLINE", "SL":
+		f = func() int {
+			v, _ := dbg.vcs.TV.GetState(television.ReqScanline)
+			return v
+		}
+	case "HORIZPOS", "HP":
+		f = func() int {
+			v, _ := dbg.vcs.TV.GetState(television.ReqHorizPos)
+			return v
+		}
+
+	// cartridge
+	case "BANK":
+		f = func() int { return dbg.vcs.Mem.Cart.GetBank(dbg.vcs.CPU.PC.Address()) }
+
+	default:
+		// symbols are replaced by the address they represent
+		_, _, addr, err := dbg.disasm.Symtable.SearchSymbol(name, symbols.UnspecifiedSymTable)
+		if err != nil {
+			return exprNode{}, fmt.Errorf("unrecognised identifier (%s)", name)
+		}
+		return constantNode(int(addr)), nil
+	}
+
+	return exprNode{eval: f}, nil
+}
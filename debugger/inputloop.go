@@ -109,7 +109,8 @@ func (dbg *Debugger) inputLoop(inputter terminal.Input, videoCycle bool) error {
 			dbg.breakMessages != "" ||
 			dbg.trapMessages != "" ||
 			dbg.watchMessages != "" ||
-			dbg.lastStepError || dbg.haltImmediately
+			dbg.lastStepError || dbg.haltImmediately ||
+			dbg.gifDone() || dbg.callStack.stepComplete()
 
 		// expand halt to include step-once/many flag
 		haltEmulation = haltEmulation || !dbg.runUntilHalt
@@ -134,6 +135,10 @@ func (dbg *Debugger) inputLoop(inputter terminal.Input, videoCycle bool) error {
 		if haltEmulation || checkTerm {
 			// some things we don't want to if this is only a momentary halt
 			if haltEmulation {
+				// a STEP OVER or STEP OUT is finished whatever the reason
+				// for the halt
+				dbg.callStack.cancelStep()
+
 				// input has halted. print on halt command if it is defined
 				if dbg.commandOnHalt != nil {
 					_, err := dbg.processTokenGroup(dbg.commandOnHalt)
@@ -285,6 +290,19 @@ func (dbg *Debugger) inputLoop(inputter terminal.Input, videoCycle bool) error {
 						dbg.printLine(terminal.StyleError, "%s", dbg.vcs.CPU.LastResult)
 						return errors.New(errors.DebuggerError, err)
 					}
+
+					// profiler must be updated before the call stack
+					if dbg.profiler.enabled {
+						dbg.profiler.record()
+					}
+
+					dbg.callStack.update()
+
+					if dbg.trace.active() {
+						dbg.trace.trace()
+					}
+
+					dbg.rewind.advance()
 				}
 			}
 
@@ -0,0 +1,280 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/disassembly"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// profileKey identifies an instruction by bank and mapped address. mapping the
+// address means that cartridge mirrors are counted as the same address.
+type profileKey struct {
+	bank    int
+	address uint16
+}
+
+func newProfileKey(bank int, address uint16) profileKey {
+	address, _ = memorymap.MapAddress(address, true)
+	return profileKey{bank: bank, address: address}
+}
+
+// profileEntry accumulates statistics for a single address or subroutine.
+type profileEntry struct {
+	key        profileKey
+	cycles     int
+	pageFaults int
+}
+
+// profiler accumulates cycle counts from execution.Result for every executed
+// instruction. statistics are gathered per address, per subroutine and per
+// bank.
+//
+// cycles spent in a subroutine are inclusive. in other words, cycles spent in
+// a nested subroutine are also counted towards the calling subroutine.
+type profiler struct {
+	dbg *Debugger
+
+	// profiling is off by default because of the small but measurable cost
+	enabled bool
+
+	addresses   map[profileKey]*profileEntry
+	subroutines map[profileKey]*profileEntry
+	banks       map[int]int
+
+	totalCycles int
+
+	// the first and most recent frame numbers seen while profiling. used to
+	// calculate per-frame values
+	startFrame int
+	lastFrame  int
+
+	// the largest number of cycles accumulated by a single address. used to
+	// normalise heatmap values
+	maxCycles int
+}
+
+func newProfiler(dbg *Debugger) *profiler {
+	pr := &profiler{dbg: dbg}
+	pr.clear()
+	return pr
+}
+
+// clear all accumulated statistics.
+func (pr *profiler) clear() {
+	pr.addresses = make(map[profileKey]*profileEntry)
+	pr.subroutines = make(map[profileKey]*profileEntry)
+	pr.banks = make(map[int]int)
+	pr.totalCycles = 0
+	pr.startFrame = -1
+	pr.lastFrame = -1
+	pr.maxCycles = 0
+}
+
+// frames returns the number of frames that have been profiled.
+func (pr *profiler) frames() int {
+	if pr.startFrame == -1 {
+		return 0
+	}
+	return pr.lastFrame - pr.startFrame + 1
+}
+
+// perFrame returns the number of cycles per frame.
+func (pr *profiler) perFrame(cycles int) float64 {
+	if pr.frames() == 0 {
+		return 0
+	}
+	return float64(cycles) / float64(pr.frames())
+}
+
+// record the most recently completed instruction. should be called after
+// every completed CPU instruction and before the call stack is updated, so
+// that the cycles for JSR are counted towards the caller and the cycles for
+// RTS are counted towards the subroutine.
+func (pr *profiler) record() {
+	res := pr.dbg.vcs.CPU.LastResult
+	if !res.Final || res.Defn == nil {
+		return
+	}
+
+	fn, _ := pr.dbg.vcs.TV.GetState(television.ReqFramenum)
+	if pr.startFrame == -1 {
+		pr.startFrame = fn
+	}
+	pr.lastFrame = fn
+
+	key := newProfileKey(pr.dbg.lastBank, res.Address)
+
+	e, ok := pr.addresses[key]
+	if !ok {
+		e = &profileEntry{key: key}
+		pr.addresses[key] = e
+	}
+	e.cycles += res.ActualCycles
+	if res.PageFault {
+		e.pageFaults++
+	}
+	if e.cycles > pr.maxCycles {
+		pr.maxCycles = e.cycles
+	}
+
+	pr.banks[pr.dbg.lastBank] += res.ActualCycles
+	pr.totalCycles += res.ActualCycles
+
+	// count cycles towards every subroutine in the call stack. a recursive
+	// subroutine is only counted once
+	frames := pr.dbg.callStack.frames
+	for i := range frames {
+		key := newProfileKey(frames[i].SubroutineBank, frames[i].Subroutine)
+
+		counted := false
+		for j := 0; j < i; j++ {
+			if key == newProfileKey(frames[j].SubroutineBank, frames[j].Subroutine) {
+				counted = true
+				break // for loop
+			}
+		}
+		if counted {
+			continue // for loop
+		}
+
+		s, ok := pr.subroutines[key]
+		if !ok {
+			s = &profileEntry{key: key}
+			pr.subroutines[key] = s
+		}
+		s.cycles += res.ActualCycles
+		if res.PageFault {
+			s.pageFaults++
+		}
+	}
+}
+
+// heat returns a value between 0.0 and 1.0 indicating the number of cycles
+// consumed by the instruction at the disassembly entry, relative to the
+// instruction that has consumed the most cycles.
+func (pr *profiler) heat(e *disassembly.Entry) float32 {
+	if pr.maxCycles == 0 {
+		return 0.0
+	}
+	p, ok := pr.addresses[newProfileKey(e.Bank, e.Result.Address)]
+	if !ok {
+		return 0.0
+	}
+	return float32(p.cycles) / float32(pr.maxCycles)
+}
+
+// annotate is used to add profiling information to the output of the
+// DISASSEMBLY command.
+func (pr *profiler) annotate(e *disassembly.Entry) string {
+	p, ok := pr.addresses[newProfileKey(e.Bank, e.Result.Address)]
+	if !ok {
+		return ""
+	}
+
+	s := fmt.Sprintf("; %8d cycles %9.2f/frame", p.cycles, pr.perFrame(p.cycles))
+	if p.pageFaults > 0 {
+		s = fmt.Sprintf("%s %d page faults", s, p.pageFaults)
+	}
+	return s
+}
+
+// sortedProfile returns the entries in the map sorted by the number of cycles,
+// largest first. the list is truncated to the specified length.
+func sortedProfile(m map[profileKey]*profileEntry, n int) []*profileEntry {
+	l := make([]*profileEntry, 0, len(m))
+	for _, e := range m {
+		l = append(l, e)
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].cycles == l[j].cycles {
+			if l[i].key.bank == l[j].key.bank {
+				return l[i].key.address < l[j].key.address
+			}
+			return l[i].key.bank < l[j].key.bank
+		}
+		return l[i].cycles > l[j].cycles
+	})
+
+	if n > 0 && len(l) > n {
+		l = l[:n]
+	}
+
+	return l
+}
+
+// label returns the location symbol for the address, if there is one.
+func (pr *profiler) label(key profileKey) string {
+	if l, ok := pr.dbg.disasm.Symtable.Locations.Get(key.bank, key.address); ok {
+		return fmt.Sprintf(" (%s)", l)
+	}
+	return ""
+}
+
+// report prints a summary of the profile to the terminal. the number of
+// addresses and subroutines listed is limited to n.
+func (pr *profiler) report(n int) {
+	if pr.totalCycles == 0 {
+		pr.dbg.printLine(terminal.StyleFeedback, "no profiling data")
+		return
+	}
+
+	pr.dbg.printLine(terminal.StyleFeedback, "%d cycles over %d frames (%.2f/frame)",
+		pr.totalCycles, pr.frames(), pr.perFrame(pr.totalCycles))
+
+	banks := make([]int, 0, len(pr.banks))
+	for b := range pr.banks {
+		banks = append(banks, b)
+	}
+	sort.Ints(banks)
+
+	pr.dbg.printLine(terminal.StyleFeedback, "banks:")
+	for _, b := range banks {
+		c := pr.banks[b]
+		pr.dbg.printLine(terminal.StyleFeedback, "% 4d: %10d cycles %9.2f/frame %6.2f%%",
+			b, c, pr.perFrame(c), float64(c)*100/float64(pr.totalCycles))
+	}
+
+	if len(pr.subroutines) > 0 {
+		pr.dbg.printLine(terminal.StyleFeedback, "subroutines:")
+		for _, s := range sortedProfile(pr.subroutines, n) {
+			pr.dbg.printLine(terminal.StyleFeedback, "  %#04x%s [bank %d] %10d cycles %9.2f/frame %6.2f%%",
+				s.key.address, pr.label(s.key), s.key.bank,
+				s.cycles, pr.perFrame(s.cycles), float64(s.cycles)*100/float64(pr.totalCycles))
+		}
+	}
+
+	pr.dbg.printLine(terminal.StyleFeedback, "addresses:")
+	for _, a := range sortedProfile(pr.addresses, n) {
+		s := fmt.Sprintf("  %#04x%s [bank %d] %10d cycles %9.2f/frame %6.2f%%",
+			a.key.address, pr.label(a.key), a.key.bank,
+			a.cycles, pr.perFrame(a.cycles), float64(a.cycles)*100/float64(pr.totalCycles))
+		if a.pageFaults > 0 {
+			s = fmt.Sprintf("%s %d page faults", s, a.pageFaults)
+		}
+		pr.dbg.printLine(terminal.StyleFeedback, "%s", s)
+	}
+}
@@ -0,0 +1,129 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import (
+	"testing"
+)
+
+func (trm *mockTerm) testProfiler() {
+	// profiler is off at startup
+	trm.sndInput("PROFILE")
+	trm.cmpOutput("profiling off")
+
+	trm.sndInput("PROFILE REPORT")
+	trm.cmpOutput("no profiling data")
+
+	trm.sndInput("PROFILE ON")
+	trm.cmpOutput("profiling on (0 frames)")
+
+	trm.sndInput("PROFILE REPORT 0")
+	trm.cmpOutput("invalid number of entries (0)")
+
+	trm.sndInput("PROFILE OFF")
+	trm.cmpOutput("profiling off")
+}
+
+// cmpOutputLines checks that every line in the list is somewhere in the most
+// recent output. the output can be long and slow to start so it is received
+// until the debugger has stopped sending it, waiting for up to a second for
+// the first line
+func (trm *mockTerm) cmpOutputLines(lines []string) {
+	trm.t.Helper()
+
+	for i := 0; i < 100; i++ {
+		n := len(trm.output)
+		trm.rcvOutput()
+		if n > 0 && n == len(trm.output) {
+			break // for loop
+		}
+	}
+
+	for _, l := range lines {
+		found := false
+		for _, o := range trm.output {
+			if o == l {
+				found = true
+				break // for loop
+			}
+		}
+		if !found {
+			trm.t.Errorf("expected debugger output (%s) not found", l)
+		}
+	}
+}
+
+func TestProfileProgram(t *testing.T) {
+	runProgram(t, frameProgram, func(trm *mockTerm) {
+		defer func() { trm.sndInput("QUIT") }()
+
+		// the symbols file is missing. ignore the error
+		trm.rcvOutput()
+
+		trm.sndInput("PROFILE ON")
+		trm.cmpOutput("profiling on (0 frames)")
+
+		// run until the JMP at the end of the third frame. the count
+		// subroutine has been called three times and the JMP has been executed
+		// twice
+		trm.sndInput("BREAK PC 0xf022 & FRAME 3")
+		trm.sndInput("RUN")
+		trm.cmpPC("f022")
+
+		// each frame is 2113 cycles (ignoring the time the CPU is stalled by
+		// WSYNC). plus the four cycles at the start of the program, less the
+		// JMP that has not been executed
+		trm.sndInput("PROFILE REPORT")
+		trm.cmpOutputLines([]string{
+			"6340 cycles over 4 frames (1585.00/frame)",
+			"   0:       6340 cycles   1585.00/frame 100.00%",
+
+			// INC and RTS. the JSR is counted towards the caller
+			"  0x1040 [bank 0]         33 cycles      8.25/frame   0.52%",
+
+			// STA WSYNC and BNE in the loop of 256 scanlines
+			"  0x1013 [bank 0]       2304 cycles    576.00/frame  36.34%",
+			"  0x1016 [bank 0]       2301 cycles    575.25/frame  36.29%",
+
+			"  0x1040 [bank 0]         15 cycles      3.75/frame   0.24%",
+			"  0x1042 [bank 0]         18 cycles      4.50/frame   0.28%",
+		})
+
+		trm.sndInput("DISASSEMBLY PROFILE")
+		trm.cmpOutputLines([]string{
+			"0x101f JSR $f040   6       ;       18 cycles      4.50/frame",
+			"0x1022 JMP $f003   3       ;        6 cycles      1.50/frame",
+			"0x1040 INC   $80   5       ;       15 cycles      3.75/frame",
+		})
+
+		// the profile is not affected by instructions executed after
+		// profiling has been turned off
+		trm.sndInput("PROFILE OFF")
+		trm.sndInput("STEP")
+		trm.sndInput("PROFILE REPORT")
+		trm.cmpOutputLines([]string{
+			"6340 cycles over 4 frames (1585.00/frame)",
+		})
+
+		trm.sndInput("PROFILE CLEAR")
+		trm.sndInput("PROFILE REPORT")
+		trm.cmpOutput("no profiling data")
+	})
+}
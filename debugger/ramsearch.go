@@ -0,0 +1,204 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"fmt"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/debugger/terminal/commandline"
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/symbols"
+)
+
+// the number of candidates listed by the SEARCH LIST command before the list
+// is truncated
+const searchListMax = 64
+
+// searchCandidate is a single RAM address that is being considered by the
+// RAM search.
+type searchCandidate struct {
+	// label of the RAM area. either "RIOT" or the label of the cartridge RAM
+	area string
+
+	// cartridge RAM has different read and write addresses. for RIOT RAM the
+	// two addresses are the same
+	readAddress  uint16
+	writeAddress uint16
+
+	// value of the address when the search was started or last narrowed
+	value uint8
+}
+
+func (c searchCandidate) String() string {
+	return fmt.Sprintf("%#04x (%s) -> %#02x", c.readAddress, c.area, c.value)
+}
+
+// searchComparison is used to narrow the list of candidates. the function
+// should return true if the candidate is to be kept.
+type searchComparison func(previous uint8, current uint8) bool
+
+// ramSearch narrows down a list of candidate RAM addresses by comparing
+// values between one halt and the next. this is the classic method of finding
+// the addresses used for lives and score.
+//
+// candidates are taken from RIOT RAM and from any active cartridge RAM.
+type ramSearch struct {
+	dbg        *Debugger
+	candidates []searchCandidate
+	started    bool
+}
+
+func newRAMSearch(dbg *Debugger) *ramSearch {
+	return &ramSearch{dbg: dbg}
+}
+
+// peek value at address. returns false if address cannot be peeked.
+func (rs *ramSearch) peek(address uint16) (uint8, bool) {
+	ai, err := rs.dbg.dbgmem.peek(address)
+	if err != nil {
+		return 0, false
+	}
+	return ai.data, true
+}
+
+// start a new search. every RAM address is a candidate.
+func (rs *ramSearch) start() {
+	rs.candidates = rs.candidates[:0]
+	rs.started = true
+
+	for a := memorymap.OriginRAM; a <= memorymap.MemtopRAM; a++ {
+		if v, ok := rs.peek(a); ok {
+			rs.candidates = append(rs.candidates, searchCandidate{
+				area:         "RIOT",
+				readAddress:  a,
+				writeAddress: a,
+				value:        v,
+			})
+		}
+	}
+
+	for _, ri := range rs.dbg.vcs.Mem.Cart.GetRAMinfo() {
+		if !ri.Active {
+			continue // for loop
+		}
+		for i := 0; i < ri.ReadLen(); i++ {
+			a := ri.ReadOrigin + uint16(i)
+			if v, ok := rs.peek(a); ok {
+				rs.candidates = append(rs.candidates, searchCandidate{
+					area:         ri.Label,
+					readAddress:  a,
+					writeAddress: ri.WriteOrigin + uint16(i),
+					value:        v,
+				})
+			}
+		}
+	}
+}
+
+// clear search.
+func (rs *ramSearch) clear() {
+	rs.candidates = rs.candidates[:0]
+	rs.started = false
+}
+
+// narrow the list of candidates with the comparison function. the value of
+// each remaining candidate is updated so that the next comparison is made
+// against the current value.
+func (rs *ramSearch) narrow(cmp searchComparison) error {
+	if !rs.started {
+		return errors.New(errors.CommandError, "search has not been started")
+	}
+
+	n := rs.candidates[:0]
+	for _, c := range rs.candidates {
+		v, ok := rs.peek(c.readAddress)
+		if !ok || !cmp(c.value, v) {
+			continue // for loop
+		}
+		c.value = v
+		n = append(n, c)
+	}
+	rs.candidates = n
+
+	return nil
+}
+
+// candidate returns the numbered candidate.
+func (rs *ramSearch) candidate(num int) (searchCandidate, error) {
+	if num < 0 || num >= len(rs.candidates) {
+		return searchCandidate{}, errors.New(errors.CommandError, fmt.Sprintf("search result #%d is not available", num))
+	}
+	return rs.candidates[num], nil
+}
+
+// promote the numbered candidate to a symbol. the symbol is added to both the
+// read and write symbol tables.
+func (rs *ramSearch) promoteSymbol(num int, symbol string) error {
+	c, err := rs.candidate(num)
+	if err != nil {
+		return err
+	}
+
+	err = rs.dbg.disasm.Symtable.AddSymbol(symbols.ReadSymTable, c.readAddress, symbol)
+	if err != nil {
+		return err
+	}
+
+	err = rs.dbg.disasm.Symtable.AddSymbol(symbols.WriteSymTable, c.writeAddress, symbol)
+	if err != nil {
+		return err
+	}
+
+	return rs.dbg.disasm.Reformat()
+}
+
+// promote the numbered candidate to a write watch.
+func (rs *ramSearch) promoteWatch(num int) error {
+	c, err := rs.candidate(num)
+	if err != nil {
+		return err
+	}
+
+	return rs.dbg.watches.parseWatch(commandline.TokeniseInput(fmt.Sprintf("WRITE %#04x", c.writeAddress)))
+}
+
+// list candidates. the list is truncated if it is too long.
+func (rs *ramSearch) list() {
+	for i, c := range rs.candidates {
+		if i >= searchListMax {
+			rs.dbg.printLine(terminal.StyleFeedback, "... and %d more", len(rs.candidates)-searchListMax)
+			break // for loop
+		}
+		rs.dbg.printLine(terminal.StyleFeedback, "% 3d: %s", i, c)
+	}
+	rs.dbg.printLine(terminal.StyleFeedback, "%s", rs)
+}
+
+func (rs *ramSearch) String() string {
+	if !rs.started {
+		return "no search in progress"
+	}
+	if len(rs.candidates) == 1 {
+		return "1 candidate"
+	}
+	return fmt.Sprintf("%d candidates", len(rs.candidates))
+}
@@ -0,0 +1,118 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import (
+	"fmt"
+	"testing"
+)
+
+func (trm *mockTerm) testRAMSearch() {
+	trm.sndInput("SEARCH")
+	trm.cmpOutput("no search in progress")
+
+	// search must be started before it can be narrowed
+	trm.sndInput("SEARCH CHANGED")
+	trm.cmpOutput("search has not been started")
+
+	// every address in VCS RAM is a candidate
+	trm.sndInput("SEARCH START")
+	trm.cmpOutput("128 candidates")
+
+	// emulation hasn't moved on so no values will have changed
+	trm.sndInput("SEARCH UNCHANGED")
+	trm.cmpOutput("128 candidates")
+
+	trm.sndInput("SEARCH WATCH 128")
+	trm.cmpOutput("search result #128 is not available")
+
+	trm.sndInput("SEARCH CLEAR")
+	trm.cmpOutput("no search in progress")
+}
+
+// the frame counter of frameProgram is incremented at the end of each frame.
+// the counter is the only RAM address that changes after the first frame
+func TestRAMSearchProgram(t *testing.T) {
+	runProgram(t, frameProgram, func(trm *mockTerm) {
+		defer func() { trm.sndInput("QUIT") }()
+
+		// the symbols file is missing. ignore the error
+		trm.rcvOutput()
+
+		// run to the end of each frame in turn
+		frame := 1
+		runFrame := func() {
+			trm.sndInput(fmt.Sprintf("BREAK PC 0xf022 & FRAME %d", frame))
+			trm.sndInput("RUN")
+			trm.cmpPC("f022")
+			frame++
+		}
+
+		runFrame()
+		trm.sndInput("SEARCH START")
+		trm.cmpOutput("128 candidates")
+
+		// every address except the frame counter is the same from one frame to
+		// the next
+		runFrame()
+		trm.sndInput("SEARCH EQUAL")
+		trm.cmpOutput("127 candidates")
+
+		// start again and find the frame counter
+		trm.sndInput("SEARCH START")
+		trm.cmpOutput("128 candidates")
+		runFrame()
+		trm.sndInput("SEARCH CHANGED")
+		trm.cmpOutput("1 candidate")
+		if len(trm.output) < 2 || trm.output[len(trm.output)-2] != "  0: 0x0080 (RIOT) -> 0x03" {
+			trm.t.Errorf("unexpected search candidate (%v)", trm.output)
+		}
+
+		runFrame()
+		trm.sndInput("SEARCH INCREASED")
+		trm.cmpOutput("1 candidate")
+
+		trm.sndInput("SEARCH EQUAL 5")
+		trm.cmpOutput("0 candidates")
+
+		// start again and search for the value of the counter in the next
+		// frame
+		trm.sndInput("SEARCH START")
+		runFrame()
+		trm.sndInput("SEARCH INCREASED")
+		trm.cmpOutput("1 candidate")
+		trm.sndInput("SEARCH EQUAL 5")
+		trm.cmpOutput("1 candidate")
+
+		// promoting the candidate adds it to the symbol tables and the
+		// disassembly is updated
+		trm.sndInput("DISASSEMBLY")
+		trm.cmpOutputLines([]string{"0x1040 INC   $80   5      "})
+
+		trm.sndInput("SEARCH SYMBOL 0 frames")
+		trm.cmpOutput("frames -> 0x0080")
+
+		trm.sndInput("SYMBOL LIST WRITE")
+		trm.cmpOutputLines([]string{"0x0080 -> frames"})
+
+		trm.sndInput("DISASSEMBLY")
+		trm.cmpOutputLines([]string{"0x1040 INC frames   5      "})
+	})
+}
@@ -0,0 +1,399 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// the number of frames between checkpoints
+const rewindInterval = 1
+
+// the maximum number of checkpoints kept. once this number is reached the
+// oldest checkpoint is dropped whenever a new one is taken
+const rewindMaxCheckpoints = 300
+
+// checkpoint is a snapshot of the entire machine after a known number of
+// instructions
+type checkpoint struct {
+	count     int
+	frame     int
+	state     *hardware.State
+	callStack []CallFrame
+}
+
+// rewindEvent is an input event that has been noted so that it can be
+// delivered again when the emulation is re-executed from a checkpoint
+type rewindEvent struct {
+	// the number of instructions executed when the event was received. the
+	// event is delivered before the next instruction is executed
+	count int
+
+	id    input.ID
+	event input.Event
+	value input.EventData
+}
+
+// rewind implements reverse execution for the STEP BACK and RUN BACK
+// commands.
+//
+// the emulation can't be run backwards so instead the machine is returned to
+// a recent checkpoint and re-executed up to the required point. checkpoints
+// are taken periodically as the emulation runs forward and input events are
+// noted as they happen (rewind is an implementation of input.EventRecorder).
+// re-execution is therefore deterministic, even when the user has been
+// interacting with the emulation.
+//
+// input events received part way through an instruction (possible in the
+// video quantum) are delivered at the start of that instruction when
+// re-executed.
+//
+// frames produced during re-execution have been seen before so they are not
+// captured by the VIDEO or GIF commands. the GUI does see the frames however
+// and will redraw them as they are produced.
+type rewind struct {
+	dbg *Debugger
+
+	// the number of instructions executed since the machine was reset
+	count int
+
+	// checkpoints in the order they were taken
+	checkpoints []checkpoint
+
+	// input events received since the earliest checkpoint
+	events []rewindEvent
+
+	// a checkpoint is due but has not yet been taken
+	pending bool
+
+	// input events are not noted while re-executing
+	replaying bool
+}
+
+func newRewind(dbg *Debugger) *rewind {
+	return &rewind{dbg: dbg}
+}
+
+// reset forgets all checkpoints and input events and takes a new checkpoint
+// of the current state. should be called whenever the machine is reset.
+func (rw *rewind) reset() {
+	rw.count = 0
+	rw.checkpoints = rw.checkpoints[:0]
+	rw.events = rw.events[:0]
+	rw.checkpoint()
+}
+
+// RecordEvent implements the input.EventRecorder interface
+func (rw *rewind) RecordEvent(id input.ID, event input.Event, value input.EventData) error {
+	if rw.replaying || event == input.NoEvent {
+		return nil
+	}
+	rw.events = append(rw.events, rewindEvent{count: rw.count, id: id, event: event, value: value})
+	return nil
+}
+
+// advance should be called after every completed CPU instruction. a
+// checkpoint is taken if one is due.
+func (rw *rewind) advance() {
+	rw.count++
+
+	if !rw.pending && len(rw.checkpoints) > 0 {
+		fn, _ := rw.dbg.vcs.TV.GetState(television.ReqFramenum)
+		rw.pending = fn >= rw.checkpoints[len(rw.checkpoints)-1].frame+rewindInterval
+	}
+
+	if rw.pending {
+		rw.checkpoint()
+	}
+}
+
+// take a checkpoint of the current state. if the state can't be saved then
+// the checkpoint remains pending.
+func (rw *rewind) checkpoint() {
+	state, err := rw.dbg.vcs.SaveState()
+	if err != nil {
+		rw.pending = true
+		return
+	}
+	rw.pending = false
+
+	fn, _ := rw.dbg.vcs.TV.GetState(television.ReqFramenum)
+	rw.checkpoints = append(rw.checkpoints, checkpoint{
+		count:     rw.count,
+		frame:     fn,
+		state:     state,
+		callStack: rw.dbg.callStack.chain(),
+	})
+
+	if len(rw.checkpoints) > rewindMaxCheckpoints {
+		rw.checkpoints = rw.checkpoints[1:]
+
+		// input events from before the earliest checkpoint are no longer
+		// required
+		i := 0
+		for i < len(rw.events) && rw.events[i].count < rw.checkpoints[0].count {
+			i++
+		}
+		rw.events = rw.events[i:]
+	}
+}
+
+// restore the machine to the state in the numbered checkpoint
+func (rw *rewind) restore(idx int) error {
+	cp := rw.checkpoints[idx]
+
+	err := rw.dbg.vcs.RestoreState(cp.state)
+	if err != nil {
+		return err
+	}
+
+	rw.dbg.callStack.frames = append(rw.dbg.callStack.frames[:0], cp.callStack...)
+	rw.count = cp.count
+
+	return nil
+}
+
+// run re-executes the emulation until the instruction count reaches target.
+// if cond is not nil, it is called after every instruction and the most
+// recent instruction count for which it returned true is returned. a value of
+// -1 is returned if cond never returned true.
+func (rw *rewind) run(target int, cond func() bool) (int, error) {
+	rw.replaying = true
+	rw.suspendCapture(true)
+	defer func() {
+		rw.replaying = false
+		rw.suspendCapture(false)
+	}()
+
+	vcsStep := func() error {
+		return rw.dbg.reflect.Check()
+	}
+
+	found := -1
+
+	for rw.count < target {
+		err := rw.deliverEvents()
+		if err != nil {
+			return found, err
+		}
+
+		rw.dbg.lastBank = rw.dbg.vcs.Mem.Cart.GetBank(rw.dbg.vcs.CPU.PC.Address())
+
+		err = rw.dbg.vcs.Step(vcsStep)
+		if err != nil {
+			return found, err
+		}
+
+		rw.dbg.callStack.update()
+		rw.count++
+
+		if cond != nil && cond() {
+			found = rw.count
+		}
+	}
+
+	return found, nil
+}
+
+// suspend (or resume) any VIDEO or GIF capture that is in progress
+func (rw *rewind) suspendCapture(suspend bool) {
+	if rw.dbg.video != nil {
+		rw.dbg.video.Suspend(suspend)
+	}
+	if rw.dbg.gif != nil {
+		rw.dbg.gif.Suspend(suspend)
+	}
+}
+
+// deliver noted input events for the current instruction count
+func (rw *rewind) deliverEvents() error {
+	for _, ev := range rw.events {
+		if ev.count > rw.count {
+			break
+		}
+		if ev.count < rw.count {
+			continue
+		}
+
+		var port input.Port
+		switch ev.id {
+		case input.HandControllerZeroID:
+			port = rw.dbg.vcs.HandController0
+		case input.HandControllerOneID:
+			port = rw.dbg.vcs.HandController1
+		case input.PanelID:
+			port = rw.dbg.vcs.Panel
+		default:
+			continue
+		}
+
+		err := port.Handle(ev.event, ev.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// the most recent checkpoint at or before the instruction count
+func (rw *rewind) nearest(count int) int {
+	for i := len(rw.checkpoints) - 1; i >= 0; i-- {
+		if rw.checkpoints[i].count <= count {
+			return i
+		}
+	}
+	return -1
+}
+
+// goTo returns the emulation to the state it was in after count instructions
+func (rw *rewind) goTo(count int) error {
+	idx := rw.nearest(count)
+	if idx == -1 {
+		return errors.New(errors.CommandError, "cannot rewind any further")
+	}
+
+	err := rw.restore(idx)
+	if err != nil {
+		return err
+	}
+
+	_, err = rw.run(count, nil)
+	if err != nil {
+		return err
+	}
+
+	rw.truncate()
+
+	return nil
+}
+
+// forget checkpoints and input events that are in the future of the current
+// instruction count. emulation that runs forward from this point will not
+// necessarily follow the same path
+func (rw *rewind) truncate() {
+	i := len(rw.checkpoints)
+	for i > 0 && rw.checkpoints[i-1].count > rw.count {
+		i--
+	}
+	rw.checkpoints = rw.checkpoints[:i]
+
+	i = len(rw.events)
+	for i > 0 && rw.events[i-1].count >= rw.count {
+		i--
+	}
+	rw.events = rw.events[:i]
+
+	rw.pending = false
+}
+
+// stepBack returns the emulation to the state it was in before the most
+// recent instruction
+func (rw *rewind) stepBack() error {
+	if rw.count == 0 {
+		return errors.New(errors.CommandError, "cannot rewind any further")
+	}
+	return rw.goTo(rw.count - 1)
+}
+
+// searchBack looks for the most recent instruction count, earlier than the
+// current count, at which the condition became true. newCond is called once
+// for every checkpoint that is searched and should return a new condition
+// function. the condition function is called once before the search begins
+// (the result of which is ignored) and then after every instruction.
+//
+// if the condition is found the emulation is left in that state and the
+// function returns true. otherwise the emulation is left in the state of the
+// earliest checkpoint.
+func (rw *rewind) searchBack(newCond func() func() bool) (bool, error) {
+	if rw.count == 0 {
+		return false, errors.New(errors.CommandError, "cannot rewind any further")
+	}
+
+	end := rw.count
+	limit := rw.count
+
+	for idx := rw.nearest(end - 1); idx >= 0; idx-- {
+		err := rw.restore(idx)
+		if err != nil {
+			return false, err
+		}
+
+		// the condition may be true for the current instruction count. we're
+		// not interested in that so the result is masked. the condition must
+		// still be called however so that it can update its own state
+		c := newCond()
+		_ = c()
+		cond := func() bool {
+			return c() && rw.count < limit
+		}
+
+		found, err := rw.run(end, cond)
+		if err != nil {
+			return false, err
+		}
+
+		if found != -1 {
+			return true, rw.goTo(found)
+		}
+
+		end = rw.checkpoints[idx].count
+	}
+
+	if len(rw.checkpoints) == 0 {
+		return false, errors.New(errors.CommandError, "cannot rewind any further")
+	}
+
+	return false, rw.goTo(rw.checkpoints[0].count)
+}
+
+// a condition for searchBack() that is true when the condition of any
+// breakpoint is first met
+func (rw *rewind) breakCondition() func() bool {
+	prev := ""
+	return func() bool {
+		curr := rw.dbg.breakpoints.matching()
+		r := false
+		for _, m := range strings.Split(curr, "\n") {
+			if m != "" && !strings.Contains(prev, m+"\n") {
+				r = true
+			}
+		}
+		prev = curr
+		return r
+	}
+}
+
+// a condition for searchBack() that is true on the first instruction of a new
+// frame
+func (rw *rewind) frameCondition() func() bool {
+	prev, _ := rw.dbg.vcs.TV.GetState(television.ReqFramenum)
+	return func() bool {
+		curr, _ := rw.dbg.vcs.TV.GetState(television.ReqFramenum)
+		r := curr != prev
+		prev = curr
+		return r
+	}
+}
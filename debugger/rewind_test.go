@@ -0,0 +1,126 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import (
+	"testing"
+)
+
+func (trm *mockTerm) testRewind() {
+	// nothing has been executed so there is nothing to rewind
+	trm.sndInput("STEP BACK")
+	trm.cmpOutput("cannot rewind any further")
+
+	trm.sndInput("STEP BACK FRAME")
+	trm.cmpOutput("cannot rewind any further")
+
+	trm.sndInput("RUN BACK")
+	trm.cmpOutput("cannot rewind any further")
+}
+
+// lastOutput returns the last line of output from the command
+func (trm *mockTerm) lastOutput(cmd string) string {
+	trm.t.Helper()
+
+	trm.sndInput(cmd)
+	trm.rcvOutput()
+	if len(trm.output) == 0 {
+		trm.t.Errorf("no output from %s", cmd)
+		return ""
+	}
+	return trm.output[len(trm.output)-1]
+}
+
+func TestRewindProgram(t *testing.T) {
+	runProgram(t, frameProgram, func(trm *mockTerm) {
+		defer func() { trm.sndInput("QUIT") }()
+
+		// the symbols file is missing. ignore the error
+		trm.rcvOutput()
+
+		// the CPU state after each of the first few instructions
+		cpu := make([]string, 0)
+		for i := 0; i < 4; i++ {
+			trm.sndInput("STEP")
+			cpu = append(cpu, trm.lastOutput("CPU"))
+		}
+
+		// step back one instruction at a time
+		for i := len(cpu) - 2; i >= 0; i-- {
+			trm.sndInput("STEP BACK")
+			trm.cmpOutput("")
+			if c := trm.lastOutput("CPU"); c != cpu[i] {
+				trm.t.Errorf("unexpected CPU state after STEP BACK (%s) should be (%s)", c, cpu[i])
+			}
+		}
+
+		// run to the end of the second frame. the frame counter has been
+		// incremented twice
+		trm.sndInput("BREAK PC 0xf022 & FRAME 2")
+		trm.sndInput("RUN")
+		trm.cmpPC("f022")
+		breakCPU := trm.lastOutput("CPU")
+		breakRAM := trm.lastOutput("PEEK 0x80")
+		if breakRAM != "0x0080 (RAM) -> 0x02" {
+			trm.t.Errorf("unexpected frame counter (%s)", breakRAM)
+		}
+
+		// the state at the start of the next frame
+		trm.sndInput("STEP FRAME")
+		frameCPU := trm.lastOutput("CPU")
+		frameRAM := trm.lastOutput("PEEK 0x80")
+
+		// run to the end of the third frame and then back to the start of it.
+		// the frame counter is the value it was at the start of the frame
+		trm.sndInput("BREAK PC 0xf022 & FRAME 3")
+		trm.sndInput("RUN")
+		trm.cmpPC("f022")
+		if r := trm.lastOutput("PEEK 0x80"); r != "0x0080 (RAM) -> 0x03" {
+			trm.t.Errorf("unexpected frame counter (%s)", r)
+		}
+
+		trm.sndInput("STEP BACK FRAME")
+		if c := trm.lastOutput("CPU"); c != frameCPU {
+			trm.t.Errorf("unexpected CPU state after STEP BACK FRAME (%s) should be (%s)", c, frameCPU)
+		}
+		if r := trm.lastOutput("PEEK 0x80"); r != frameRAM {
+			trm.t.Errorf("unexpected frame counter after STEP BACK FRAME (%s) should be (%s)", r, frameRAM)
+		}
+
+		// RUN BACK stops at the breakpoint at the end of the second frame and
+		// not at the start of the program
+		trm.sndInput("RUN BACK")
+		if c := trm.lastOutput("CPU"); c != breakCPU {
+			trm.t.Errorf("unexpected CPU state after RUN BACK (%s) should be (%s)", c, breakCPU)
+		}
+		if r := trm.lastOutput("PEEK 0x80"); r != breakRAM {
+			trm.t.Errorf("unexpected frame counter after RUN BACK (%s) should be (%s)", r, breakRAM)
+		}
+
+		// the emulation continues as before from the rewound state
+		trm.sndInput("STEP FRAME")
+		if c := trm.lastOutput("CPU"); c != frameCPU {
+			trm.t.Errorf("unexpected CPU state after STEP FRAME (%s) should be (%s)", c, frameCPU)
+		}
+		if r := trm.lastOutput("PEEK 0x80"); r != frameRAM {
+			trm.t.Errorf("unexpected frame counter after STEP FRAME (%s) should be (%s)", r, frameRAM)
+		}
+	})
+}
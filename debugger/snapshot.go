@@ -0,0 +1,287 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware/memory/addresses"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// snapshotEntry is the value of a single address at the moment the snapshot
+// was taken.
+type snapshotEntry struct {
+	// label of the memory area. "RAM", "TIA", "RIOT" or the label of the
+	// cartridge RAM
+	area string
+
+	address uint16
+	value   uint8
+
+	// the value is the one last written to a chip register rather than the
+	// value that would be read from the address
+	write bool
+}
+
+// snapshotKey identifies an entry in a snapshot. the TIA read and write
+// registers share addresses so the address alone is not enough.
+type snapshotKey struct {
+	address uint16
+	write   bool
+}
+
+// memSnapshot is a record of every peekable RAM address and chip register at
+// a point in time.
+type memSnapshot struct {
+	name string
+
+	// television state when the snapshot was taken
+	frame    int
+	scanline int
+	horizpos int
+
+	entries []snapshotEntry
+
+	// index of entries by key. the value is the index into the entries slice
+	index map[snapshotKey]int
+}
+
+func (s memSnapshot) String() string {
+	return fmt.Sprintf("%s (frame=%d scanline=%d horizpos=%d)", s.name, s.frame, s.scanline, s.horizpos)
+}
+
+// lookup value of entry in snapshot. returns false if the entry was not
+// recorded in the snapshot.
+func (s memSnapshot) lookup(e snapshotEntry) (uint8, bool) {
+	i, ok := s.index[snapshotKey{address: e.address, write: e.write}]
+	if !ok {
+		return 0, false
+	}
+	return s.entries[i].value, true
+}
+
+// memSnapshots is the list of named snapshots taken with the SNAPSHOT command.
+// snapshots are compared with the DIFF command.
+type memSnapshots struct {
+	dbg       *Debugger
+	snapshots []*memSnapshot
+}
+
+func newMemSnapshots(dbg *Debugger) *memSnapshots {
+	return &memSnapshots{dbg: dbg}
+}
+
+// clear all snapshots.
+func (ms *memSnapshots) clear() {
+	ms.snapshots = ms.snapshots[:0]
+}
+
+// find snapshot with name. returns index of snapshot or -1 if there is no
+// snapshot with that name.
+func (ms *memSnapshots) find(name string) int {
+	for i, s := range ms.snapshots {
+		if s.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// snapshotStrobes are the write registers for which the value written is
+// meaningless. they are not recorded in snapshots.
+var snapshotStrobes = map[string]bool{
+	"WSYNC": true, "RSYNC": true, "RESP0": true, "RESP1": true, "RESM0": true,
+	"RESM1": true, "RESBL": true, "HMOVE": true, "HMCLR": true, "CXCLR": true,
+}
+
+// take a snapshot of the current state of memory.
+func (ms *memSnapshots) take(name string) *memSnapshot {
+	s := &memSnapshot{name: name, index: make(map[snapshotKey]int)}
+
+	s.frame, _ = ms.dbg.vcs.TV.GetState(television.ReqFramenum)
+	s.scanline, _ = ms.dbg.vcs.TV.GetState(television.ReqScanline)
+	s.horizpos, _ = ms.dbg.vcs.TV.GetState(television.ReqHorizPos)
+
+	add := func(e snapshotEntry) {
+		s.index[snapshotKey{address: e.address, write: e.write}] = len(s.entries)
+		s.entries = append(s.entries, e)
+	}
+
+	record := func(area string, address uint16) {
+		ai, err := ms.dbg.dbgmem.peek(address)
+		if err != nil {
+			return
+		}
+		add(snapshotEntry{area: area, address: address, value: ai.data})
+	}
+
+	// chip registers that can be read are peeked in the same way as RAM
+	chip := make([]uint16, 0, len(addresses.CanonicalReadSymbols))
+	for a := range addresses.CanonicalReadSymbols {
+		chip = append(chip, a)
+	}
+	sort.Slice(chip, func(i, j int) bool { return chip[i] < chip[j] })
+	for _, a := range chip {
+		_, area := memorymap.MapAddress(a, true)
+		record(area.String(), a)
+	}
+
+	// the write registers (COLUBK, GRP0, TIM64T, etc.) are recorded with the
+	// value last written to them by the CPU
+	chip = chip[:0]
+	for a, sym := range addresses.CanonicalWriteSymbols {
+		if snapshotStrobes[sym] {
+			continue // for loop
+		}
+		chip = append(chip, a)
+	}
+	sort.Slice(chip, func(i, j int) bool { return chip[i] < chip[j] })
+	for _, a := range chip {
+		_, area := memorymap.MapAddress(a, false)
+		mem := ms.dbg.vcs.Mem.TIA
+		if area == memorymap.RIOT {
+			mem = ms.dbg.vcs.Mem.RIOT
+		}
+		v, err := mem.PeekWrite(a)
+		if err != nil {
+			continue // for loop
+		}
+		add(snapshotEntry{area: area.String(), address: a, value: v, write: true})
+	}
+
+	for a := memorymap.OriginRAM; a <= memorymap.MemtopRAM; a++ {
+		record(memorymap.RAM.String(), a)
+	}
+
+	for _, ri := range ms.dbg.vcs.Mem.Cart.GetRAMinfo() {
+		if !ri.Active {
+			continue // for loop
+		}
+		for i := 0; i < ri.ReadLen(); i++ {
+			record(ri.Label, ri.ReadOrigin+uint16(i))
+		}
+	}
+
+	return s
+}
+
+// add a new named snapshot. any existing snapshot with the same name is
+// replaced.
+func (ms *memSnapshots) add(name string) *memSnapshot {
+	s := ms.take(name)
+	if i := ms.find(name); i != -1 {
+		ms.snapshots[i] = s
+	} else {
+		ms.snapshots = append(ms.snapshots, s)
+	}
+	return s
+}
+
+// drop the named snapshot.
+func (ms *memSnapshots) drop(name string) error {
+	i := ms.find(name)
+	if i == -1 {
+		return errors.New(errors.CommandError, fmt.Sprintf("no snapshot named %s", name))
+	}
+	ms.snapshots = append(ms.snapshots[:i], ms.snapshots[i+1:]...)
+	return nil
+}
+
+// list snapshots.
+func (ms *memSnapshots) list() {
+	if len(ms.snapshots) == 0 {
+		ms.dbg.printLine(terminal.StyleFeedback, "no snapshots")
+		return
+	}
+	for _, s := range ms.snapshots {
+		ms.dbg.printLine(terminal.StyleFeedback, "%s", s)
+	}
+}
+
+// symbol for entry. cartridge RAM has different read and write addresses so
+// the read symbol table is used in preference to the write symbol table,
+// unless the entry is for a chip write register.
+func (ms *memSnapshots) symbol(e snapshotEntry) string {
+	if e.write {
+		return ms.dbg.disasm.Symtable.Write.Symbols[e.address]
+	}
+	if sym, ok := ms.dbg.disasm.Symtable.Read.Symbols[e.address]; ok {
+		return sym
+	}
+	if sym, ok := ms.dbg.disasm.Symtable.Write.Symbols[e.address]; ok {
+		return sym
+	}
+	return ""
+}
+
+// diff lists every address that has a different value in the two named
+// snapshots. if the to argument is the empty string then the from snapshot is
+// compared with the current state of memory.
+func (ms *memSnapshots) diff(from string, to string) error {
+	i := ms.find(from)
+	if i == -1 {
+		return errors.New(errors.CommandError, fmt.Sprintf("no snapshot named %s", from))
+	}
+	a := ms.snapshots[i]
+
+	var b *memSnapshot
+	if to == "" {
+		b = ms.take("current")
+	} else {
+		i := ms.find(to)
+		if i == -1 {
+			return errors.New(errors.CommandError, fmt.Sprintf("no snapshot named %s", to))
+		}
+		b = ms.snapshots[i]
+	}
+
+	n := 0
+	for _, e := range b.entries {
+		v, ok := a.lookup(e)
+		if !ok || v == e.value {
+			continue // for loop
+		}
+
+		s := fmt.Sprintf("%#04x", e.address)
+		if sym := ms.symbol(e); sym != "" {
+			s = fmt.Sprintf("%s (%s)", s, sym)
+		}
+		ms.dbg.printLine(terminal.StyleFeedback, "%s [%s]: %#02x -> %#02x", s, e.area, v, e.value)
+		n++
+	}
+
+	if n == 0 {
+		ms.dbg.printLine(terminal.StyleFeedback, "no differences between %s and %s", a.name, b.name)
+		return nil
+	}
+
+	if n == 1 {
+		ms.dbg.printLine(terminal.StyleFeedback, "1 difference between %s and %s", a.name, b.name)
+	} else {
+		ms.dbg.printLine(terminal.StyleFeedback, "%d differences between %s and %s", n, a.name, b.name)
+	}
+
+	return nil
+}
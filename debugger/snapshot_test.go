@@ -0,0 +1,109 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import "testing"
+
+func (trm *mockTerm) testSnapshot() {
+	trm.sndInput("SNAPSHOT")
+	trm.cmpOutput("no snapshots")
+
+	trm.sndInput("DIFF a")
+	trm.cmpOutput("no snapshot named a")
+
+	trm.sndInput("SNAPSHOT a")
+	trm.cmpOutput("snapshot a (frame=0 scanline=0 horizpos=0)")
+
+	// nothing has changed since the snapshot was taken
+	trm.sndInput("DIFF a")
+	trm.cmpOutput("no differences between a and current")
+
+	trm.sndInput("POKE 0x80 0x10")
+	trm.sndInput("DIFF a")
+	trm.cmpOutput("1 difference between a and current")
+
+	trm.sndInput("SNAPSHOT b")
+	trm.sndInput("DIFF a b")
+	trm.cmpOutput("1 difference between a and b")
+
+	trm.sndInput("DIFF b a")
+	trm.cmpOutput("1 difference between b and a")
+
+	trm.sndInput("SNAPSHOT DROP a")
+	trm.cmpOutput("snapshot a dropped")
+
+	trm.sndInput("DIFF a b")
+	trm.cmpOutput("no snapshot named a")
+
+	trm.sndInput("SNAPSHOT CLEAR")
+	trm.sndInput("SNAPSHOT LIST")
+	trm.cmpOutput("no snapshots")
+}
+
+// the frame program writes 0x02 and then 0x00 to VSYNC at the start of every
+// frame and increments the frame counter at the end of each frame.
+func TestSnapshotProgram(t *testing.T) {
+	runProgram(t, frameProgram, func(trm *mockTerm) {
+		defer func() { trm.sndInput("QUIT") }()
+
+		// the symbols file is missing. ignore the error
+		trm.rcvOutput()
+
+		trm.sndInput("BREAK PC 0xf022 & FRAME 1")
+		trm.sndInput("RUN")
+		trm.cmpPC("f022")
+		trm.sndInput("SNAPSHOT a")
+		trm.cmpOutput("snapshot a (frame=1 scanline=259 horizpos=-5)")
+
+		// JMP, LDA and STA VSYNC. the value written to the VSYNC register is
+		// recorded even though it can't be read
+		trm.sndInput("STEP")
+		trm.sndInput("STEP")
+		trm.sndInput("STEP")
+		trm.cmpPC("f007")
+		trm.sndInput("DIFF a")
+		trm.cmpOutputLines([]string{
+			"0x0284 (INTIM) [RIOT]: 0x23 -> 0x1b",
+			"0x0000 (VSYNC) [TIA]: 0x00 -> 0x02",
+			"2 differences between a and current",
+		})
+
+		trm.sndInput("SNAPSHOT b")
+		trm.cmpOutput("snapshot b (frame=1 scanline=259 horizpos=19)")
+
+		// VSYNC is switched off again by the end of the next frame
+		trm.sndInput("BREAK PC 0xf022 & FRAME 2")
+		trm.sndInput("RUN")
+		trm.cmpPC("f022")
+		trm.sndInput("DIFF a")
+		trm.cmpOutputLines([]string{
+			"0x0284 (INTIM) [RIOT]: 0x23 -> 0x5b",
+			"0x0080 [RAM]: 0x01 -> 0x02",
+			"2 differences between a and current",
+		})
+		trm.sndInput("DIFF b")
+		trm.cmpOutputLines([]string{
+			"0x0284 (INTIM) [RIOT]: 0x1b -> 0x5b",
+			"0x0000 (VSYNC) [TIA]: 0x02 -> 0x00",
+			"0x0080 [RAM]: 0x01 -> 0x02",
+			"3 differences between b and current",
+		})
+	})
+}
@@ -0,0 +1,113 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/symbols"
+)
+
+// the number of lines either side of the current line shown by the SOURCE
+// command by default
+const sourceContext = 5
+
+// isSourceLocation returns true if the string is of the form file:line
+func isSourceLocation(s string) bool {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return false
+	}
+	_, err := strconv.Atoi(s[i+1:])
+	return err == nil
+}
+
+// parseSourceLocation finds the source line for a string of the form
+// file:line. also returns the cartridge banks in which the code for the line
+// can be found.
+func parseSourceLocation(dbg *Debugger, s string) (*symbols.SourceLine, []int, error) {
+	if dbg.disasm.Symtable.Source == nil {
+		return nil, nil, errors.New(errors.CommandError, "no listing file for cartridge")
+	}
+
+	i := strings.LastIndex(s, ":")
+	if i <= 0 {
+		return nil, nil, errors.New(errors.CommandError, fmt.Sprintf("invalid source location (%s)", s))
+	}
+
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return nil, nil, errors.New(errors.CommandError, fmt.Sprintf("invalid source location (%s)", s))
+	}
+
+	sl, err := dbg.disasm.Symtable.Source.FindLine(s[:i], line)
+	if err != nil {
+		return nil, nil, errors.New(errors.CommandError, err)
+	}
+
+	return sl, dbg.disasm.SourceBanks(sl), nil
+}
+
+// currentSource returns the source line for the instruction at the current PC
+// address.
+func currentSource(dbg *Debugger) (*symbols.SourceLine, error) {
+	if dbg.disasm.Symtable.Source == nil {
+		return nil, errors.New(errors.CommandError, "no listing file for cartridge")
+	}
+
+	// see buildPrompt() for an explanation of this condition
+	var addr uint16
+	if dbg.vcs.CPU.LastResult.Final || dbg.vcs.CPU.HasReset() {
+		addr = dbg.vcs.CPU.PC.Address()
+	} else {
+		addr = dbg.vcs.CPU.LastResult.Address
+	}
+
+	if !memorymap.IsArea(addr, memorymap.Cartridge) {
+		return nil, errors.New(errors.CommandError, fmt.Sprintf("%#04x is not in cartridge space", addr))
+	}
+
+	e, ok := dbg.disasm.GetEntryByAddress(dbg.vcs.Mem.Cart.GetBank(addr), addr)
+	if ok {
+		if sl := dbg.disasm.Source(e); sl != nil {
+			return sl, nil
+		}
+	}
+
+	return nil, errors.New(errors.CommandError, fmt.Sprintf("no source for %#04x", addr))
+}
+
+// printSource prints the source lines surrounding the source line. the source
+// line itself is marked.
+func (dbg *Debugger) printSource(sl *symbols.SourceLine, count int) {
+	dbg.printLine(terminal.StyleFeedback, "%s", sl)
+	for _, l := range dbg.disasm.Symtable.Source.Context(sl, count) {
+		marker := " "
+		if l == sl {
+			marker = ">"
+		}
+		dbg.printLine(terminal.StyleFeedback, "%s %5d  %s", marker, l.Line, l.Text)
+	}
+}
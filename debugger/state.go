@@ -34,6 +34,12 @@ func (dbg *Debugger) GetLastBank() int {
 	return dbg.lastBank
 }
 
+// GetCallChain returns a copy of the current subroutine call stack. the
+// outermost call is the first entry
+func (dbg *Debugger) GetCallChain() []CallFrame {
+	return dbg.callStack.chain()
+}
+
 // HasBreak returns true if there is a breakpoint at the address. the second
 // return value indicates if there is a breakpoint at the address AND bank
 func (dbg *Debugger) HasBreak(e *disassembly.Entry) BreakGroup {
@@ -41,6 +47,14 @@ func (dbg *Debugger) HasBreak(e *disassembly.Entry) BreakGroup {
 	return g
 }
 
+// ProfileHeat returns a value between 0.0 and 1.0 indicating how many cycles
+// have been spent at the address represented by the disassembly entry,
+// relative to the busiest address. always returns 0.0 if the profiler has not
+// been used
+func (dbg *Debugger) ProfileHeat(e *disassembly.Entry) float32 {
+	return dbg.profiler.heat(e)
+}
+
 // TogglePCBreak sets or unsets a PC break at the address rerpresented by th
 // disassembly entry
 func (dbg *Debugger) TogglePCBreak(e *disassembly.Entry) {
@@ -0,0 +1,82 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import "testing"
+
+func (trm *mockTerm) testSymbols() {
+	trm.sndInput("SYMBOL lives")
+	trm.cmpOutput("lives -> not found")
+
+	trm.sndInput("SYMBOL ADD lives 0x80")
+	trm.cmpOutput("lives -> 0x0080")
+
+	trm.sndInput("SYMBOL lives")
+	trm.cmpOutput("lives (read) -> 0x0080")
+
+	trm.sndInput("SYMBOL ADD lives 0x80 1")
+	trm.cmpOutput("invalid bank (1)")
+
+	trm.sndInput("SYMBOL REMOVE WRITE lives")
+	trm.cmpOutput("lives removed")
+
+	trm.sndInput("SYMBOL REMOVE lives")
+	trm.cmpOutput("lives removed")
+
+	trm.sndInput("SYMBOL lives")
+	trm.cmpOutput("lives -> not found")
+
+	trm.sndInput("SYMBOL SAVE")
+	trm.cmpOutput("no cartridge attached")
+}
+
+// symbols added with SYMBOL ADD are used by the disassembly straight away
+func TestSymbolsProgram(t *testing.T) {
+	runProgram(t, frameProgram, func(trm *mockTerm) {
+		defer func() { trm.sndInput("QUIT") }()
+
+		// the symbols file is missing. ignore the error
+		trm.rcvOutput()
+
+		trm.sndInput("SYMBOL ADD WRITE frames 0x80")
+		trm.cmpOutput("frames -> 0x0080")
+
+		trm.sndInput("SYMBOL ADD LOCATION frame 0xf003 0")
+		trm.cmpOutput("frame -> 0xf003 [bank 0]")
+
+		trm.sndInput("SYMBOL frame")
+		trm.cmpOutput("frame (location) -> 0xf003")
+
+		trm.sndInput("DISASSEMBLY")
+		trm.cmpOutputLines([]string{
+			"0x1022 JMP  frame   3      ",
+			"0x1040 INC frames   5      ",
+		})
+
+		trm.sndInput("SYMBOL REMOVE LOCATION frame")
+		trm.cmpOutput("frame removed")
+
+		trm.sndInput("DISASSEMBLY")
+		trm.cmpOutputLines([]string{
+			"0x1022 JMP  $f003   3      ",
+			"0x1040 INC frames   5      ",
+		})
+	})
+}
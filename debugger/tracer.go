@@ -0,0 +1,220 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jetsetilly/gopher2600/debugger/terminal"
+	"github.com/jetsetilly/gopher2600/disassembly"
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// traceRange is an inclusive range of values used by the trace filters.
+type traceRange struct {
+	from int
+	to   int
+}
+
+func (r traceRange) contains(v int) bool {
+	return v >= r.from && v <= r.to
+}
+
+// tracer writes every executed instruction to a file, one line per
+// instruction. the format of each line is fixed width so that two trace files
+// can be compared with standard diff tools.
+//
+// the tracer is inactive unless a trace file has been opened with start().
+// the only cost when inactive is the check made by the active() function.
+type tracer struct {
+	dbg *Debugger
+
+	f        *os.File
+	w        *bufio.Writer
+	filename string
+
+	// number of lines written to the current trace file
+	lines int
+
+	// filters are applied with AND logic. a nil filter matches everything.
+	// address filters are compared against mapped addresses so that cartridge
+	// mirrors are treated as the same address
+	address *traceRange
+	bank    *traceRange
+	frame   *traceRange
+}
+
+func newTracer(dbg *Debugger) *tracer {
+	return &tracer{dbg: dbg}
+}
+
+// uniqueTraceFilename returns a filename that is very likely to be unique.
+func uniqueTraceFilename(cartName string) string {
+	n := time.Now()
+	return fmt.Sprintf("trace_%s_%04d%02d%02d_%02d%02d%02d.txt",
+		cartName, n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second())
+}
+
+// active returns true if trace lines are being written.
+func (tr *tracer) active() bool {
+	return tr.w != nil
+}
+
+// start writing trace lines to the named file.
+func (tr *tracer) start(filename string) error {
+	if tr.active() {
+		return errors.New(errors.TraceError, fmt.Sprintf("already tracing to %s", tr.filename))
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.New(errors.TraceError, err)
+	}
+
+	tr.f = f
+	tr.w = bufio.NewWriter(f)
+	tr.filename = filename
+	tr.lines = 0
+
+	return nil
+}
+
+// stop writing trace lines and close the trace file.
+func (tr *tracer) stop() error {
+	if !tr.active() {
+		return errors.New(errors.TraceError, "not tracing")
+	}
+
+	err := tr.w.Flush()
+	tr.w = nil
+	if err != nil {
+		_ = tr.f.Close()
+		return errors.New(errors.TraceError, err)
+	}
+
+	err = tr.f.Close()
+	if err != nil {
+		return errors.New(errors.TraceError, err)
+	}
+
+	return nil
+}
+
+// clearFilters removes all trace filters.
+func (tr *tracer) clearFilters() {
+	tr.address = nil
+	tr.bank = nil
+	tr.frame = nil
+}
+
+// trace writes the most recently completed instruction to the trace file.
+// should be called after every completed CPU instruction.
+//
+// the register values in the trace line are the values after the
+// instruction has executed. the television values are the values at the
+// moment the instruction completed.
+func (tr *tracer) trace() {
+	res := tr.dbg.vcs.CPU.LastResult
+	if !res.Final || res.Defn == nil {
+		return
+	}
+
+	bank := tr.dbg.lastBank
+
+	if tr.address != nil {
+		addr, _ := memorymap.MapAddress(res.Address, true)
+		if !tr.address.contains(int(addr)) {
+			return
+		}
+	}
+
+	if tr.bank != nil && !tr.bank.contains(bank) {
+		return
+	}
+
+	frame, _ := tr.dbg.vcs.TV.GetState(television.ReqFramenum)
+	if tr.frame != nil && !tr.frame.contains(frame) {
+		return
+	}
+
+	scanline, _ := tr.dbg.vcs.TV.GetState(television.ReqScanline)
+	horizpos, _ := tr.dbg.vcs.TV.GetState(television.ReqHorizPos)
+
+	e, err := tr.dbg.disasm.FormatResult(bank, res, disassembly.EntryLevelBlessed)
+	if err != nil {
+		return
+	}
+
+	cpu := tr.dbg.vcs.CPU
+	_, err = fmt.Fprintf(tr.w, "%6d %3d %4d %3s %04x %-8s %-3s %-16s A=%02x X=%02x Y=%02x SP=%02x %s\n",
+		frame, scanline, horizpos,
+		e.BankDecorated, res.Address,
+		e.Bytecode, e.Mnemonic, e.Operand,
+		cpu.A.Value(), cpu.X.Value(), cpu.Y.Value(), cpu.SP.Value(), cpu.Status)
+
+	// stop tracing on write error. there's no point trying to write more
+	// lines to a file that is failing
+	if err != nil {
+		tr.dbg.printLine(terminal.StyleError, "%s", errors.New(errors.TraceError, err))
+		_ = tr.stop()
+		return
+	}
+
+	tr.lines++
+}
+
+// String returns a summary of the tracer's state.
+func (tr *tracer) String() string {
+	s := strings.Builder{}
+
+	if tr.active() {
+		s.WriteString(fmt.Sprintf("tracing to %s (%d lines)", tr.filename, tr.lines))
+	} else {
+		s.WriteString("not tracing")
+	}
+
+	if f := tr.filters(); f != "" {
+		s.WriteString(fmt.Sprintf(" [%s]", f))
+	}
+
+	return s.String()
+}
+
+// filters returns a description of the active filters. returns the empty
+// string if there are no filters.
+func (tr *tracer) filters() string {
+	f := make([]string, 0, 3)
+	if tr.address != nil {
+		f = append(f, fmt.Sprintf("address %#04x to %#04x", tr.address.from, tr.address.to))
+	}
+	if tr.bank != nil {
+		f = append(f, fmt.Sprintf("bank %d", tr.bank.from))
+	}
+	if tr.frame != nil {
+		f = append(f, fmt.Sprintf("frame %d to %d", tr.frame.from, tr.frame.to))
+	}
+	return strings.Join(f, ", ")
+}
@@ -0,0 +1,153 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package debugger_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func (trm *mockTerm) testTrace() {
+	// tracer is not active at startup
+	trm.sndInput("TRACE")
+	trm.cmpOutput("not tracing")
+
+	trm.sndInput("TRACE STOP")
+	trm.cmpOutput("trace error: not tracing")
+
+	// filters can be set before tracing begins
+	trm.sndInput("TRACE FILTER FRAME 10 20")
+	trm.cmpOutput("trace filter: frame 10 to 20")
+
+	trm.sndInput("TRACE FILTER FRAME 20 10")
+	trm.cmpOutput("frame range is backwards")
+
+	trm.sndInput("TRACE")
+	trm.cmpOutput("not tracing [frame 10 to 20]")
+
+	trm.sndInput("TRACE FILTER CLEAR")
+	trm.cmpOutput("no trace filter")
+}
+
+// readTrace returns the lines of the trace file
+func (trm *mockTerm) readTrace(filename string) []string {
+	trm.t.Helper()
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		trm.t.Errorf(err.Error())
+		return []string{}
+	}
+
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+// cmpTraceWidth checks that every line in the trace is the same width
+func (trm *mockTerm) cmpTraceWidth(lines []string) {
+	trm.t.Helper()
+
+	for _, l := range lines {
+		if len(l) != len(lines[0]) {
+			trm.t.Errorf("unexpected trace line width (%s) should be %d", l, len(lines[0]))
+		}
+	}
+}
+
+func TestTraceProgram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	traceFile := filepath.Join(dir, "trace.txt")
+	frameFile := filepath.Join(dir, "frame.txt")
+
+	runProgram(t, frameProgram, func(trm *mockTerm) {
+		defer func() { trm.sndInput("QUIT") }()
+
+		// the symbols file is missing. ignore the error
+		trm.rcvOutput()
+
+		trm.sndInput("TRACE START " + traceFile)
+		trm.cmpOutput("tracing to " + traceFile)
+		for i := 0; i < 4; i++ {
+			trm.sndInput("STEP")
+		}
+		trm.sndInput("TRACE")
+		trm.cmpOutput("tracing to " + traceFile + " (4 lines)")
+		trm.sndInput("TRACE STOP")
+		trm.cmpOutput("4 lines written to " + traceFile)
+
+		expected := []string{
+			"     0   0  -62   0 f000 a2 ff    LDX #$ff             A=00 X=ff Y=00 SP=ff Sv-bdizc",
+			"     0   0  -56   0 f002 9a       TXS                  A=00 X=ff Y=00 SP=ff Sv-bdizc",
+			"     0   0  -50   0 f003 a9 02    LDA #$02             A=02 X=ff Y=00 SP=ff sv-bdizc",
+			"     0   0  -41   0 f005 85 00    STA VSYNC            A=02 X=ff Y=00 SP=ff sv-bdizc",
+		}
+
+		lines := trm.readTrace(traceFile)
+		if len(lines) != len(expected) {
+			trm.t.Errorf("unexpected number of trace lines (%d) should be %d", len(lines), len(expected))
+		} else {
+			for i := range lines {
+				if lines[i] != expected[i] {
+					trm.t.Errorf("unexpected trace line (%s) should be (%s)", lines[i], expected[i])
+				}
+			}
+		}
+
+		// trace the whole of the third frame while running to the fifth. the
+		// frame begins when VSYNC is turned off and there are 790
+		// instructions in each frame
+		trm.sndInput("TRACE FILTER FRAME 2 2")
+		trm.cmpOutput("trace filter: frame 2 to 2")
+		trm.sndInput("TRACE START " + frameFile)
+		trm.cmpOutput("tracing to " + frameFile)
+		trm.sndInput("BREAK FRAME 4")
+		trm.sndInput("RUN")
+		trm.rcvOutput()
+		trm.sndInput("TRACE STOP")
+		trm.cmpOutput("790 lines written to " + frameFile)
+
+		lines = trm.readTrace(frameFile)
+		if len(lines) != 790 {
+			trm.t.Errorf("unexpected number of trace lines (%d) should be 790", len(lines))
+			return
+		}
+		for _, l := range lines {
+			if !strings.HasPrefix(l, "     2 ") {
+				trm.t.Errorf("unexpected trace line (%s) should be in frame 2", l)
+			}
+		}
+		if !strings.HasPrefix(lines[0], "     2   0  -53   0 f00f 85 00    STA VSYNC") {
+			trm.t.Errorf("unexpected first line of frame (%s)", lines[0])
+		}
+		if !strings.HasPrefix(lines[len(lines)-1], "     2 262  -62   0 f00d a9 00    LDA #$00") {
+			trm.t.Errorf("unexpected last line of frame (%s)", lines[len(lines)-1])
+		}
+
+		// the two traces line up
+		trm.cmpTraceWidth(append(lines, expected...))
+	})
+}
@@ -103,18 +103,34 @@ func (tr traps) list() {
 
 // parse tokens and add new trap
 func (tr *traps) parseTrap(tokens *commandline.Tokens) error {
+	// an expression is a single trap that halts execution when the value of the
+	// expression changes
+	expr := isExpression(tokens.Remainder())
+
 	_, present := tokens.Peek()
 	for present {
-		tgt, err := parseTarget(tr.dbg, tokens)
-		if err != nil {
-			return err
+		var tgt *target
+
+		if expr {
+			ex, err := compileExpression(tr.dbg, tokens.Remainder())
+			if err != nil {
+				return err
+			}
+			tokens.End()
+			tgt = ex.valueTarget()
+		} else {
+			var err error
+			tgt, err = parseTarget(tr.dbg, tokens)
+			if err != nil {
+				return err
+			}
 		}
 
 		addNewTrap := true
 		for _, t := range tr.traps {
 			if t.target.Label() == tgt.Label() {
 				addNewTrap = false
-				tr.dbg.printLine(terminal.StyleError, fmt.Sprintf("trap already exists (%s)", t))
+				tr.dbg.printLine(terminal.StyleError, "trap already exists (%s)", t)
 				break // for loop
 			}
 		}
@@ -35,4 +35,14 @@ func (trm *mockTerm) testTraps() {
 	// list traps. compare last line.
 	trm.sndInput("LIST TRAPS")
 	trm.cmpOutput(" 0: A")
+
+	// add an expression trap
+	trm.sndInput("TRAP [$80] & $0f")
+	trm.cmpOutput("")
+
+	trm.sndInput("TRAP [$80]&$0f")
+	trm.cmpOutput("trap already exists ([$80] & $0f)")
+
+	trm.sndInput("LIST TRAPS")
+	trm.cmpOutput(" 1: [$80] & $0f")
 }
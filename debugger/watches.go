@@ -37,6 +37,9 @@ type watcher struct {
 	// watcher will match regardless of the value
 	matchValue bool
 	value      uint8
+
+	// an optional condition that must also be true for the watch to match
+	cond *expression
 }
 
 func (wtr watcher) String() string {
@@ -48,7 +51,11 @@ func (wtr watcher) String() string {
 	if wtr.ai.read {
 		event = "read"
 	}
-	return fmt.Sprintf("%s %s%s", wtr.ai, event, val)
+	cond := ""
+	if wtr.cond != nil {
+		cond = fmt.Sprintf(" if %s", wtr.cond)
+	}
+	return fmt.Sprintf("%s %s%s%s", wtr.ai, event, val, cond)
 }
 
 // the list of currently defined watches in the system
@@ -112,6 +119,11 @@ func (wtc *watches) check(previousResult string) string {
 		if (wtc.watches[i].ai.read == false && wtc.vcsmem.LastAccessWrite) ||
 			(wtc.watches[i].ai.read == true && !wtc.vcsmem.LastAccessWrite) {
 
+			// the watch does not match if its condition is false
+			if wtc.watches[i].cond != nil && wtc.watches[i].cond.eval() == 0 {
+				continue
+			}
+
 			// match watched-for value to the value that was read/written to the
 			// watched address
 			if !wtc.watches[i].matchValue {
@@ -152,6 +164,19 @@ func (wtc *watches) list() {
 
 // parse tokens and add new watch. unlike breakpoints and traps, only one watch
 // at a time can be specified on the command line.
+// returns true if the string begins with a binary operator. a number followed
+// by such a string is the first operand of a condition and not a watch value
+func beginsBinaryOperator(s string) bool {
+	for _, g := range binaryOperators {
+		for _, op := range g {
+			if strings.HasPrefix(s, op) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (wtc *watches) parseWatch(tokens *commandline.Tokens) error {
 	var event int
 
@@ -199,21 +224,39 @@ func (wtc *watches) parseWatch(tokens *commandline.Tokens) error {
 		return errors.New(errors.CommandError, fmt.Sprintf("invalid watch address: %s", a))
 	}
 
-	// get value if possible
+	// get value if possible. the next token is a value if it is a number that
+	// does not begin a condition
 	var val uint64
 	var err error
-	v, useVal := tokens.Get()
-	if useVal {
-		val, err = strconv.ParseUint(v, 0, 8)
+	useVal := false
+	if v, ok := tokens.Get(); ok {
+		n, _ := tokens.Peek()
+		if isExpression(v) || beginsBinaryOperator(n) {
+			tokens.Unget()
+		} else if val, err = strconv.ParseUint(v, 0, 8); err == nil {
+			useVal = true
+		} else if tokens.Remaining() == 0 {
+			return errors.New(errors.CommandError, fmt.Sprintf("invalid watch value (%s)", v))
+		} else {
+			tokens.Unget()
+		}
+	}
+
+	// any remaining tokens are the condition
+	var cond *expression
+	if tokens.Remaining() > 0 {
+		cond, err = compileExpression(wtc.dbg, tokens.Remainder())
 		if err != nil {
-			return errors.New(errors.CommandError, fmt.Sprintf("invalid watch value (%s)", a))
+			return err
 		}
+		tokens.End()
 	}
 
 	nw := watcher{
 		ai:         *ai,
 		matchValue: useVal,
 		value:      uint8(val),
+		cond:       cond,
 	}
 
 	// check to see if watch already exists
@@ -229,7 +272,8 @@ func (wtc *watches) parseWatch(tokens *commandline.Tokens) error {
 		// that only the larger set remains, it may confuse the user
 		if w.ai.address == nw.ai.address &&
 			w.ai.read == nw.ai.read &&
-			w.matchValue == nw.matchValue && w.value == nw.value {
+			w.matchValue == nw.matchValue && w.value == nw.value &&
+			w.cond.String() == nw.cond.String() {
 
 			return errors.New(errors.CommandError, fmt.Sprintf("already being watched (%s)", w))
 		}
@@ -72,4 +72,31 @@ func (trm *mockTerm) testWatches() {
 	// last item in list watches should be the new entry
 	trm.sndInput("LIST WATCHES")
 	trm.cmpOutput(" 1: 0x0000 (VSYNC) (TIA) write (value=0x01)")
+
+	// add watch with a condition
+	trm.sndInput("WATCH VSYNC SL > 100")
+	trm.cmpOutput("")
+
+	trm.sndInput("LIST WATCHES")
+	trm.cmpOutput(" 2: 0x0000 (VSYNC) (TIA) write if SL > 100")
+
+	trm.sndInput("WATCH VSYNC SL>100")
+	trm.cmpOutput("already being watched (0x0000 (VSYNC) (TIA) write if SL > 100)")
+
+	// add watch with a value and a condition
+	trm.sndInput("WATCH VSYNC 0x1 SL>100")
+	trm.cmpOutput("")
+
+	trm.sndInput("LIST WATCHES")
+	trm.cmpOutput(" 3: 0x0000 (VSYNC) (TIA) write (value=0x01) if SL > 100")
+
+	// a number followed by an operator begins a condition
+	trm.sndInput("WATCH VSYNC 100 < SL")
+	trm.cmpOutput("")
+
+	trm.sndInput("LIST WATCHES")
+	trm.cmpOutput(" 4: 0x0000 (VSYNC) (TIA) write if 100 < SL")
+
+	trm.sndInput("WATCH VSYNC FOO")
+	trm.cmpOutput("invalid watch value (FOO)")
 }
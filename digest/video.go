@@ -27,9 +27,10 @@ import (
 	"github.com/jetsetilly/gopher2600/television"
 )
 
-// Video is an implementation of the television.PixelRenderer interface with an
-// embedded television for convenience. It generates a SHA-1 value of the image
-// every frame. it does not display the image anywhere.
+// Video is an implementation of the television.PixelRenderer and
+// television.FrameRenderer interfaces with an embedded television for
+// convenience. It generates a SHA-1 value of the image every frame. It does
+// not display the image anywhere.
 //
 // Note that the use of SHA-1 is fine for this application because this is not
 // a cryptographic task.
@@ -107,23 +108,38 @@ func (dig *Video) NewScanline(scanline int) error {
 	return nil
 }
 
-// SetPixel implements television.PixelRenderer interface
-func (dig *Video) SetPixel(x, y int, red, green, blue byte, vblank bool) error {
-	// preserve the first few bytes for a chained fingerprint
-	i := len(dig.digest)
-	i += television.HorizClksScanline * y * pixelDepth
-	i += x * pixelDepth
-
-	if i <= len(dig.pixels)-pixelDepth {
-		// setting every pixel regardless of vblank value
-		dig.pixels[i] = red
-		dig.pixels[i+1] = green
-		dig.pixels[i+2] = blue
+// RenderFrame implements television.FrameRenderer interface
+func (dig *Video) RenderFrame(frame *television.Frame) error {
+	for y := 0; y < frame.Scanlines; y++ {
+		for x := 0; x < television.HorizClksScanline; x++ {
+			// preserve the first few bytes for a chained fingerprint
+			i := len(dig.digest)
+			i += television.HorizClksScanline * y * pixelDepth
+			i += x * pixelDepth
+
+			if i > len(dig.pixels)-pixelDepth {
+				return nil
+			}
+
+			// setting every pixel regardless of vblank value
+			col := frame.RGB(x, y)
+			dig.pixels[i] = col.Red
+			dig.pixels[i+1] = col.Green
+			dig.pixels[i+2] = col.Blue
+		}
 	}
 
 	return nil
 }
 
+// SetPixel implements television.PixelRenderer interface
+//
+// Video implements the television.FrameRenderer interface so SetPixel() is
+// never called. Pixels are instead read from the completed frame.
+func (dig *Video) SetPixel(x, y int, red, green, blue byte, vblank bool) error {
+	return nil
+}
+
 // SetAltPixel implements television.PixelRenderer interface
 func (dig *Video) SetAltPixel(x, y int, red, green, blue byte, vblank bool) error {
 	return nil
@@ -79,6 +79,35 @@ func (dsm *Disassembly) BlessEntry(bank int, address uint16) {
 	}
 }
 
+// Reformat every entry in the disassembly. This should be called when the
+// symbols table has been changed.
+func (dsm *Disassembly) Reformat() error {
+	dsm.crit.Lock()
+	defer dsm.crit.Unlock()
+
+	dsm.fields = fields{}
+
+	for b := range dsm.reference {
+		for _, e := range dsm.reference[b] {
+			if e == nil {
+				continue // for loop
+			}
+
+			n, err := dsm.FormatResult(b, e.Result, e.Level)
+			if err != nil {
+				return errors.New(errors.DisasmError, err)
+			}
+			*e = *n
+
+			if e.Level == EntryLevelBlessed {
+				dsm.fields.updateWidths(e)
+			}
+		}
+	}
+
+	return nil
+}
+
 // FromCartridge initialises a new partial emulation and returns a
 // disassembly from the supplied cartridge filename. - useful for one-shot
 // disassemblies, like the gopher2600 "disasm" mode
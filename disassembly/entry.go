@@ -151,7 +151,7 @@ func (dsm *Disassembly) FormatResult(bank int, result execution.Result, level En
 	d.Address = fmt.Sprintf("0x%04x", result.Address)
 
 	// look up address in symbol table
-	if v, ok := dsm.Symtable.Locations.Symbols[result.Address]; ok {
+	if v, ok := dsm.Symtable.Locations.Get(bank, result.Address); ok {
 		d.Location = v
 	}
 
@@ -250,23 +250,23 @@ func (dsm *Disassembly) FormatResult(bank int, result execution.Result, level En
 						pc.Add(operand)
 
 						// -- look up mock program counter value in symbol table
-						if v, ok := dsm.Symtable.Locations.Symbols[pc.Address()]; ok {
+						if v, ok := dsm.Symtable.Locations.Get(bank, pc.Address()); ok {
 							d.Operand = v
 						}
 
 					} else {
-						if v, ok := dsm.Symtable.Locations.Symbols[operand]; ok {
+						if v, ok := dsm.Symtable.Locations.Get(bank, operand); ok {
 							d.Operand = v
 						}
 					}
 				case instructions.Read:
-					if v, ok := dsm.Symtable.Read.Symbols[operand]; ok {
+					if v, ok := dsm.Symtable.Read.Get(bank, operand); ok {
 						d.Operand = v
 					}
 				case instructions.Write:
 					fallthrough
 				case instructions.RMW:
-					if v, ok := dsm.Symtable.Write.Symbols[operand]; ok {
+					if v, ok := dsm.Symtable.Write.Get(bank, operand); ok {
 						d.Operand = v
 					}
 				}
@@ -0,0 +1,92 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package disassembly
+
+import (
+	ref "github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+	"github.com/jetsetilly/gopher2600/symbols"
+)
+
+// matchSource returns true if the bytes generated by the source line are the
+// same as the bytes of the disassembled instruction.
+func matchSource(sl *symbols.SourceLine, e *Entry) bool {
+	if e == nil || e.Result.Defn == nil {
+		return false
+	}
+
+	b := []uint8{e.Result.Defn.OpCode, uint8(e.Result.InstructionData), uint8(e.Result.InstructionData >> 8)}
+	b = b[:e.Result.ByteCount]
+
+	// DASM lists at most four bytes for a single source line and data lines
+	// may generate more bytes than an instruction
+	n := len(b)
+	if len(sl.Bytes) < n {
+		n = len(sl.Bytes)
+	}
+	if n == 0 {
+		return false
+	}
+
+	for i := 0; i < n; i++ {
+		if sl.Bytes[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Source returns the source line for the disassembly entry. returns nil if
+// there is no listing file or if no source line can be found.
+//
+// For cartridges with more than one bank the same address may appear in the
+// listing more than once. The source line chosen is the one that generated
+// the same bytes as the entry.
+func (dsm *Disassembly) Source(e *Entry) *symbols.SourceLine {
+	if dsm.Symtable == nil || dsm.Symtable.Source == nil {
+		return nil
+	}
+
+	lines := dsm.Symtable.Source.LinesAtAddress(e.Result.Address)
+	for _, sl := range lines {
+		if matchSource(sl, e) {
+			return sl
+		}
+	}
+
+	// there's no ambiguity with only one bank
+	if len(lines) > 0 && len(dsm.reference) == 1 {
+		return lines[0]
+	}
+
+	return nil
+}
+
+// SourceBanks returns the banks in which the code generated by the source
+// line can be found.
+func (dsm *Disassembly) SourceBanks(sl *symbols.SourceLine) []int {
+	banks := make([]int, 0, len(dsm.reference))
+	for b := range dsm.reference {
+		if matchSource(sl, dsm.reference[b][sl.Address&ref.AddressMaskCart]) {
+			banks = append(banks, b)
+		}
+	}
+	return banks
+}
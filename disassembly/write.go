@@ -30,6 +30,10 @@ import (
 type WriteAttr struct {
 	ByteCode bool
 	Raw      bool
+
+	// Annotate is called for every entry that is written. if the returned
+	// string is not empty it is appended to the end of the line.
+	Annotate func(e *Entry) string
 }
 
 // Write the entire disassembly to io.Writer
@@ -94,5 +98,12 @@ func (dsm *Disassembly) WriteEntry(output io.Writer, attr WriteAttr, e *Entry) {
 	output.Write([]byte(" "))
 	output.Write([]byte(dsm.GetField(FldDefnNotes, e)))
 
+	if attr.Annotate != nil {
+		if a := attr.Annotate(e); a != "" {
+			output.Write([]byte(" "))
+			output.Write([]byte(a))
+		}
+	}
+
 	output.Write([]byte("\n"))
 }
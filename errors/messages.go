@@ -44,6 +44,8 @@ const (
 	TerminalError   = "%v"
 	GUIEventError   = "%v"
 	BreakpointError = "breakpoint error: %v"
+	ExpressionError = "expression error: %v"
+	TraceError      = "trace error: %v"
 
 	// commandline
 	ParserError     = "parser error: %v"
@@ -76,6 +78,7 @@ const (
 	RegressionError         = "regression error: %v"
 	RegressionDigestError   = "digest entry: %v"
 	RegressionPlaybackError = "playback entry: %v"
+	RegressionDiffError     = "differential run: %v"
 
 	// setup
 	SetupError           = "setup error: %v"
@@ -87,15 +90,19 @@ const (
 	PatchError = "patch error: %v"
 
 	// symbols
+	SymbolsError           = "symbols error: %v"
 	SymbolsFileError       = "symbols error: error processing symbols file: %v"
 	SymbolsFileUnavailable = "symbols error: no symbols file for %v"
 	SymbolUnknown          = "symbols error: unrecognised symbol (%v)"
+	ListingFileError       = "symbols error: error processing listing file: %v"
+	ListingFileUnavailable = "symbols error: no listing file for %v"
 
 	// cartridgeloader
 	CartridgeLoader = "cartridge loading error: %v"
 
 	// vcs
 	PolycounterError = "polycounter error: %v"
+	StateError       = "state error: %v"
 
 	// cpu
 	UnimplementedInstruction       = "cpu error: unimplemented instruction (%#02x) at (%#04x)"
@@ -116,12 +123,17 @@ const (
 	UnpatchableCartType = "cartridge error: cannot patch this cartridge type (%v)"
 
 	// input
-	UnknownInputEvent = "input error: %v: unsupported event (%v)"
-	BadInputEventType = "input error: bad value type for event %v (expecting %s)"
+	UnknownInputEvent   = "input error: %v: unsupported event (%v)"
+	BadInputEventType   = "input error: bad value type for event %v (expecting %s)"
+	UnknownKeypadLayout = "input error: unknown keypad layout (%v)"
 
 	// television
 	UnknownTVRequest = "television error: unsupported request (%v)"
 	Television       = "television error: %v"
+	PaletteError     = "palette error: %v"
+
+	// screenshot
+	ScreenshotError = "screenshot error: %v"
 
 	// digests
 	VideoDigest = "video digest: %v"
@@ -130,8 +142,25 @@ const (
 	// audio2wav
 	WavWriter = "wav writer: %v"
 
+	// videowriter
+	VideoWriter = "video writer: %v"
+
+	// gifwriter
+	GifWriter = "gif writer: %v"
+
+	// postprocess
+	PostProcessError = "post-processing: %v"
+
+	// signallog
+	SignalLog = "signal log: %v"
+
+	// cheats
+	CheatsError = "cheats error: %v"
+
 	// gui
 	UnsupportedGUIRequest = "gui error: unsupported request (%v)"
 	SDLDebug              = "sdldebug: %v"
 	SDLPlay               = "sdlplay: %v"
+	GamepadMappingError   = "gamepad mapping: %v"
+	BindingsError         = "key bindings: %v"
 )
@@ -0,0 +1,28 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package gifwriter allows a short sequence of television frames to be saved
+// to disk as an animated GIF. The GIF palette is the VCS palette of the
+// television specification so no dithering is required.
+//
+// Note that many GIF viewers do not honour frame delays of less than two
+// hundredths of a second. The frame delay is therefore never less than that,
+// meaning that a GIF of an NTSC television will play slightly slower than the
+// real thing.
+package gifwriter
@@ -0,0 +1,246 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package gifwriter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+	"os"
+	"time"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/screenshot"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// the minimum delay between frames, measured in hundredths of a second
+const minDelay = 2
+
+// the maximum number of colors in a GIF palette
+const maxColors = 256
+
+// GifWriter implements the television.PixelRenderer interface. The frames are
+// taken from a screenshot.Screenshot.
+type GifWriter struct {
+	television.Television
+
+	scr *screenshot.Screenshot
+
+	// the number of frames requested by the most recent call to Start()
+	numFrames int
+
+	// the first new frame after a call to Start() marks the beginning of a
+	// complete frame
+	started bool
+
+	frames []*image.Paletted
+	delays []int
+
+	// frames are not collected while the capture is suspended
+	suspended bool
+
+	palette color.Palette
+	index   map[color.RGBA]uint8
+}
+
+// New is the preferred method of initialisation for the GifWriter type. The
+// screenshot argument can be nil, in which case a new instance of
+// screenshot.Screenshot will be created. The new instance is added to the
+// television's list of pixel renderers.
+//
+// Frames will not be collected until Start() is called.
+func New(tv television.Television, scr *screenshot.Screenshot) (*GifWriter, error) {
+	var err error
+
+	if scr == nil {
+		scr, err = screenshot.NewScreenshot(tv)
+		if err != nil {
+			return nil, errors.New(errors.GifWriter, err)
+		}
+	}
+
+	gw := &GifWriter{
+		Television: tv,
+		scr:        scr,
+	}
+
+	tv.AddPixelRenderer(gw)
+
+	return gw, nil
+}
+
+// Start collecting frames. Any previously collected frames are discarded. The
+// first frame collected is the first complete frame after Start() is called.
+func (gw *GifWriter) Start(numFrames int) {
+	gw.numFrames = numFrames
+	gw.started = false
+	gw.frames = gw.frames[:0]
+	gw.delays = gw.delays[:0]
+
+	// the palette begins with the colors of the current specification in
+	// order. any additional colors seen in the frames (because the palette has
+	// been changed for example) are added as they are found
+	gw.palette = make(color.Palette, 0, maxColors)
+	gw.index = make(map[color.RGBA]uint8)
+	gw.addColor(color.RGBA{A: 255})
+	for _, c := range gw.GetSpec().Colors {
+		gw.addColor(color.RGBA{R: c.Red, G: c.Green, B: c.Blue, A: 255})
+	}
+}
+
+// addColor to the palette if it is not already present. returns the index of
+// the color in the palette. if the palette is full the nearest color is used
+func (gw *GifWriter) addColor(c color.RGBA) uint8 {
+	if i, ok := gw.index[c]; ok {
+		return i
+	}
+
+	if len(gw.palette) >= maxColors {
+		return uint8(gw.palette.Index(c))
+	}
+
+	i := uint8(len(gw.palette))
+	gw.palette = append(gw.palette, c)
+	gw.index[c] = i
+
+	return i
+}
+
+// Suspend (or resume) the capture. Frames produced by the television while the
+// capture is suspended are not collected.
+func (gw *GifWriter) Suspend(suspend bool) {
+	gw.suspended = suspend
+}
+
+// Frames returns the number of frames collected since the last call to Start()
+func (gw *GifWriter) Frames() int {
+	return len(gw.frames)
+}
+
+// Done returns true if all the frames requested by Start() have been
+// collected
+func (gw *GifWriter) Done() bool {
+	return gw.numFrames > 0 && len(gw.frames) >= gw.numFrames
+}
+
+// collect the most recent frame
+func (gw *GifWriter) collect() {
+	img := gw.scr.Image(screenshot.Options{})
+
+	frame := image.NewPaletted(img.Bounds(), nil)
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			frame.SetColorIndex(x, y, gw.addColor(img.RGBAAt(x, y)))
+		}
+	}
+	gw.frames = append(gw.frames, frame)
+
+	delay := int(math.Round(100.0 / float64(gw.GetSpec().FramesPerSecond)))
+	if delay < minDelay {
+		delay = minDelay
+	}
+	gw.delays = append(gw.delays, delay)
+}
+
+// Save the collected frames as an animated GIF. The animation loops forever.
+func (gw *GifWriter) Save(filename string) error {
+	if len(gw.frames) == 0 {
+		return errors.New(errors.GifWriter, "no frames to save")
+	}
+
+	// all frames share the same palette. the palette may have grown since the
+	// first frames were collected so it is set here
+	for _, f := range gw.frames {
+		f.Palette = gw.palette
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.New(errors.GifWriter, err)
+	}
+
+	err = gif.EncodeAll(f, &gif.GIF{
+		Image: gw.frames,
+		Delay: gw.delays,
+	})
+	if err != nil {
+		_ = f.Close()
+		return errors.New(errors.GifWriter, err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return errors.New(errors.GifWriter, err)
+	}
+
+	return nil
+}
+
+// Resize implements television.PixelRenderer interface
+func (gw *GifWriter) Resize(_, _ int) error {
+	return nil
+}
+
+// NewFrame implements television.PixelRenderer interface
+func (gw *GifWriter) NewFrame(_ int) error {
+	if gw.numFrames == 0 || gw.Done() || gw.suspended {
+		return nil
+	}
+
+	if !gw.started {
+		gw.started = true
+		return nil
+	}
+
+	gw.collect()
+
+	return nil
+}
+
+// NewScanline implements television.PixelRenderer interface
+func (gw *GifWriter) NewScanline(_ int) error {
+	return nil
+}
+
+// SetPixel implements television.PixelRenderer interface
+func (gw *GifWriter) SetPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// SetAltPixel implements television.PixelRenderer interface
+func (gw *GifWriter) SetAltPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// EndRendering implements television.PixelRenderer interface
+func (gw *GifWriter) EndRendering() error {
+	return nil
+}
+
+// UniqueFilename returns a filename, based on the name of the cartridge and
+// the current time, that is suitable for a new GIF
+func UniqueFilename(cartName string) string {
+	n := time.Now()
+	return fmt.Sprintf("gif_%s_%04d%02d%02d_%02d%02d%02d.gif",
+		cartName, n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second())
+}
@@ -0,0 +1,131 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package gifwriter_test
+
+import (
+	"image/gif"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/gifwriter"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// send frames to the television, each frame using a different color
+func signalFrames(t *testing.T, tv television.Television, frames int) {
+	t.Helper()
+
+	for f := 0; f < frames; f++ {
+		for sl := 0; sl < tv.GetSpec().ScanlinesTotal; sl++ {
+			for hp := 0; hp < television.HorizClksScanline; hp++ {
+				err := tv.Signal(television.SignalAttributes{
+					VSync: sl < 3,
+					HSync: hp >= 16 && hp < 36,
+					Pixel: television.ColorSignal((f * 2) & 0xfe),
+				})
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+		}
+	}
+}
+
+func TestGifWriter(t *testing.T) {
+	tv, err := television.NewTelevision("PAL")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	gw, err := gifwriter.New(tv, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// no frames are collected before Start()
+	signalFrames(t, tv, 2)
+	if gw.Frames() != 0 || gw.Done() {
+		t.Fatalf("frames collected before capture started")
+	}
+
+	gw.Start(5)
+
+	// no frames are collected while the capture is suspended
+	gw.Suspend(true)
+	signalFrames(t, tv, 2)
+	if gw.Frames() != 0 {
+		t.Fatalf("frames collected while capture suspended")
+	}
+	gw.Suspend(false)
+
+	signalFrames(t, tv, 10)
+	if !gw.Done() {
+		t.Fatalf("capture not completed")
+	}
+	if gw.Frames() != 5 {
+		t.Errorf("expected 5 frames (got %d)", gw.Frames())
+	}
+
+	dir, err := ioutil.TempDir("", "gifwriter")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "test.gif")
+	err = gw.Save(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer f.Close()
+
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(g.Image) != 5 {
+		t.Errorf("expected 5 frames in GIF (got %d)", len(g.Image))
+	}
+
+	// PAL is 50 frames per second
+	for _, d := range g.Delay {
+		if d != 2 {
+			t.Errorf("unexpected frame delay (%d)", d)
+		}
+	}
+
+	// every frame is a different color so the middle pixel of consecutive
+	// frames should be different
+	w := g.Image[0].Bounds().Dx() / 2
+	h := g.Image[0].Bounds().Dy() / 2
+	for i := 1; i < len(g.Image); i++ {
+		if g.Image[i].ColorIndexAt(w, h) == g.Image[i-1].ColorIndexAt(w, h) {
+			t.Errorf("frames %d and %d are the same color", i-1, i)
+		}
+	}
+}
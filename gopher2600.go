@@ -37,13 +37,20 @@ import (
 	"github.com/jetsetilly/gopher2600/gui/sdldebug"
 	"github.com/jetsetilly/gopher2600/gui/sdlimgui"
 	"github.com/jetsetilly/gopher2600/gui/sdlplay"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
 	"github.com/jetsetilly/gopher2600/modalflag"
 	"github.com/jetsetilly/gopher2600/paths"
 	"github.com/jetsetilly/gopher2600/performance"
 	"github.com/jetsetilly/gopher2600/playmode"
+	"github.com/jetsetilly/gopher2600/postprocess"
 	"github.com/jetsetilly/gopher2600/recorder"
 	"github.com/jetsetilly/gopher2600/regression"
+	"github.com/jetsetilly/gopher2600/setup"
+	"github.com/jetsetilly/gopher2600/signallog"
 	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/television/colors"
+	"github.com/jetsetilly/gopher2600/videowriter"
 	"github.com/jetsetilly/gopher2600/wavwriter"
 )
 
@@ -178,7 +185,7 @@ func launch(sync *mainSync) {
 	md := &modalflag.Modes{Output: os.Stdout}
 	md.NewArgs(os.Args[1:])
 	md.NewMode()
-	md.AddSubModes("RUN", "PLAY", "DEBUG", "DISASM", "PERFORMANCE", "REGRESS")
+	md.AddSubModes("RUN", "PLAY", "DEBUG", "DISASM", "PERFORMANCE", "REGRESS", "SIGNALS")
 
 	p, err := md.Parse()
 	switch p {
@@ -207,6 +214,9 @@ func launch(sync *mainSync) {
 
 	case "REGRESS":
 		err = regress(md)
+
+	case "SIGNALS":
+		err = signals(md)
 	}
 
 	if err != nil {
@@ -219,13 +229,21 @@ func play(md *modalflag.Modes, sync *mainSync) error {
 	md.NewMode()
 
 	cartFormat := md.AddString("cartformat", "AUTO", "force use of cartridge format")
-	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL")
+	spec := md.AddString("tv", "AUTO", fmt.Sprintf("television specification: AUTO, %s", strings.Join(television.SpecList, ", ")))
 	scaling := md.AddFloat64("scale", 3.0, "television scaling")
+	crt := md.AddBool("crt", false, "television behaves like a real CRT when the signal is out of spec")
+	palette := md.AddString("palette", "", "palette file (.pal format) for the initial television specification")
 	stable := md.AddBool("stable", true, "wait for stable frame before opening display")
 	fpsCap := md.AddBool("fpscap", true, "cap fps to specification")
 	record := md.AddBool("record", false, "record user input to a file")
 	wav := md.AddString("wav", "", "record audio to wav file")
+	video := md.AddString("video", "", "record video and audio to avi file")
+	postProcess := md.AddString("postprocess", "", "crt effects applied without the gpu: DEFAULT or name=value list (scanlines, blur, persistence, blend, bloom, bloomthreshold)")
 	patchFile := md.AddString("patch", "", "patch file to apply (cartridge args only)")
+	keypad := md.AddString("keypad", "STANDARD", fmt.Sprintf("keypad layout: %s", strings.Join(input.KeypadLayoutList, ", ")))
+	lightGun := md.AddBool("lightgun", false, "plug light gun into left player port (aim with mouse)")
+	screenshotAtFrame := md.AddInt("screenshot-at-frame", 0, "save a screenshot when frame is complete and then quit (0 to disable)")
+	signalLog := md.AddString("signallog", "", "log changes to the television signal to file")
 
 	p, err := md.Parse()
 	if p != modalflag.ParseContinue {
@@ -236,6 +254,11 @@ func play(md *modalflag.Modes, sync *mainSync) error {
 	case 0:
 		return fmt.Errorf("2600 cartridge required for %s mode", md)
 	case 1:
+		keypadLayout, err := input.NewKeypadLayout(strings.ToUpper(*keypad))
+		if err != nil {
+			return errors.New(errors.PlayError, err)
+		}
+
 		cartload := cartridgeloader.Loader{
 			Filename: md.GetArg(0),
 			Format:   *cartFormat,
@@ -250,6 +273,40 @@ func play(md *modalflag.Modes, sync *mainSync) error {
 		// set fps cap
 		tv.SetFPSCap(*fpsCap)
 
+		tv.SetCRTMode(*crt)
+
+		if *palette != "" {
+			err = setPalette(tv, *palette)
+			if err != nil {
+				return errors.New(errors.PlayError, err)
+			}
+		}
+
+		// the post-processing stage sits between the television and the
+		// renderers. from this point on the stage is used in place of the
+		// television
+		if *postProcess != "" {
+			params, err := postprocess.ParseParams(*postProcess)
+			if err != nil {
+				return errors.New(errors.PlayError, err)
+			}
+			tv, err = postprocess.NewStage(tv, params)
+			if err != nil {
+				return errors.New(errors.PlayError, err)
+			}
+		}
+
+		// the signal logger sits between the VCS and the television. from
+		// this point on the logger is used in place of the television
+		if *signalLog != "" {
+			lg, err := signallog.New(tv, *signalLog)
+			if err != nil {
+				return errors.New(errors.PlayError, err)
+			}
+			defer lg.Close()
+			tv = lg
+		}
+
 		// add wavwriter mixer if wav argument has been specified
 		if *wav != "" {
 			aw, err := wavwriter.New(*wav)
@@ -259,6 +316,15 @@ func play(md *modalflag.Modes, sync *mainSync) error {
 			tv.AddAudioMixer(aw)
 		}
 
+		// add videowriter if video argument has been specified. the
+		// videowriter adds itself to the television
+		if *video != "" {
+			_, err := videowriter.New(tv, nil, *video)
+			if err != nil {
+				return errors.New(errors.PlayError, err)
+			}
+		}
+
 		// create gui
 		sync.creator <- func() (GuiCreator, error) {
 			return sdlplay.NewSdlPlay(tv, float32(*scaling))
@@ -283,7 +349,7 @@ func play(md *modalflag.Modes, sync *mainSync) error {
 			return err
 		}
 
-		err = playmode.Play(tv, scr, *stable, *record, cartload, *patchFile)
+		err = playmode.Play(tv, scr, *stable, *record, cartload, *patchFile, keypadLayout, *lightGun, *screenshotAtFrame)
 		if err != nil {
 			return err
 		}
@@ -308,8 +374,10 @@ func debug(md *modalflag.Modes, sync *mainSync) error {
 	}
 
 	cartFormat := md.AddString("cartformat", "AUTO", "force use of cartridge format")
-	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL")
+	spec := md.AddString("tv", "AUTO", fmt.Sprintf("television specification: AUTO, %s", strings.Join(television.SpecList, ", ")))
 	termType := md.AddString("term", "IMGUI", "terminal type to use in debug mode: IMGUI, COLOR, PLAIN")
+	crt := md.AddBool("crt", false, "television behaves like a real CRT when the signal is out of spec")
+	palette := md.AddString("palette", "", "palette file (.pal format) for the initial television specification")
 	initScript := md.AddString("initscript", defInitScript, "script to run on debugger start")
 	profile := md.AddBool("profile", false, "run debugger through cpu profiler")
 
@@ -324,6 +392,15 @@ func debug(md *modalflag.Modes, sync *mainSync) error {
 	}
 	defer tv.End()
 
+	tv.SetCRTMode(*crt)
+
+	if *palette != "" {
+		err = setPalette(tv, *palette)
+		if err != nil {
+			return errors.New(errors.DebuggerError, err)
+		}
+	}
+
 	var term terminal.Terminal
 
 	// decide which gui to use
@@ -484,7 +561,7 @@ func perform(md *modalflag.Modes, sync *mainSync) error {
 	display := md.AddBool("display", false, "display TV output")
 	fpsCap := md.AddBool("fpscap", true, "cap FPS to specification (only valid if -display=true)")
 	scaling := md.AddFloat64("scale", 3.0, "display scaling (only valid if -display=true")
-	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL")
+	spec := md.AddString("tv", "AUTO", fmt.Sprintf("television specification: AUTO, %s", strings.Join(television.SpecList, ", ")))
 	duration := md.AddString("duration", "5s", "run duration (note: there is a 2s overhead)")
 	profile := md.AddBool("profile", false, "produce cpu and memory profiling reports")
 
@@ -550,6 +627,123 @@ func perform(md *modalflag.Modes, sync *mainSync) error {
 	return nil
 }
 
+func signals(md *modalflag.Modes) error {
+	md.NewMode()
+	md.AddSubModes("LOG", "SUMMARY")
+
+	p, err := md.Parse()
+	if p != modalflag.ParseContinue {
+		return err
+	}
+
+	switch md.Mode() {
+	case "LOG":
+		md.NewMode()
+
+		cartFormat := md.AddString("cartformat", "AUTO", "force use of cartridge format")
+		spec := md.AddString("tv", "AUTO", fmt.Sprintf("television specification: AUTO, %s", strings.Join(television.SpecList, ", ")))
+		frames := md.AddInt("frames", 60, "number of frames to log")
+		summary := md.AddBool("summary", true, "summarise frame structure once the log is complete")
+
+		p, err := md.Parse()
+		if p != modalflag.ParseContinue {
+			return err
+		}
+
+		var filename string
+
+		switch len(md.RemainingArgs()) {
+		case 0:
+			return fmt.Errorf("2600 cartridge required for %s mode", md)
+		case 1:
+		case 2:
+			filename = md.GetArg(1)
+		default:
+			return fmt.Errorf("too many arguments for %s mode", md)
+		}
+
+		cartload := cartridgeloader.Loader{
+			Filename: md.GetArg(0),
+			Format:   *cartFormat,
+		}
+
+		if filename == "" {
+			filename = signallog.UniqueFilename(cartload.ShortName())
+		}
+
+		tv, err := television.NewTelevision(*spec)
+		if err != nil {
+			return errors.New(errors.SignalLog, err)
+		}
+		defer tv.End()
+
+		tv.SetFPSCap(false)
+
+		lg, err := signallog.New(tv, filename)
+		if err != nil {
+			return err
+		}
+
+		vcs, err := hardware.NewVCS(lg)
+		if err != nil {
+			_ = lg.Close()
+			return errors.New(errors.SignalLog, err)
+		}
+
+		err = setup.AttachCartridge(vcs, cartload)
+		if err != nil {
+			_ = lg.Close()
+			return errors.New(errors.SignalLog, err)
+		}
+
+		err = vcs.RunForFrameCount(*frames, nil)
+		if err != nil {
+			_ = lg.Close()
+			return errors.New(errors.SignalLog, err)
+		}
+
+		err = lg.Close()
+		if err != nil {
+			return err
+		}
+
+		md.Output.Write([]byte(fmt.Sprintf("! signal log written to %s\n", filename)))
+
+		if *summary {
+			return summariseSignalLog(md.Output, filename)
+		}
+
+	case "SUMMARY":
+		md.NewMode()
+
+		p, err := md.Parse()
+		if p != modalflag.ParseContinue {
+			return err
+		}
+
+		switch len(md.RemainingArgs()) {
+		case 0:
+			return fmt.Errorf("signal log required for %s mode", md)
+		case 1:
+			return summariseSignalLog(md.Output, md.GetArg(0))
+		default:
+			return fmt.Errorf("too many arguments for %s mode", md)
+		}
+	}
+
+	return nil
+}
+
+func summariseSignalLog(output io.Writer, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return errors.New(errors.SignalLog, err)
+	}
+	defer f.Close()
+
+	return signallog.Summarise(output, f)
+}
+
 type yesReader struct{}
 
 func (*yesReader) Read(p []byte) (n int, err error) {
@@ -559,7 +753,7 @@ func (*yesReader) Read(p []byte) (n int, err error) {
 
 func regress(md *modalflag.Modes) error {
 	md.NewMode()
-	md.AddSubModes("RUN", "LIST", "DELETE", "ADD")
+	md.AddSubModes("RUN", "LIST", "DELETE", "ADD", "DIFF")
 
 	p, err := md.Parse()
 	if p != modalflag.ParseContinue {
@@ -637,6 +831,9 @@ func regress(md *modalflag.Modes) error {
 
 	case "ADD":
 		return regressAdd(md)
+
+	case "DIFF":
+		return regressDiff(md)
 	}
 
 	return nil
@@ -646,7 +843,7 @@ func regressAdd(md *modalflag.Modes) error {
 	md.NewMode()
 
 	cartFormat := md.AddString("cartformat", "AUTO", "force use of cartridge format")
-	spec := md.AddString("tv", "AUTO", "television specification: NTSC, PAL [cartridge args only]")
+	spec := md.AddString("tv", "AUTO", fmt.Sprintf("television specification: AUTO, %s [cartridge args only]", strings.Join(television.SpecList, ", ")))
 	numframes := md.AddInt("frames", 10, "number of frames to run [cartridge args only]")
 	state := md.AddBool("state", false, "record TV state at every CPU step [cartrdige args only]")
 	mode := md.AddString("mode", "video", "type of digest to create [cartridge args only]")
@@ -712,3 +909,45 @@ func regressAdd(md *modalflag.Modes) error {
 
 	return nil
 }
+
+// regressDiff runs the same cartridge or playback file in two differently
+// configured emulations and reports where their output first diverges
+func regressDiff(md *modalflag.Modes) error {
+	md.NewMode()
+
+	tvA := md.AddString("tvA", "", "television specification for emulation A (default: AUTO or the playback's specification)")
+	tvB := md.AddString("tvB", "", "television specification for emulation B (default: AUTO or the playback's specification)")
+	formatA := md.AddString("formatA", "", "cartridge format for emulation A (default: AUTO or the playback's format)")
+	formatB := md.AddString("formatB", "", "cartridge format for emulation B (default: AUTO or the playback's format)")
+	numFrames := md.AddInt("frames", 0, "number of frames to compare (default: length of playback or 100 frames for cartridges)")
+
+	p, err := md.Parse()
+	if p != modalflag.ParseContinue {
+		return err
+	}
+
+	switch len(md.RemainingArgs()) {
+	case 0:
+		return fmt.Errorf("2600 cartridge or playback file required for %s mode", md)
+	case 1:
+		a := regression.DiffSetup{TVSpec: *tvA, CartFormat: *formatA}
+		b := regression.DiffSetup{TVSpec: *tvB, CartFormat: *formatB}
+		_, err := regression.RegressDifferential(md.Output, md.GetArg(0), a, b, *numFrames)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("only one cartridge or playback file can be compared when using %s mode", md)
+	}
+
+	return nil
+}
+
+// setPalette loads the named palette file and gives it to the television
+func setPalette(tv television.Television, filename string) error {
+	p, err := colors.LoadPalette(filename)
+	if err != nil {
+		return err
+	}
+	return tv.SetPalette(p)
+}
@@ -79,3 +79,72 @@ type EventDbgMouseButton struct {
 	HorizPos int
 	Scanline int
 }
+
+// GamepadID identifies a gamepad connected to the host. The ID is unique for
+// as long as the gamepad remains connected.
+type GamepadID int
+
+// GamepadButton identifies a gamepad button. The naming follows the layout of
+// the Xbox 360 controller, as is the convention of most gamepad libraries.
+type GamepadButton string
+
+// list of valid GamepadButtons
+const (
+	GamepadButtonA             GamepadButton = "A"
+	GamepadButtonB             GamepadButton = "B"
+	GamepadButtonX             GamepadButton = "X"
+	GamepadButtonY             GamepadButton = "Y"
+	GamepadButtonBack          GamepadButton = "BACK"
+	GamepadButtonGuide         GamepadButton = "GUIDE"
+	GamepadButtonStart         GamepadButton = "START"
+	GamepadButtonLeftStick     GamepadButton = "LEFTSTICK"
+	GamepadButtonRightStick    GamepadButton = "RIGHTSTICK"
+	GamepadButtonLeftShoulder  GamepadButton = "LEFTSHOULDER"
+	GamepadButtonRightShoulder GamepadButton = "RIGHTSHOULDER"
+	GamepadButtonDPadUp        GamepadButton = "DPADUP"
+	GamepadButtonDPadDown      GamepadButton = "DPADDOWN"
+	GamepadButtonDPadLeft      GamepadButton = "DPADLEFT"
+	GamepadButtonDPadRight     GamepadButton = "DPADRIGHT"
+)
+
+// GamepadAxis identifies an analogue gamepad axis
+type GamepadAxis string
+
+// list of valid GamepadAxes
+const (
+	GamepadAxisLeftX        GamepadAxis = "LEFTX"
+	GamepadAxisLeftY        GamepadAxis = "LEFTY"
+	GamepadAxisRightX       GamepadAxis = "RIGHTX"
+	GamepadAxisRightY       GamepadAxis = "RIGHTY"
+	GamepadAxisTriggerLeft  GamepadAxis = "TRIGGERLEFT"
+	GamepadAxisTriggerRight GamepadAxis = "TRIGGERRIGHT"
+)
+
+// EventGamepadConnected is sent when a gamepad has been connected to the host
+type EventGamepadConnected struct {
+	ID   GamepadID
+	Name string
+}
+
+// EventGamepadDisconnected is sent when a gamepad has been disconnected from
+// the host
+type EventGamepadDisconnected struct {
+	ID GamepadID
+}
+
+// EventGamepadButton is the data that accompanies gamepad button events
+type EventGamepadButton struct {
+	ID     GamepadID
+	Button GamepadButton
+	Down   bool
+}
+
+// EventGamepadAxis is the data that accompanies gamepad axis events
+type EventGamepadAxis struct {
+	ID   GamepadID
+	Axis GamepadAxis
+
+	// the amount of movement in the axis. for the sticks the range is -1.0 to
+	// 1.0 (left/up to right/down). for the triggers the range is 0.0 to 1.0
+	Amount float32
+}
@@ -0,0 +1,29 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package sdlgamepad converts SDL GameController events into the gamepad
+// events of the gui package. It is shared by the SDL based GUI
+// implementations.
+//
+// The SDL GameController API presents all supported gamepads with the same
+// layout of buttons and axes, regardless of the physical device. Gamepads
+// connected and disconnected while the program is running are handled with
+// the appropriate gui.EventGamepadConnected and gui.EventGamepadDisconnected
+// events.
+package sdlgamepad
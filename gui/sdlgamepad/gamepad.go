@@ -0,0 +1,161 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package sdlgamepad
+
+import (
+	"github.com/jetsetilly/gopher2600/gui"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// buttons maps SDL GameController buttons to gui.GamepadButton values
+var buttons = map[uint8]gui.GamepadButton{
+	sdl.CONTROLLER_BUTTON_A:             gui.GamepadButtonA,
+	sdl.CONTROLLER_BUTTON_B:             gui.GamepadButtonB,
+	sdl.CONTROLLER_BUTTON_X:             gui.GamepadButtonX,
+	sdl.CONTROLLER_BUTTON_Y:             gui.GamepadButtonY,
+	sdl.CONTROLLER_BUTTON_BACK:          gui.GamepadButtonBack,
+	sdl.CONTROLLER_BUTTON_GUIDE:         gui.GamepadButtonGuide,
+	sdl.CONTROLLER_BUTTON_START:         gui.GamepadButtonStart,
+	sdl.CONTROLLER_BUTTON_LEFTSTICK:     gui.GamepadButtonLeftStick,
+	sdl.CONTROLLER_BUTTON_RIGHTSTICK:    gui.GamepadButtonRightStick,
+	sdl.CONTROLLER_BUTTON_LEFTSHOULDER:  gui.GamepadButtonLeftShoulder,
+	sdl.CONTROLLER_BUTTON_RIGHTSHOULDER: gui.GamepadButtonRightShoulder,
+	sdl.CONTROLLER_BUTTON_DPAD_UP:       gui.GamepadButtonDPadUp,
+	sdl.CONTROLLER_BUTTON_DPAD_DOWN:     gui.GamepadButtonDPadDown,
+	sdl.CONTROLLER_BUTTON_DPAD_LEFT:     gui.GamepadButtonDPadLeft,
+	sdl.CONTROLLER_BUTTON_DPAD_RIGHT:    gui.GamepadButtonDPadRight,
+}
+
+// axes maps SDL GameController axes to gui.GamepadAxis values
+var axes = map[uint8]gui.GamepadAxis{
+	sdl.CONTROLLER_AXIS_LEFTX:        gui.GamepadAxisLeftX,
+	sdl.CONTROLLER_AXIS_LEFTY:        gui.GamepadAxisLeftY,
+	sdl.CONTROLLER_AXIS_RIGHTX:       gui.GamepadAxisRightX,
+	sdl.CONTROLLER_AXIS_RIGHTY:       gui.GamepadAxisRightY,
+	sdl.CONTROLLER_AXIS_TRIGGERLEFT:  gui.GamepadAxisTriggerLeft,
+	sdl.CONTROLLER_AXIS_TRIGGERRIGHT: gui.GamepadAxisTriggerRight,
+}
+
+// Gamepads keeps track of the gamepads connected to the host.
+//
+// SDL must have been initialised with the INIT_GAMECONTROLLER flag (or
+// INIT_EVERYTHING) before gamepads will be detected.
+type Gamepads struct {
+	// open controllers indexed by their SDL instance ID
+	controllers map[sdl.JoystickID]*sdl.GameController
+
+	// open() is called on receipt of a CONTROLLERDEVICEADDED event. it
+	// returns the instance ID and name of the newly opened controller. the
+	// function is replaced during testing so that no physical gamepad is
+	// required
+	open func(index int) (sdl.JoystickID, string, bool)
+}
+
+// NewGamepads is the preferred method of initialisation for the Gamepads type
+func NewGamepads() *Gamepads {
+	gp := &Gamepads{
+		controllers: make(map[sdl.JoystickID]*sdl.GameController),
+	}
+	gp.open = gp.openController
+	return gp
+}
+
+// openController opens the SDL GameController at the device index
+func (gp *Gamepads) openController(index int) (sdl.JoystickID, string, bool) {
+	if !sdl.IsGameController(index) {
+		return 0, "", false
+	}
+
+	ctrl := sdl.GameControllerOpen(index)
+	if ctrl == nil {
+		return 0, "", false
+	}
+
+	id := ctrl.Joystick().InstanceID()
+	gp.controllers[id] = ctrl
+
+	return id, ctrl.Name(), true
+}
+
+// Destroy closes all open controllers
+func (gp *Gamepads) Destroy() {
+	for id, ctrl := range gp.controllers {
+		ctrl.Close()
+		delete(gp.controllers, id)
+	}
+}
+
+// Convert an SDL event into the equivalent gui event. Returns false if the
+// SDL event is not a gamepad event or if it can't be converted.
+func (gp *Gamepads) Convert(ev sdl.Event) (gui.Event, bool) {
+	switch ev := ev.(type) {
+	case *sdl.ControllerDeviceEvent:
+		switch ev.Type {
+		case sdl.CONTROLLERDEVICEADDED:
+			// the Which field is the device index for this event type, not
+			// the instance ID
+			id, name, ok := gp.open(int(ev.Which))
+			if !ok {
+				return nil, false
+			}
+			return gui.EventGamepadConnected{ID: gui.GamepadID(id), Name: name}, true
+
+		case sdl.CONTROLLERDEVICEREMOVED:
+			if ctrl, ok := gp.controllers[ev.Which]; ok {
+				ctrl.Close()
+				delete(gp.controllers, ev.Which)
+			}
+			return gui.EventGamepadDisconnected{ID: gui.GamepadID(ev.Which)}, true
+		}
+
+	case *sdl.ControllerButtonEvent:
+		button, ok := buttons[ev.Button]
+		if !ok {
+			return nil, false
+		}
+		return gui.EventGamepadButton{
+			ID:     gui.GamepadID(ev.Which),
+			Button: button,
+			Down:   ev.Type == sdl.CONTROLLERBUTTONDOWN,
+		}, true
+
+	case *sdl.ControllerAxisEvent:
+		axis, ok := axes[ev.Axis]
+		if !ok {
+			return nil, false
+		}
+
+		// normalise value. the range of an SDL axis is -32768 to 32767 (the
+		// triggers never go below zero)
+		amount := float32(ev.Value) / 32767.0
+		if amount < -1.0 {
+			amount = -1.0
+		}
+
+		return gui.EventGamepadAxis{
+			ID:     gui.GamepadID(ev.Which),
+			Axis:   axis,
+			Amount: amount,
+		}, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,133 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package sdlgamepad
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/gui"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// newTestGamepads returns an instance of Gamepads that does not require a
+// physical gamepad
+func newTestGamepads() *Gamepads {
+	gp := NewGamepads()
+	gp.open = func(index int) (sdl.JoystickID, string, bool) {
+		return sdl.JoystickID(index + 100), "test gamepad", true
+	}
+	return gp
+}
+
+func TestHotplug(t *testing.T) {
+	gp := newTestGamepads()
+
+	ev, ok := gp.Convert(&sdl.ControllerDeviceEvent{Type: sdl.CONTROLLERDEVICEADDED, Which: 1})
+	if !ok {
+		t.Fatalf("device added event not converted")
+	}
+	if ev != (gui.EventGamepadConnected{ID: 101, Name: "test gamepad"}) {
+		t.Errorf("unexpected event for device added (%v)", ev)
+	}
+
+	ev, ok = gp.Convert(&sdl.ControllerDeviceEvent{Type: sdl.CONTROLLERDEVICEREMOVED, Which: 101})
+	if !ok {
+		t.Fatalf("device removed event not converted")
+	}
+	if ev != (gui.EventGamepadDisconnected{ID: 101}) {
+		t.Errorf("unexpected event for device removed (%v)", ev)
+	}
+}
+
+func TestButtons(t *testing.T) {
+	gp := newTestGamepads()
+
+	ev, ok := gp.Convert(&sdl.ControllerButtonEvent{
+		Type:   sdl.CONTROLLERBUTTONDOWN,
+		Which:  3,
+		Button: sdl.CONTROLLER_BUTTON_DPAD_LEFT,
+		State:  sdl.PRESSED,
+	})
+	if !ok {
+		t.Fatalf("button event not converted")
+	}
+	if ev != (gui.EventGamepadButton{ID: 3, Button: gui.GamepadButtonDPadLeft, Down: true}) {
+		t.Errorf("unexpected event for button down (%v)", ev)
+	}
+
+	ev, ok = gp.Convert(&sdl.ControllerButtonEvent{
+		Type:   sdl.CONTROLLERBUTTONUP,
+		Which:  3,
+		Button: sdl.CONTROLLER_BUTTON_A,
+		State:  sdl.RELEASED,
+	})
+	if !ok {
+		t.Fatalf("button event not converted")
+	}
+	if ev != (gui.EventGamepadButton{ID: 3, Button: gui.GamepadButtonA, Down: false}) {
+		t.Errorf("unexpected event for button up (%v)", ev)
+	}
+
+	// unknown buttons are not converted
+	_, ok = gp.Convert(&sdl.ControllerButtonEvent{
+		Type:   sdl.CONTROLLERBUTTONDOWN,
+		Button: sdl.CONTROLLER_BUTTON_MAX,
+	})
+	if ok {
+		t.Errorf("unknown button unexpectedly converted")
+	}
+}
+
+func TestAxes(t *testing.T) {
+	gp := newTestGamepads()
+
+	for _, tc := range []struct {
+		value  int16
+		amount float32
+	}{
+		{value: 0, amount: 0.0},
+		{value: 32767, amount: 1.0},
+		{value: -32767, amount: -1.0},
+		{value: -32768, amount: -1.0},
+	} {
+		ev, ok := gp.Convert(&sdl.ControllerAxisEvent{
+			Type:  sdl.CONTROLLERAXISMOTION,
+			Which: 0,
+			Axis:  sdl.CONTROLLER_AXIS_LEFTX,
+			Value: tc.value,
+		})
+		if !ok {
+			t.Fatalf("axis event not converted")
+		}
+		if ev != (gui.EventGamepadAxis{ID: 0, Axis: gui.GamepadAxisLeftX, Amount: tc.amount}) {
+			t.Errorf("unexpected event for axis value %d (%v)", tc.value, ev)
+		}
+	}
+}
+
+func TestNonGamepadEvent(t *testing.T) {
+	gp := newTestGamepads()
+
+	_, ok := gp.Convert(&sdl.QuitEvent{Type: sdl.QUIT})
+	if ok {
+		t.Errorf("non-gamepad event unexpectedly converted")
+	}
+}
@@ -62,12 +62,15 @@ type imguiColors struct {
 	DisasmOperand  imgui.Vec4
 	DisasmCycles   imgui.Vec4
 	DisasmNotes    imgui.Vec4
+	DisasmSource   imgui.Vec4
 
 	// disassembly other
 	DisasmCPUstep      imgui.Vec4
 	DisasmVideoStep    imgui.Vec4
 	DisasmBreakAddress imgui.Vec4
 	DisasmBreakOther   imgui.Vec4
+	DisasmCallChain    imgui.Vec4
+	DisasmHeat         imgui.Vec4
 
 	// audio oscilloscope
 	AudioOscBg   imgui.Vec4
@@ -89,12 +92,18 @@ type imguiColors struct {
 	TermStyleInstrument      imgui.Vec4
 	TermStyleError           imgui.Vec4
 
-	vec4PaletteNTSC   vec4Palette
-	vec4PalettePAL    vec4Palette
-	vec4PaletteAlt    vec4Palette
-	packedPaletteNTSC packedPalette
-	packedPalettePAL  packedPalette
-	packedPaletteAlt  packedPalette
+	vec4PaletteNTSC    vec4Palette
+	vec4PalettePAL     vec4Palette
+	vec4PaletteSECAM   vec4Palette
+	vec4PaletteAlt     vec4Palette
+	packedPaletteNTSC  packedPalette
+	packedPalettePAL   packedPalette
+	packedPaletteSECAM packedPalette
+	packedPaletteAlt   packedPalette
+
+	// the most recent user supplied palette and its packed equivalent
+	userPalette       colors.Palette
+	packedPaletteUser packedPalette
 }
 
 func newColors() *imguiColors {
@@ -129,10 +138,13 @@ func newColors() *imguiColors {
 		DisasmOperand:  imgui.Vec4{0.8, 0.8, 0.3, 1.0},
 		DisasmCycles:   imgui.Vec4{0.8, 0.8, 0.8, 1.0},
 		DisasmNotes:    imgui.Vec4{0.8, 0.8, 0.8, 1.0},
+		DisasmSource:   imgui.Vec4{0.5, 0.7, 0.5, 1.0},
 
 		// disassembly other
 		DisasmCPUstep:   imgui.Vec4{1.0, 1.0, 1.0, 0.1},
 		DisasmVideoStep: imgui.Vec4{1.0, 0.8, 0.8, 0.07},
+		DisasmCallChain: imgui.Vec4{0.4, 0.8, 0.4, 0.1},
+		DisasmHeat:      imgui.Vec4{1.0, 0.4, 0.1, 0.5},
 		// deferring DisasmBreakAddress & DisasmBreakOther
 
 		// audio oscilloscope
@@ -193,6 +205,17 @@ func newColors() *imguiColors {
 		cols.vec4PalettePAL = append(cols.vec4PalettePAL, v)
 	}
 
+	cols.vec4PaletteSECAM = make(vec4Palette, 0, len(colors.PaletteSECAM))
+	for _, c := range colors.PaletteSECAM {
+		v := imgui.Vec4{
+			float32(c.Red) / 255,
+			float32(c.Green) / 255,
+			float32(c.Blue) / 255,
+			1.0,
+		}
+		cols.vec4PaletteSECAM = append(cols.vec4PaletteSECAM, v)
+	}
+
 	cols.vec4PaletteAlt = make(vec4Palette, 0, len(colors.PaletteAlt))
 	for _, c := range colors.PaletteAlt {
 		v := imgui.Vec4{
@@ -214,6 +237,11 @@ func newColors() *imguiColors {
 		cols.packedPalettePAL = append(cols.packedPalettePAL, imgui.PackedColorFromVec4(c))
 	}
 
+	cols.packedPaletteSECAM = make(packedPalette, 0, len(cols.vec4PaletteSECAM))
+	for _, c := range cols.vec4PaletteSECAM {
+		cols.packedPaletteSECAM = append(cols.packedPaletteSECAM, imgui.PackedColorFromVec4(c))
+	}
+
 	cols.packedPaletteAlt = make(packedPalette, 0, len(cols.vec4PaletteAlt))
 	for _, c := range cols.vec4PaletteAlt {
 		cols.packedPaletteAlt = append(cols.packedPaletteAlt, imgui.PackedColorFromVec4(c))
@@ -221,3 +249,25 @@ func newColors() *imguiColors {
 
 	return &cols
 }
+
+// packUserPalette returns the packed equivalent of a user supplied palette.
+// the most recent palette is cached so this can be called every frame.
+func (cols *imguiColors) packUserPalette(p colors.Palette) packedPalette {
+	if len(cols.userPalette) > 0 && len(p) > 0 && &cols.userPalette[0] == &p[0] {
+		return cols.packedPaletteUser
+	}
+
+	cols.userPalette = p
+	cols.packedPaletteUser = make(packedPalette, 0, len(p))
+	for _, c := range p {
+		v := imgui.Vec4{
+			float32(c.Red) / 255,
+			float32(c.Green) / 255,
+			float32(c.Blue) / 255,
+			1.0,
+		}
+		cols.packedPaletteUser = append(cols.packedPaletteUser, imgui.PackedColorFromVec4(v))
+	}
+
+	return cols.packedPaletteUser
+}
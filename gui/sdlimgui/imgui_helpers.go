@@ -20,6 +20,8 @@
 package sdlimgui
 
 import (
+	"github.com/jetsetilly/gopher2600/television/colors"
+
 	"github.com/inkyblackness/imgui-go/v2"
 )
 
@@ -113,11 +115,19 @@ func (img *SdlImgui) imguiWindowQuadrant(p imgui.Vec2) imgui.Vec2 {
 
 // use appropriate palette for television spec
 func (img *SdlImgui) imguiTVPalette() (string, packedPalette) {
+	// the television is using a palette supplied by the user
+	if p := img.lazy.TV.Spec.Colors; len(p) > 0 &&
+		&p[0] != &colors.PaletteNTSC[0] && &p[0] != &colors.PalettePAL[0] && &p[0] != &colors.PaletteSECAM[0] {
+		return img.lazy.TV.Spec.ID + " (user)", img.cols.packUserPalette(p)
+	}
+
 	switch img.lazy.TV.Spec.ID {
-	case "PAL":
-		return "PAL", img.cols.packedPalettePAL
-	case "NTSC":
-		return "NTSC", img.cols.packedPaletteNTSC
+	case "PAL", "PAL60":
+		return img.lazy.TV.Spec.ID, img.cols.packedPalettePAL
+	case "NTSC", "NTSC50":
+		return img.lazy.TV.Spec.ID, img.cols.packedPaletteNTSC
+	case "SECAM":
+		return "SECAM", img.cols.packedPaletteSECAM
 	}
 
 	return "NTSC?", img.cols.packedPaletteNTSC
@@ -29,10 +29,12 @@ import (
 type LazyDebugger struct {
 	val *Values
 
-	atomicQuantum  atomic.Value // debugger.QuantumMode
-	atomicLastBank atomic.Value // int
-	Quantum        debugger.QuantumMode
-	LastBank       int
+	atomicQuantum   atomic.Value // debugger.QuantumMode
+	atomicLastBank  atomic.Value // int
+	atomicCallChain atomic.Value // []debugger.CallFrame
+	Quantum         debugger.QuantumMode
+	LastBank        int
+	CallChain       []debugger.CallFrame
 }
 
 func newLazyDebugger(val *Values) *LazyDebugger {
@@ -44,10 +46,13 @@ func (lz *LazyDebugger) update() {
 	lz.val.Dbg.PushRawEvent(func() {
 		lz.atomicLastBank.Store(lz.val.Dbg.GetLastBank())
 		lz.atomicQuantum.Store(lz.val.Dbg.GetQuantum())
+		lz.atomicCallChain.Store(lz.val.Dbg.GetCallChain())
 	})
 	lz.Quantum, _ = lz.atomicQuantum.Load().(debugger.QuantumMode)
 
 	if lz.atomicLastBank.Load() != nil {
 		lz.LastBank = lz.atomicLastBank.Load().(int)
 	}
+
+	lz.CallChain, _ = lz.atomicCallChain.Load().([]debugger.CallFrame)
 }
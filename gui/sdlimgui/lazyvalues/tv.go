@@ -35,6 +35,7 @@ type LazyTV struct {
 	atomicFrame      atomic.Value // int
 	atomicScanline   atomic.Value // int
 	atomicHP         atomic.Value // int
+	atomicSyncState  atomic.Value // television.SyncState
 	atomicReqFPS     atomic.Value // float32
 	atomicActualFPS  atomic.Value // float32
 
@@ -44,6 +45,7 @@ type LazyTV struct {
 	Frame      int
 	Scanline   int
 	HP         int
+	SyncState  television.SyncState
 	AcutalFPS  float32
 
 	// taken from debugger rather than tv
@@ -69,6 +71,9 @@ func (lz *LazyTV) update() {
 		hp, _ := lz.val.VCS.TV.GetState(television.ReqHorizPos)
 		lz.atomicHP.Store(hp)
 
+		sync, _ := lz.val.VCS.TV.GetState(television.ReqSyncState)
+		lz.atomicSyncState.Store(television.SyncState(sync))
+
 		lz.atomicReqFPS.Store(lz.val.Dbg.GetReqFPS())
 		lz.atomicActualFPS.Store(lz.val.VCS.TV.GetActualFPS())
 
@@ -79,6 +84,7 @@ func (lz *LazyTV) update() {
 	lz.Frame, _ = lz.atomicFrame.Load().(int)
 	lz.Scanline, _ = lz.atomicScanline.Load().(int)
 	lz.HP, _ = lz.atomicHP.Load().(int)
+	lz.SyncState, _ = lz.atomicSyncState.Load().(television.SyncState)
 	lz.ReqFPS, _ = lz.atomicReqFPS.Load().(float32)
 	lz.AcutalFPS, _ = lz.atomicActualFPS.Load().(float32)
 }
@@ -68,6 +68,9 @@ type Values struct {
 
 	// breakpoints
 	atomicBrk []atomic.Value // debugger.BreakGroup
+
+	// profiler heat
+	atomicHeat []atomic.Value // float32
 }
 
 // NewValues is the preferred method of initialisation for the Values type
@@ -93,6 +96,7 @@ func NewValues() *Values {
 	// allocating enough space for every byte in cartridge space. not worrying
 	// about bank sizes or anything like that.
 	val.atomicBrk = make([]atomic.Value, memorymap.MemtopCart-memorymap.OriginCart+1)
+	val.atomicHeat = make([]atomic.Value, memorymap.MemtopCart-memorymap.OriginCart+1)
 
 	return val
 }
@@ -148,3 +152,20 @@ func (val *Values) HasBreak(e *disassembly.Entry) debugger.BreakGroup {
 
 	return debugger.BrkNone
 }
+
+// ProfileHeat returns the relative number of cycles spent at the address of
+// the disassembly entry
+func (val *Values) ProfileHeat(e *disassembly.Entry) float32 {
+	if val.Dbg == nil {
+		return 0.0
+	}
+
+	addr := e.Result.Address & memorymap.AddressMaskCart
+
+	val.Dbg.PushRawEvent(func() {
+		val.atomicHeat[addr].Store(val.Dbg.ProfileHeat(e))
+	})
+
+	h, _ := val.atomicHeat[addr].Load().(float32)
+	return h
+}
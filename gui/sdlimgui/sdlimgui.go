@@ -25,6 +25,7 @@ import (
 	"github.com/jetsetilly/gopher2600/debugger/terminal"
 	"github.com/jetsetilly/gopher2600/gui"
 	"github.com/jetsetilly/gopher2600/gui/sdlaudio"
+	"github.com/jetsetilly/gopher2600/gui/sdlgamepad"
 	"github.com/jetsetilly/gopher2600/gui/sdlimgui/lazyvalues"
 	"github.com/jetsetilly/gopher2600/paths"
 	"github.com/jetsetilly/gopher2600/television"
@@ -53,6 +54,9 @@ type SdlImgui struct {
 	screen *screen
 	audio  *sdlaudio.Audio
 
+	// gamepads connected to the host
+	gamepads *sdlgamepad.Gamepads
+
 	// imgui window management
 	wm *windowManager
 
@@ -139,6 +143,10 @@ func NewSdlImgui(tv television.Television) (*SdlImgui, error) {
 	}
 	tv.AddAudioMixer(img.audio)
 
+	// gamepads already connected will be reported by SDL as newly added
+	// gamepads in the event queue
+	img.gamepads = sdlgamepad.NewGamepads()
+
 	return img, nil
 }
 
@@ -148,6 +156,7 @@ func NewSdlImgui(tv television.Television) (*SdlImgui, error) {
 func (img *SdlImgui) Destroy(output io.Writer) {
 	img.wm.destroy()
 	img.audio.EndMixing()
+	img.gamepads.Destroy()
 	img.glsl.destroy()
 
 	err := img.plt.destroy()
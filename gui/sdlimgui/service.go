@@ -168,6 +168,13 @@ func (img *SdlImgui) Service() {
 				}
 				img.io.AddMouseWheelDelta(deltaX*2, deltaY*2)
 
+			case *sdl.ControllerDeviceEvent, *sdl.ControllerButtonEvent, *sdl.ControllerAxisEvent:
+				// unlike keyboard events, gamepad events are forwarded even
+				// when the screen has not captured input. gamepads are not
+				// used by the imgui interface so there is no conflict
+				if gev, ok := img.gamepads.Convert(ev); ok {
+					img.events <- gev
+				}
 			}
 		}
 
@@ -21,6 +21,7 @@ package sdlimgui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jetsetilly/gopher2600/debugger"
 	"github.com/jetsetilly/gopher2600/disassembly"
@@ -40,6 +41,10 @@ type winDisasm struct {
 	showAllEntries bool
 	showByteCode   bool
 
+	// show the source line for each entry. only possible if the cartridge
+	// has a listing file
+	showSource bool
+
 	// height of options line at bottom of window. valid after first frame
 	optionsHeight float32
 
@@ -66,6 +71,7 @@ type winDisasm struct {
 	colVideoStep    imgui.PackedColor
 	colBreakAddress imgui.PackedColor
 	colBreakOther   imgui.PackedColor
+	colCallChain    imgui.PackedColor
 }
 
 func newWinDisasm(img *SdlImgui) (managedWindow, error) {
@@ -82,6 +88,7 @@ func (win *winDisasm) init() {
 	win.colVideoStep = imgui.PackedColorFromVec4(win.img.cols.DisasmVideoStep)
 	win.colBreakAddress = imgui.PackedColorFromVec4(win.img.cols.DisasmBreakAddress)
 	win.colBreakOther = imgui.PackedColorFromVec4(win.img.cols.DisasmBreakOther)
+	win.colCallChain = imgui.PackedColorFromVec4(win.img.cols.DisasmCallChain)
 
 }
 
@@ -180,6 +187,11 @@ func (win *winDisasm) draw() {
 		imgui.SameLine()
 		imgui.Checkbox("Show Bytecode", &win.showByteCode)
 
+		if win.img.lazy.Dsm.Symtable.Source != nil {
+			imgui.SameLine()
+			imgui.Checkbox("Show Source", &win.showSource)
+		}
+
 		imgui.SameLine()
 		if imgui.Button("Goto PC") {
 			win.alignOnPC = true
@@ -306,6 +318,27 @@ func (win *winDisasm) drawEntry(e *disassembly.Entry, pcaddr uint16, selected bo
 		adj = imgui.Vec4{0.1, 0.1, 0.1, 0.0}
 	}
 
+	// shade entry according to the number of cycles spent there by the
+	// profiler. the busiest address is shaded with the full heat colour
+	if heat := win.img.lazy.ProfileHeat(e); heat > 0.0 {
+		col := win.img.cols.DisasmHeat
+		col.W *= heat
+		p1 := imgui.CursorScreenPos()
+		p2 := p1
+		p2.X += imgui.WindowWidth()
+		p2.Y += imgui.FontSize() * 1.1
+		imgui.WindowDrawList().AddRectFilled(p1, p2, imgui.PackedColorFromVec4(col))
+	}
+
+	// highlight JSR instructions that are part of the current call chain
+	if win.inCallChain(e) {
+		p1 := imgui.CursorScreenPos()
+		p2 := p1
+		p2.X += imgui.WindowWidth()
+		p2.Y += imgui.FontSize() * 1.1
+		imgui.WindowDrawList().AddRectFilled(p1, p2, win.colCallChain)
+	}
+
 	// add some space for the gutter. has to be something tangible so that the
 	// IsItemVisible() check below has something to grab onto
 	imgui.Text(" ")
@@ -347,6 +380,16 @@ func (win *winDisasm) drawEntry(e *disassembly.Entry, pcaddr uint16, selected bo
 
 	imgui.PopStyleColorV(5)
 
+	if win.showSource {
+		if sl := win.img.lazy.Dsm.Source(e); sl != nil {
+			imgui.SameLine()
+			imgui.PushStyleColor(imgui.StyleColorText, win.img.cols.DisasmSource.Plus(adj))
+			// imgui doesn't expand tab characters
+			imgui.Text(fmt.Sprintf("%s  %s", sl, strings.ReplaceAll(sl.Text, "\t", " ")))
+			imgui.PopStyleColor()
+		}
+	}
+
 	imgui.EndGroup()
 
 	// the following Is*() conditions apply to the whole group
@@ -365,6 +408,18 @@ func (win *winDisasm) drawEntry(e *disassembly.Entry, pcaddr uint16, selected bo
 	}
 }
 
+// inCallChain returns true if the disassembly entry is the call site of a
+// subroutine in the current call chain
+func (win *winDisasm) inCallChain(e *disassembly.Entry) bool {
+	addr := e.Result.Address & memorymap.AddressMaskCart
+	for _, f := range win.img.lazy.Debugger.CallChain {
+		if f.CallBank == e.Bank && f.CallAddress&memorymap.AddressMaskCart == addr {
+			return true
+		}
+	}
+	return false
+}
+
 func (win *winDisasm) drawBreak(e *disassembly.Entry) {
 	switch win.img.lazy.HasBreak(e) {
 	case debugger.BrkPCAddress:
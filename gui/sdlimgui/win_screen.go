@@ -135,6 +135,9 @@ func (win *winScreen) draw() {
 	if win.img.lazy.TV.LastSignal.HSync {
 		signal.WriteString("HSYNC ")
 	}
+	if win.img.lazy.TV.SyncState != 0 {
+		signal.WriteString(win.img.lazy.TV.SyncState.String())
+	}
 	imgui.Text(signal.String())
 
 	// display toggles
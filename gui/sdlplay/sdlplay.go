@@ -25,6 +25,7 @@ import (
 	"github.com/jetsetilly/gopher2600/errors"
 	"github.com/jetsetilly/gopher2600/gui"
 	"github.com/jetsetilly/gopher2600/gui/sdlaudio"
+	"github.com/jetsetilly/gopher2600/gui/sdlgamepad"
 	"github.com/jetsetilly/gopher2600/television"
 
 	"github.com/veandco/go-sdl2/sdl"
@@ -52,6 +53,9 @@ type SdlPlay struct {
 	// all audio is handled by the sound type
 	aud *sdlaudio.Audio
 
+	// gamepads connected to the host
+	gamepads *sdlgamepad.Gamepads
+
 	// sdl stuff
 	window   *sdl.Window
 	renderer *sdl.Renderer
@@ -104,6 +108,10 @@ func NewSdlPlay(tv television.Television, scale float32) (*SdlPlay, error) {
 
 	setupService()
 
+	// gamepads already connected will be reported by SDL as newly added
+	// gamepads in the event queue
+	scr.gamepads = sdlgamepad.NewGamepads()
+
 	// SDL window - window size is set in Resize() function
 	scr.window, err = sdl.CreateWindow(windowTitle,
 		int32(sdl.WINDOWPOS_UNDEFINED), int32(sdl.WINDOWPOS_UNDEFINED),
@@ -157,6 +165,8 @@ func NewSdlPlay(tv television.Television, scale float32) (*SdlPlay, error) {
 //
 // MUST ONLY be called from the #mainthread
 func (scr *SdlPlay) Destroy(output io.Writer) {
+	scr.gamepads.Destroy()
+
 	err := scr.texture.Destroy()
 	if err != nil {
 		output.Write([]byte(err.Error()))
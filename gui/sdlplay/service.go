@@ -144,6 +144,11 @@ func (scr *SdlPlay) Service() {
 						Button: button,
 						Down:   ev.Type == sdl.MOUSEBUTTONDOWN}
 				}
+
+			case *sdl.ControllerDeviceEvent, *sdl.ControllerButtonEvent, *sdl.ControllerAxisEvent:
+				if gev, ok := scr.gamepads.Convert(ev); ok {
+					scr.events <- gev
+				}
 			}
 		}
 
@@ -172,6 +172,62 @@ func (mc *CPU) LoadPC(directAddress uint16) error {
 	return nil
 }
 
+// cpuState is the type returned by SaveState(). the bus, instruction table
+// and callback are not part of the state
+type cpuState struct {
+	PC     registers.ProgramCounter
+	A      registers.Register
+	X      registers.Register
+	Y      registers.Register
+	SP     registers.Register
+	Status registers.StatusRegister
+
+	RdyFlg     bool
+	LastResult execution.Result
+}
+
+// SaveState notes and returns the current state of the CPU. The state can
+// only be saved between instructions
+func (mc *CPU) SaveState() (interface{}, error) {
+	if mc.isExecuting {
+		return nil, errors.New(errors.InvalidOperationMidInstruction, "save state")
+	}
+
+	return cpuState{
+		PC:         *mc.PC,
+		A:          *mc.A,
+		X:          *mc.X,
+		Y:          *mc.Y,
+		SP:         *mc.SP,
+		Status:     *mc.Status,
+		RdyFlg:     mc.RdyFlg,
+		LastResult: mc.LastResult,
+	}, nil
+}
+
+// RestoreState returns the CPU to a state previously returned by SaveState()
+func (mc *CPU) RestoreState(state interface{}) error {
+	if mc.isExecuting {
+		return errors.New(errors.InvalidOperationMidInstruction, "restore state")
+	}
+
+	s, ok := state.(cpuState)
+	if !ok {
+		return errors.New(errors.StateError, "not a cpu state")
+	}
+
+	*mc.PC = s.PC
+	*mc.A = s.A
+	*mc.X = s.X
+	*mc.Y = s.Y
+	*mc.SP = s.SP
+	*mc.Status = s.Status
+	mc.RdyFlg = s.RdyFlg
+	mc.LastResult = s.LastResult
+
+	return nil
+}
+
 // read8Bit reads 8 bits from the specified address
 //
 // * note that read8Bit calls endCycle as appropriate
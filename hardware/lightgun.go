@@ -0,0 +1,90 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package hardware
+
+import (
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// the light sensor of the light gun isn't a single point. we allow the
+// electron beam to be detected for a few color clocks after the aim point.
+const lightGunSensorWidth = 4
+
+// the brightness (in the range 0 to 255) of a pixel required for the light
+// sensor to detect it
+const lightGunThreshold = 128
+
+// senseLight checks whether the electron beam, as represented by the
+// television, is passing the aim point of any light guns that are plugged in.
+// it should be called after every TIA step.
+func (vcs *VCS) senseLight() error {
+	for _, hc := range []*input.HandController{vcs.RIOT.Input.HandController0, vcs.RIOT.Input.HandController1} {
+		x, y, ok := hc.LightGunAim()
+		if !ok {
+			continue // for loop
+		}
+
+		lit, err := vcs.beamAt(x, y)
+		if err != nil {
+			return err
+		}
+
+		hc.SenseLight(lit)
+	}
+
+	return nil
+}
+
+// beamAt returns true if the electron beam is drawing a bright pixel at the
+// aim point. the aim point is specified as fractions of the visible screen.
+func (vcs *VCS) beamAt(x float32, y float32) (bool, error) {
+	spec := vcs.TV.GetSpec()
+
+	scanline, err := vcs.TV.GetState(television.ReqScanline)
+	if err != nil {
+		return false, err
+	}
+
+	if scanline != spec.ScanlineTop+int(y*float32(spec.ScanlinesVisible)) {
+		return false, nil
+	}
+
+	// horizontal position is relative to the end of the horizontal blank
+	horizPos, err := vcs.TV.GetState(television.ReqHorizPos)
+	if err != nil {
+		return false, err
+	}
+
+	aim := int(x * float32(television.HorizClksVisible))
+	if horizPos < aim || horizPos >= aim+lightGunSensorWidth {
+		return false, nil
+	}
+
+	sig := vcs.TV.GetLastSignal()
+	if sig.Pixel == television.VideoBlack {
+		return false, nil
+	}
+
+	col := spec.Colors[sig.Pixel]
+	luma := (299*int(col.Red) + 587*int(col.Green) + 114*int(col.Blue)) / 1000
+
+	return luma >= lightGunThreshold, nil
+}
@@ -0,0 +1,185 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package hardware_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestLightGun(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	read := func(address uint16) int {
+		t.Helper()
+		v, err := vcs.Mem.Read(address)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return int(v)
+	}
+
+	const inpt4 = 0x000c
+	const swcha = 0x0280
+
+	hc := vcs.RIOT.Input.HandController0
+
+	// aiming plugs the light gun in
+	_, _, ok := hc.LightGunAim()
+	test.Equate(t, ok, false)
+	test.ExpectedSuccess(t, hc.Handle(input.LightGunX, float32(0.5)))
+	test.ExpectedSuccess(t, hc.Handle(input.LightGunY, float32(0.25)))
+	x, y, ok := hc.LightGunAim()
+	test.Equate(t, ok, true)
+	if x != 0.5 || y != 0.25 {
+		t.Errorf("unexpected aim point (%f, %f)", x, y)
+	}
+
+	// the trigger is the joystick up direction
+	test.ExpectedSuccess(t, hc.Handle(input.LightGunTrigger, true))
+	test.Equate(t, read(swcha)&0xf0, 0xe0)
+	test.ExpectedSuccess(t, hc.Handle(input.LightGunTrigger, false))
+	test.Equate(t, read(swcha)&0xf0, 0xf0)
+
+	// light is sensed through the fire button input
+	test.Equate(t, read(inpt4)&0x80, 0x80)
+	hc.SenseLight(true)
+	test.Equate(t, read(inpt4)&0x80, 0x00)
+	hc.SenseLight(false)
+	test.Equate(t, read(inpt4)&0x80, 0x80)
+
+	// with the latch set the input remains low until the latch is released
+	vcs.RIOT.Input.VBlankBits.SetLatchFireButton(true)
+	hc.SenseLight(true)
+	hc.SenseLight(false)
+	test.Equate(t, read(inpt4)&0x80, 0x00)
+	vcs.RIOT.Input.VBlankBits.SetLatchFireButton(false)
+	test.Equate(t, read(inpt4)&0x80, 0x80)
+}
+
+// a program that fills every frame with a bright background
+var lightGunProgram = []uint8{
+	0xa9, 0x0e, // LDA #$0e
+	0x85, 0x09, // STA COLUBK
+	0xa9, 0x02, // frame: LDA #$02
+	0x85, 0x00, // STA VSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0xa9, 0x00, // LDA #$00
+	0x85, 0x00, // STA VSYNC
+	0x85, 0x01, // STA VBLANK
+	0xa2, 0x00, // LDX #$00
+	0x85, 0x02, // line: STA WSYNC
+	0xca,       // DEX
+	0xd0, 0xfb, // BNE line
+	0x4c, 0x04, 0xf0, // JMP frame
+}
+
+func TestLightGunRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_lightgun")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	rom := make([]uint8, 4096)
+	copy(rom, lightGunProgram)
+	rom[0xffc] = 0x00
+	rom[0xffd] = 0xf0
+	filename := filepath.Join(dir, "lightgun.bin")
+	err = ioutil.WriteFile(filename, rom, 0644)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: filename, Format: "AUTO"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	const inpt4 = 0x000c
+
+	// the latch holds the input low once the light has been sensed, so the
+	// result can be checked between instructions
+	vcs.RIOT.Input.VBlankBits.SetLatchFireButton(true)
+
+	// run for a few frames and report whether light was sensed
+	sensed := func() bool {
+		t.Helper()
+		start, err := vcs.TV.GetState(television.ReqFramenum)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		lit := false
+		err = vcs.Run(func() (bool, error) {
+			v, err := vcs.Mem.Read(inpt4)
+			if err != nil {
+				return false, err
+			}
+			lit = v&0x80 == 0x00
+			fn, err := vcs.TV.GetState(television.ReqFramenum)
+			if err != nil {
+				return false, err
+			}
+			return !lit && fn < start+3, nil
+		})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return lit
+	}
+
+	// no light gun has been plugged in yet
+	test.Equate(t, sensed(), false)
+
+	// aim at the middle of the screen, which is always lit
+	hc := vcs.RIOT.Input.HandController0
+	test.ExpectedSuccess(t, hc.Handle(input.LightGunX, float32(0.5)))
+	test.ExpectedSuccess(t, hc.Handle(input.LightGunY, float32(0.5)))
+	test.Equate(t, sensed(), true)
+}
@@ -54,6 +54,11 @@ type ChipMemory struct {
 	writeData    uint8
 	writeSignal  bool
 
+	// the last value written by the CPU to each register. the chips consume
+	// written values as they are serviced so this is the only record of
+	// them. used by the debugger
+	written []uint8
+
 	// readRegister works slightly different than writeAddress. it stores the
 	// register *name* of the last memory location *read* by the CPU
 	readRegister string
@@ -130,6 +135,17 @@ func (area *ChipMemory) Write(address uint16, data uint8) error {
 	area.writeAddress = address
 	area.writeSignal = true
 	area.writeData = data
+	area.written[address^area.origin] = data
 
 	return nil
 }
+
+// PeekWrite returns the value most recently written by the CPU to the write
+// register at address. Address must be normalised.
+func (area ChipMemory) PeekWrite(address uint16) (uint8, error) {
+	sym := addresses.Write[address]
+	if sym == "" {
+		return 0, errors.New(errors.UnpeekableAddress, fmt.Sprintf("%#04x", address))
+	}
+	return area.written[address^area.origin], nil
+}
@@ -33,6 +33,7 @@ func newRIOT() *ChipMemory {
 
 	// allocation the minimal amount of memory
 	area.memory = make([]uint8, area.memtop-area.origin+1)
+	area.written = make([]uint8, len(area.memory))
 
 	// SWCHA set on startup by NewHandController0() and NewHandController1()
 
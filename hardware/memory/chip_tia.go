@@ -34,6 +34,7 @@ func newTIA() *ChipMemory {
 
 	// allocation the minimal amount of memory
 	area.memory = make([]uint8, area.memtop-area.origin+1)
+	area.written = make([]uint8, len(area.memory))
 
 	// initial values
 	area.memory[addresses.INPT1] = 0x00
@@ -115,6 +115,85 @@ func (mem *VCSMemory) GetArea(area memorymap.Area) (bus.DebuggerBus, error) {
 	return nil, errors.New(errors.MemoryError, "area not mapped correctly")
 }
 
+// memoryState is the type returned by SaveState()
+type memoryState struct {
+	riot ChipMemory
+	tia  ChipMemory
+	ram  []uint8
+	cart interface{}
+
+	lastAccessAddress uint16
+	lastAccessValue   uint8
+	lastAccessWrite   bool
+	lastAccessID      int
+	accessCount       int
+}
+
+// SaveState notes and returns the current contents of every memory area,
+// including the state of the cartridge
+func (mem *VCSMemory) SaveState() interface{} {
+	s := memoryState{
+		riot:              *mem.RIOT,
+		tia:               *mem.TIA,
+		ram:               make([]uint8, len(mem.RAM.memory)),
+		cart:              mem.Cart.SaveState(),
+		lastAccessAddress: mem.LastAccessAddress,
+		lastAccessValue:   mem.LastAccessValue,
+		lastAccessWrite:   mem.LastAccessWrite,
+		lastAccessID:      mem.LastAccessID,
+		accessCount:       mem.accessCount,
+	}
+
+	// the chip memory copies share the underlying arrays with the live areas
+	s.riot.memory = make([]uint8, len(mem.RIOT.memory))
+	copy(s.riot.memory, mem.RIOT.memory)
+	s.tia.memory = make([]uint8, len(mem.TIA.memory))
+	copy(s.tia.memory, mem.TIA.memory)
+	s.riot.written = make([]uint8, len(mem.RIOT.written))
+	copy(s.riot.written, mem.RIOT.written)
+	s.tia.written = make([]uint8, len(mem.TIA.written))
+	copy(s.tia.written, mem.TIA.written)
+	copy(s.ram, mem.RAM.memory)
+
+	return s
+}
+
+// RestoreState returns memory to a state previously returned by SaveState().
+// The memory areas are altered in place so references to them remain valid
+func (mem *VCSMemory) RestoreState(state interface{}) error {
+	s, ok := state.(memoryState)
+	if !ok {
+		return errors.New(errors.StateError, "not a memory state")
+	}
+
+	err := mem.Cart.RestoreState(s.cart)
+	if err != nil {
+		return err
+	}
+
+	riot, riotWritten := mem.RIOT.memory, mem.RIOT.written
+	*mem.RIOT = s.riot
+	mem.RIOT.memory, mem.RIOT.written = riot, riotWritten
+	copy(mem.RIOT.memory, s.riot.memory)
+	copy(mem.RIOT.written, s.riot.written)
+
+	tia, tiaWritten := mem.TIA.memory, mem.TIA.written
+	*mem.TIA = s.tia
+	mem.TIA.memory, mem.TIA.written = tia, tiaWritten
+	copy(mem.TIA.memory, s.tia.memory)
+	copy(mem.TIA.written, s.tia.written)
+
+	copy(mem.RAM.memory, s.ram)
+
+	mem.LastAccessAddress = s.lastAccessAddress
+	mem.LastAccessValue = s.lastAccessValue
+	mem.LastAccessWrite = s.lastAccessWrite
+	mem.LastAccessID = s.lastAccessID
+	mem.accessCount = s.accessCount
+
+	return nil
+}
+
 // read maps an address to the normalised for all memory areas.
 func (mem *VCSMemory) read(address uint16, zeroPage bool) (uint8, error) {
 	// optimisation: called a lot. pointer to VCSMemory to prevent duffcopy
@@ -77,3 +77,42 @@ func TestDataMask(t *testing.T) {
 	// non-zero-page addressing
 	readData(t, mem, 0x171, 0x81)
 }
+
+func TestPeekWrite(t *testing.T) {
+	mem, err := memory.NewVCSMemory()
+	if err != nil {
+		t.Errorf("unexpected error (%s)", err)
+	}
+
+	// COLUBK and TIM64T
+	for _, w := range []struct {
+		area    *memory.ChipMemory
+		address uint16
+		data    uint8
+	}{
+		{area: mem.TIA, address: 0x09, data: 0x4e},
+		{area: mem.RIOT, address: 0x296, data: 0x2b},
+	} {
+		err = mem.Write(w.address, w.data)
+		if err != nil {
+			t.Errorf("unexpected error (%s)", err)
+		}
+
+		// the write is noted even after it has been serviced by the chip
+		w.area.ChipRead()
+
+		d, err := w.area.PeekWrite(w.address)
+		if err != nil {
+			t.Errorf("unexpected error (%s)", err)
+		}
+		if d != w.data {
+			t.Errorf("expecting %#02x received %#02x", w.data, d)
+		}
+	}
+
+	// there is no write register at 0x2d
+	_, err = mem.TIA.PeekWrite(0x2d)
+	if err == nil {
+		t.Errorf("expected error peeking unwritable address")
+	}
+}
@@ -49,13 +49,27 @@ const (
 	PanelTogglePlayer0Pro Event = "PanelTogglePlayer0Pro" // nil
 	PanelTogglePlayer1Pro Event = "PanelTogglePlayer1Pro" // nil
 
+	// Sega Genesis gamepad. the B button is the joystick fire button
+	GenesisButtonC Event = "GenesisButtonC" // bool
+
+	// CBS Booster Grip. the joystick fire button is also available
+	BoosterGripTrigger Event = "BoosterGripTrigger" // bool
+	BoosterGripBooster Event = "BoosterGripBooster" // bool
+
+	// Atari XG-1 light gun. the aim point is set with separate events for the
+	// x and y axes. values are fractions of the visible screen
+	LightGunTrigger Event = "LightGunTrigger" // bool
+	LightGunX       Event = "LightGunX"       // float32
+	LightGunY       Event = "LightGunY"       // float32
+
 	// paddles
 	PaddleFire Event = "PaddleFire" // bool
 	PaddleSet  Event = "PaddleSet"  // float64
 
-	// keypad (only need down event)
+	// keypad. more than one key can be held down at once. a KeypadUp event
+	// with nil data releases all keys
 	KeypadDown Event = "KeypadDown" // rune
-	KeypadUp   Event = "KeypadUp"   // nil
+	KeypadUp   Event = "KeypadUp"   // rune or nil
 
 	PanelPowerOff Event = "PanelPowerOff" // nil
 )
@@ -42,8 +42,36 @@ const (
 	JoystickType ControllerType = iota
 	PaddleType
 	KeypadType
+	GenesisType
+	BoosterGripType
+	LightGunType
 )
 
+// String implements the fmt.Stringer interface
+func (t ControllerType) String() string {
+	switch t {
+	case JoystickType:
+		return "joystick"
+	case PaddleType:
+		return "paddle"
+	case KeypadType:
+		return "keypad"
+	case GenesisType:
+		return "genesis"
+	case BoosterGripType:
+		return "booster grip"
+	case LightGunType:
+		return "light gun"
+	}
+	return "unknown"
+}
+
+// isStick returns true if the controller type is a joystick or a joystick
+// compatible controller
+func (t ControllerType) isStick() bool {
+	return t == JoystickType || t == GenesisType || t == BoosterGripType
+}
+
 // HandController represents the "joystick" port on the VCS. The different
 // devices (joysticks, paddles, etc.) send events to the Handle() function.
 //
@@ -57,9 +85,11 @@ type HandController struct {
 	which ControllerType
 
 	// controller types
-	stick  stick
-	paddle paddle
-	keypad keypad
+	stick    stick
+	paddle   paddle
+	keypad   keypad
+	extra    extraButtons
+	lightGun lightGun
 
 	// data direction register. for simplicity, the bits should be normalised
 	// such that only the upper nibble is used. in reality, player 0
@@ -127,14 +157,28 @@ type paddle struct {
 // to fill the capacitor.
 const bestGuessSensitivity = 0.01
 
-// the keypad type implements the keypad or "keyboard" controller
-type keypad struct {
-	column [3]addresses.ChipRegister
-	key    rune
+// the extraButtons type implements the additional buttons of the Sega Genesis
+// gamepad and the CBS Booster Grip. both controllers are joystick compatible
+// and signal the state of the extra buttons through the paddle inputs of the
+// controller port.
+//
+// the Genesis gamepad uses only pin five (the C button) while the Booster Grip
+// uses pin five (the booster) and pin nine (the trigger).
+type extraButtons struct {
+	pinFive addresses.ChipRegister
+	pinNine addresses.ChipRegister
 }
 
-// the value of keypad.key when nothing is being pressed
-const noKey = ' '
+// the values written to the paddle input by the Genesis C button. a pressed
+// button grounds the input
+const genesisButtonOn = uint8(0x00)
+const genesisButtonOff = uint8(0x80)
+
+// the values written to the paddle inputs by the Booster Grip buttons. the
+// logic is the opposite of the Genesis gamepad: the input reads high when the
+// button is pressed
+const boosterGripButtonOn = uint8(0x80)
+const boosterGripButtonOff = uint8(0x00)
 
 // NewHandController0 is the preferred method of creating a new instance of
 // HandController for representing hand controller zero
@@ -156,7 +200,10 @@ func NewHandController0(mem *inputMemory, control *VBlankBits) *HandController {
 		},
 		keypad: keypad{
 			column: [3]addresses.ChipRegister{addresses.INPT0, addresses.INPT1, addresses.INPT4},
-			key:    noKey,
+		},
+		extra: extraButtons{
+			pinFive: addresses.INPT1,
+			pinNine: addresses.INPT0,
 		},
 		normaliseOnRead:  func(n uint8) uint8 { return n & 0xf0 },
 		normaliseOnWrite: func(n uint8) uint8 { return n },
@@ -196,7 +243,10 @@ func NewHandController1(mem *inputMemory, control *VBlankBits) *HandController {
 		},
 		keypad: keypad{
 			column: [3]addresses.ChipRegister{addresses.INPT2, addresses.INPT3, addresses.INPT5},
-			key:    noKey,
+		},
+		extra: extraButtons{
+			pinFive: addresses.INPT3,
+			pinNine: addresses.INPT2,
 		},
 		normaliseOnRead:  func(n uint8) uint8 { return (n & 0x0f) << 4 },
 		normaliseOnWrite: func(n uint8) uint8 { return n >> 4 },
@@ -231,15 +281,39 @@ func (hc *HandController) SwitchType(prospective ControllerType) bool {
 
 	switch prospective {
 	case JoystickType:
+		// the Genesis gamepad and the Booster Grip are joystick compatible so
+		// there is no need to switch if either of those is being used
+		if hc.which == GenesisType || hc.which == BoosterGripType {
+			return true
+		}
 		if hc.which != KeypadType {
 			hc.which = JoystickType
 			return true
 		}
+	case GenesisType, BoosterGripType:
+		if hc.which != KeypadType {
+			hc.which = prospective
+
+			// the extra buttons are not pressed when the controller is
+			// plugged in
+			hc.writeExtraButton(hc.extra.pinFive, false)
+			hc.writeExtraButton(hc.extra.pinNine, false)
+			return true
+		}
 	case PaddleType:
 		if hc.which != KeypadType {
 			hc.which = PaddleType
 			return true
 		}
+	case LightGunType:
+		if hc.which != KeypadType {
+			hc.which = LightGunType
+
+			// the sensor is not detecting light when the gun is plugged in
+			hc.lightGun.lit = false
+			hc.mem.tia.InputDeviceWrite(hc.stick.buttonReg, stickButtonOff, 0x00)
+			return true
+		}
 	case KeypadType:
 		hc.which = KeypadType
 		return true
@@ -348,6 +422,84 @@ func (hc *HandController) Handle(event Event, value EventData) error {
 			hc.mem.tia.InputDeviceWrite(hc.stick.buttonReg, hc.stick.button, 0x00)
 		}
 
+	case GenesisButtonC:
+		b, ok := value.(bool)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "bool")
+		}
+
+		if !hc.SwitchType(GenesisType) {
+			return nil
+		}
+
+		hc.writeExtraButton(hc.extra.pinFive, b)
+
+	case BoosterGripTrigger:
+		b, ok := value.(bool)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "bool")
+		}
+
+		if !hc.SwitchType(BoosterGripType) {
+			return nil
+		}
+
+		hc.writeExtraButton(hc.extra.pinNine, b)
+
+	case BoosterGripBooster:
+		b, ok := value.(bool)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "bool")
+		}
+
+		if !hc.SwitchType(BoosterGripType) {
+			return nil
+		}
+
+		hc.writeExtraButton(hc.extra.pinFive, b)
+
+	case LightGunTrigger:
+		b, ok := value.(bool)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "bool")
+		}
+
+		if !hc.SwitchType(LightGunType) {
+			return nil
+		}
+
+		// the trigger is wired to the same pin as the joystick up direction
+		if b {
+			hc.stick.axis &^= 0x10
+		} else {
+			hc.stick.axis |= 0x10
+		}
+		hc.writeSWCHA(hc.stick.axis, hc.writeMask)
+
+	case LightGunX:
+		f, ok := value.(float32)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "float32")
+		}
+
+		if !hc.SwitchType(LightGunType) {
+			return nil
+		}
+
+		hc.lightGun.x = f
+
+	case LightGunY:
+		f, ok := value.(float32)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "float32")
+		}
+
+		if !hc.SwitchType(LightGunType) {
+			return nil
+		}
+
+		hc.lightGun.y = f
+
 	case PaddleFire:
 		b, ok := value.(bool)
 		if !ok {
@@ -387,21 +539,31 @@ func (hc *HandController) Handle(event Event, value EventData) error {
 
 		// keypad switched to only when DDR is switched
 
-		if v != '1' && v != '2' && v != '3' && v != '4' && v != '5' && v != '6' && v != '7' && v != '8' && v != '9' && v != '*' && v != '0' && v != '#' {
+		if !hc.keypad.setKey(v, true) {
 			return errors.New(errors.BadInputEventType, event, "numeric rune or '*' or '#'")
 		}
-
-		// note key for use by readKeypad()
-		hc.keypad.key = v
+		hc.writeKeypad()
 
 	case KeypadUp:
-		if value != nil {
-			return errors.New(errors.BadInputEventType, event, "nil")
+		// keypad switched to only when DDR is switched
+
+		// a nil value releases all keys. this is how KeypadUp events were
+		// handled before multiple keys were supported
+		if value == nil {
+			hc.keypad.keys = [keypadRows][keypadColumns]bool{}
+			hc.writeKeypad()
+			break // switch event
 		}
 
-		// keypad switched to only when DDR is switched
+		v, ok := value.(rune)
+		if !ok {
+			return errors.New(errors.BadInputEventType, event, "rune or nil")
+		}
 
-		hc.keypad.key = noKey
+		if !hc.keypad.setKey(v, false) {
+			return errors.New(errors.BadInputEventType, event, "numeric rune or '*' or '#'")
+		}
+		hc.writeKeypad()
 
 	case Unplug:
 		return errors.New(errors.InputDeviceUnplugged, hc.id)
@@ -433,108 +595,23 @@ func (hc *HandController) setDDR(data uint8) {
 		// switch to Joystick if DDR is anything other than 0xf0
 		hc.SwitchType(JoystickType)
 	}
-}
-
-// readKeypad() is called whenever SWCHA is tickled by the CPU. the state of
-// the ddr is of importance here.
-func (hc *HandController) readKeypad(data uint8) {
-	if hc.which != KeypadType {
-		return
-	}
-
-	data = hc.normaliseOnRead(data)
 
-	var column int
-
-	switch hc.keypad.key {
-	// row 0
-	case '1':
-		if data&0xe0 == 0xe0 && hc.ddr&0xe0 == 0xe0 {
-			column = 1
-		}
-	case '2':
-		if data&0xe0 == 0xe0 && hc.ddr&0xe0 == 0xe0 {
-			column = 2
-		}
-	case '3':
-		if data&0xe0 == 0xe0 && hc.ddr&0xe0 == 0xe0 {
-			column = 3
-		}
-
-		// row 2
-	case '4':
-		if data&0xd0 == 0xd0 && hc.ddr&0xd0 == 0xd0 {
-			column = 1
-		}
-	case '5':
-		if data&0xd0 == 0xd0 && hc.ddr&0xd0 == 0xd0 {
-			column = 2
-		}
-	case '6':
-		if data&0xd0 == 0xd0 && hc.ddr&0xd0 == 0xd0 {
-			column = 3
-		}
-
-		// row 3
-	case '7':
-		if data&0xb0 == 0xb0 && hc.ddr&0xb0 == 0xb0 {
-			column = 1
-		}
-	case '8':
-		if data&0xb0 == 0xb0 && hc.ddr&0xb0 == 0xb0 {
-			column = 2
-		}
-	case '9':
-		if data&0xb0 == 0xb0 && hc.ddr&0xb0 == 0xb0 {
-			column = 3
-		}
-
-		// row 4
-	case '*':
-		if data&0x70 == 0x70 && hc.ddr&0x70 == 0x70 {
-			column = 1
-		}
-	case '0':
-		if data&0x70 == 0x70 && hc.ddr&0x70 == 0x70 {
-			column = 2
-		}
-	case '#':
-		if data&0x70 == 0x70 && hc.ddr&0x70 == 0x70 {
-			column = 3
-		}
-	}
-
-	// The Stella Programmer's Guide says that: "a delay of 400 microseconds is
-	// necessary between writing to this port and reading the TIA input ports.".
-	// We're not emulating this here because as far as I can tell there is no need
-	// to. More over, I'm not sure what's supposed to happen if the 400ms is not
-	// adhered to.
-	//
-	// !!TODO: Consider adding 400ms delay for DDR settings to take effect.
-	switch column {
-	case 1:
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[0], 0x00, 0x00)
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[1], 0x80, 0x00)
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[2], 0x80, 0x00)
-	case 2:
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[0], 0x80, 0x00)
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[1], 0x00, 0x00)
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[2], 0x80, 0x00)
-	case 3:
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[0], 0x80, 0x00)
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[1], 0x80, 0x00)
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[2], 0x00, 0x00)
-	default:
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[0], 0x80, 0x00)
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[1], 0x80, 0x00)
-		hc.mem.tia.InputDeviceWrite(hc.keypad.column[2], 0x80, 0x00)
-	}
+	// changing the DDR changes which keypad rows are being driven
+	hc.driveKeypad()
 }
 
 // VBLANK bit 6 has been set. joystick button will latch, meaning that
 // releasing the fire button has no immediate effect
 func (hc *HandController) unlatch() {
-	if hc.which != JoystickType {
+	// the light gun sensor uses the same input as the joystick button
+	if hc.which == LightGunType {
+		if !hc.lightGun.lit {
+			hc.mem.tia.InputDeviceWrite(hc.stick.buttonReg, stickButtonOff, 0x00)
+		}
+		return
+	}
+
+	if !hc.which.isStick() {
 		return
 	}
 
@@ -598,3 +675,19 @@ func (hc *HandController) writeSWCHA(data uint8, mask uint8) {
 	data = hc.normaliseOnWrite(data & (hc.ddr ^ 0xff))
 	hc.mem.riot.InputDeviceWrite(addresses.SWCHA, data, mask)
 }
+
+// write the state of one of the extra buttons (Genesis or Booster Grip) to
+// the specified paddle input. the value written depends on the type of
+// controller that is currently plugged in
+func (hc *HandController) writeExtraButton(reg addresses.ChipRegister, pressed bool) {
+	on, off := genesisButtonOn, genesisButtonOff
+	if hc.which == BoosterGripType {
+		on, off = boosterGripButtonOn, boosterGripButtonOff
+	}
+
+	if pressed {
+		hc.mem.tia.InputDeviceWrite(reg, on, 0x00)
+	} else {
+		hc.mem.tia.InputDeviceWrite(reg, off, 0x00)
+	}
+}
@@ -0,0 +1,103 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+const inpt0 = 0x0008
+const inpt1 = 0x0009
+
+func newVCS(t *testing.T) *hardware.VCS {
+	t.Helper()
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	return vcs
+}
+
+// read the high bit of the specified TIA input register
+func readInput(t *testing.T, vcs *hardware.VCS, address uint16) int {
+	t.Helper()
+	v, err := vcs.Mem.Read(address)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return int(v & 0x80)
+}
+
+func TestGenesisButtonC(t *testing.T) {
+	vcs := newVCS(t)
+	hc := vcs.RIOT.Input.HandController0
+
+	// the C button is read through INPT1 and grounds the input when pressed
+	test.ExpectedSuccess(t, hc.Handle(input.GenesisButtonC, false))
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+
+	test.ExpectedSuccess(t, hc.Handle(input.GenesisButtonC, true))
+	test.Equate(t, readInput(t, vcs, inpt1), 0x00)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x80)
+
+	test.ExpectedSuccess(t, hc.Handle(input.GenesisButtonC, false))
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+}
+
+func TestBoosterGrip(t *testing.T) {
+	vcs := newVCS(t)
+	hc := vcs.RIOT.Input.HandController0
+
+	// the booster is read through INPT1 and the trigger through INPT0. unlike
+	// the Genesis gamepad the inputs read high when the buttons are pressed
+	test.ExpectedSuccess(t, hc.Handle(input.BoosterGripBooster, false))
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x00)
+
+	test.ExpectedSuccess(t, hc.Handle(input.BoosterGripBooster, true))
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+
+	test.ExpectedSuccess(t, hc.Handle(input.BoosterGripTrigger, true))
+	test.Equate(t, readInput(t, vcs, inpt0), 0x80)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+
+	test.ExpectedSuccess(t, hc.Handle(input.BoosterGripBooster, false))
+	test.ExpectedSuccess(t, hc.Handle(input.BoosterGripTrigger, false))
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x00)
+
+	// switching to the Genesis gamepad releases the inputs with the Genesis
+	// logic
+	test.ExpectedSuccess(t, hc.Handle(input.GenesisButtonC, false))
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+}
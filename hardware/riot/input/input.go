@@ -22,6 +22,7 @@ package input
 import (
 	"fmt"
 
+	"github.com/jetsetilly/gopher2600/errors"
 	"github.com/jetsetilly/gopher2600/hardware/memory/addresses"
 	"github.com/jetsetilly/gopher2600/hardware/memory/bus"
 )
@@ -154,4 +155,53 @@ func (inp *Input) Step() {
 	// step.
 	inp.HandController0.recharge()
 	inp.HandController1.recharge()
+
+	// changes to the keypad rows take a short while to settle
+	inp.HandController0.settleKeypad()
+	inp.HandController1.settleKeypad()
+}
+
+// inputState is the type returned by SaveState()
+type inputState struct {
+	vblankBits      VBlankBits
+	panel           Panel
+	handController0 HandController
+	handController1 HandController
+}
+
+// SaveState notes and returns the current state of the panel and the hand
+// controllers
+func (inp *Input) SaveState() interface{} {
+	return inputState{
+		vblankBits:      inp.VBlankBits,
+		panel:           *inp.Panel,
+		handController0: *inp.HandController0,
+		handController1: *inp.HandController1,
+	}
+}
+
+// RestoreState returns the panel and hand controllers to a state previously
+// returned by SaveState(). Attached playbacks and event recorders are not part
+// of the state and remain attached
+func (inp *Input) RestoreState(state interface{}) error {
+	s, ok := state.(inputState)
+	if !ok {
+		return errors.New(errors.StateError, "not an input state")
+	}
+
+	inp.VBlankBits = s.vblankBits
+
+	prt := inp.Panel.port
+	*inp.Panel = s.panel
+	inp.Panel.port = prt
+
+	prt = inp.HandController0.port
+	*inp.HandController0 = s.handController0
+	inp.HandController0.port = prt
+
+	prt = inp.HandController1.port
+	*inp.HandController1 = s.handController1
+	inp.HandController1.port = prt
+
+	return nil
 }
@@ -0,0 +1,202 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input
+
+import (
+	"fmt"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware/memory/addresses"
+)
+
+// KeypadLayout identifies the physical keypad controller plugged into the
+// port. All keypad controllers share the same key matrix so the layout makes
+// no difference to the emulation. It is useful however, for deciding how keys
+// on the host keyboard should map to the keys on the keypad.
+type KeypadLayout int
+
+// List of valid KeypadLayout values
+const (
+	// the Atari "Keyboard Controller"
+	KeypadStandard KeypadLayout = iota
+
+	// the Atari "Video Touch Pad" as supplied with Star Raiders
+	KeypadTouchPad
+
+	// the Atari "Kid's Controller" as used by the Sesame Street range of
+	// cartridges
+	KeypadKids
+)
+
+// KeypadLayoutList is a list of all possible string representations of the
+// KeypadLayout type
+var KeypadLayoutList = []string{"STANDARD", "TOUCHPAD", "KIDS"}
+
+func (l KeypadLayout) String() string {
+	switch l {
+	case KeypadStandard:
+		return "STANDARD"
+	case KeypadTouchPad:
+		return "TOUCHPAD"
+	case KeypadKids:
+		return "KIDS"
+	}
+	return fmt.Sprintf("unknown (%d)", l)
+}
+
+// NewKeypadLayout returns the KeypadLayout with the specified string
+// representation. The string should be one of those in KeypadLayoutList.
+func NewKeypadLayout(layout string) (KeypadLayout, error) {
+	for i := range KeypadLayoutList {
+		if KeypadLayoutList[i] == layout {
+			return KeypadLayout(i), nil
+		}
+	}
+	return KeypadStandard, errors.New(errors.UnknownKeypadLayout, layout)
+}
+
+// the Stella Programmer's Guide says that: "a delay of 400 microseconds is
+// necessary between writing to this port and reading the TIA input ports.". in
+// other words, the columns of the keypad do not reflect a change in the rows
+// being driven until this delay has elapsed.
+//
+// the value is in CPU cycles. 400 microseconds at 1.19MHz is 477 cycles or a
+// little over six scanlines.
+const keypadSettleDelay = 477
+
+// dimensions of the key matrix
+const (
+	keypadRows    = 4
+	keypadColumns = 3
+)
+
+// the keys in the keypad matrix, arranged by row and then by column
+var keypadMatrix = [keypadRows][keypadColumns]rune{
+	{'1', '2', '3'},
+	{'4', '5', '6'},
+	{'7', '8', '9'},
+	{'*', '0', '#'},
+}
+
+// the keypad type implements the keypad or "keyboard" controller
+type keypad struct {
+	column [3]addresses.ChipRegister
+
+	// the physical layout of the keypad
+	layout KeypadLayout
+
+	// the state of every key in the matrix. more than one key can be pressed
+	// at once
+	keys [keypadRows][keypadColumns]bool
+
+	// the most recent value written to SWCHA (normalised to the upper nibble)
+	swcha uint8
+
+	// the rows currently driven low (a bit for each row, in the upper nibble,
+	// the same as SWCHA). the rows value takes on the pending value once the
+	// settle count reaches zero
+	rows        uint8
+	pendingRows uint8
+	settle      int
+}
+
+// set the pressed state of the key. returns false if the key is not in the
+// keypad matrix
+func (kp *keypad) setKey(key rune, pressed bool) bool {
+	for r := range keypadMatrix {
+		for c := range keypadMatrix[r] {
+			if keypadMatrix[r][c] == key {
+				kp.keys[r][c] = pressed
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// KeypadLayout returns the layout of the keypad for this HandController
+func (hc *HandController) KeypadLayout() KeypadLayout {
+	return hc.keypad.layout
+}
+
+// SetKeypadLayout sets the layout of the keypad for this HandController
+func (hc *HandController) SetKeypadLayout(layout KeypadLayout) {
+	hc.keypad.layout = layout
+}
+
+// readKeypad() is called whenever SWCHA is tickled by the CPU
+func (hc *HandController) readKeypad(data uint8) {
+	hc.keypad.swcha = hc.normaliseOnRead(data)
+	hc.driveKeypad()
+}
+
+// driveKeypad() decides which keypad rows are being driven low by SWCHA. a
+// row is driven low if the corresponding bit is zero and the DDR for that bit
+// is set to output.
+//
+// the new value only takes effect after the keypadSettleDelay has elapsed.
+// the delay is only restarted if the prospective rows have changed, so
+// repeated writes of the same value to SWCHA will not delay the settling.
+func (hc *HandController) driveKeypad() {
+	if hc.which != KeypadType {
+		return
+	}
+
+	rows := ^hc.keypad.swcha & hc.ddr & 0xf0
+	if rows == hc.keypad.pendingRows {
+		return
+	}
+
+	hc.keypad.pendingRows = rows
+	hc.keypad.settle = keypadSettleDelay
+}
+
+// settleKeypad() is called every CPU cycle via Input.Step()
+func (hc *HandController) settleKeypad() {
+	if hc.keypad.settle == 0 {
+		return
+	}
+
+	hc.keypad.settle--
+	if hc.keypad.settle == 0 {
+		hc.keypad.rows = hc.keypad.pendingRows
+		hc.writeKeypad()
+	}
+}
+
+// writeKeypad() sets the column inputs according to the rows being driven
+// and the keys being pressed. a column reads low if any pressed key in that
+// column is in a row that is being driven.
+func (hc *HandController) writeKeypad() {
+	if hc.which != KeypadType {
+		return
+	}
+
+	for c := 0; c < keypadColumns; c++ {
+		v := uint8(0x80)
+		for r := 0; r < keypadRows; r++ {
+			if hc.keypad.rows&(0x10<<r) != 0 && hc.keypad.keys[r][c] {
+				v = 0x00
+				break // for r
+			}
+		}
+		hc.mem.tia.InputDeviceWrite(hc.keypad.column[c], v, 0x00)
+	}
+}
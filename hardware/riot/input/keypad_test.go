@@ -0,0 +1,166 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+const inpt4 = 0x000c
+const swcha = 0x0280
+const swacnt = 0x0281
+
+// the settle delay of the keypad in CPU cycles
+const settleDelay = 477
+
+// write to a RIOT register and step the RIOT once so that the write is
+// serviced
+func writeRIOT(t *testing.T, vcs *hardware.VCS, address uint16, data uint8) {
+	t.Helper()
+	test.ExpectedSuccess(t, vcs.Mem.Write(address, data))
+	vcs.RIOT.Step()
+}
+
+// step the RIOT for the specified number of CPU cycles
+func stepRIOT(vcs *hardware.VCS, cycles int) {
+	for i := 0; i < cycles; i++ {
+		vcs.RIOT.Step()
+	}
+}
+
+// plug a keypad into the left player port with none of the rows being driven
+func plugKeypad(t *testing.T, vcs *hardware.VCS) {
+	t.Helper()
+	writeRIOT(t, vcs, swcha, 0xf0)
+	writeRIOT(t, vcs, swacnt, 0xf0)
+	stepRIOT(vcs, settleDelay)
+}
+
+// drive the keypad rows (a bit for each row, in the upper nibble) and wait
+// for the columns to settle
+func driveRows(t *testing.T, vcs *hardware.VCS, rows uint8) {
+	t.Helper()
+	writeRIOT(t, vcs, swcha, ^rows&0xf0)
+	stepRIOT(vcs, settleDelay)
+}
+
+func TestKeypadSameColumn(t *testing.T) {
+	vcs := newVCS(t)
+	hc := vcs.RIOT.Input.HandController0
+	plugKeypad(t, vcs)
+
+	// '1' and '4' are both in the first column, in the first and second rows
+	test.ExpectedSuccess(t, hc.Handle(input.KeypadDown, '1'))
+	test.ExpectedSuccess(t, hc.Handle(input.KeypadDown, '4'))
+
+	// no rows are being driven so no columns are low
+	test.Equate(t, readInput(t, vcs, inpt0), 0x80)
+
+	driveRows(t, vcs, 0xf0)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+	test.Equate(t, readInput(t, vcs, inpt4), 0x80)
+
+	// releasing one of the keys leaves the column low because of the other
+	test.ExpectedSuccess(t, hc.Handle(input.KeypadUp, '1'))
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+	test.ExpectedSuccess(t, hc.Handle(input.KeypadDown, '1'))
+
+	// driving only the first row means only the '1' key is seen
+	driveRows(t, vcs, 0x10)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+	test.ExpectedSuccess(t, hc.Handle(input.KeypadUp, '1'))
+	test.Equate(t, readInput(t, vcs, inpt0), 0x80)
+
+	// the '4' key is still held and is seen when the second row is driven
+	driveRows(t, vcs, 0x20)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+
+	// a nil value releases all keys
+	test.ExpectedSuccess(t, hc.Handle(input.KeypadUp, nil))
+	test.Equate(t, readInput(t, vcs, inpt0), 0x80)
+}
+
+func TestKeypadSettle(t *testing.T) {
+	vcs := newVCS(t)
+	hc := vcs.RIOT.Input.HandController0
+	plugKeypad(t, vcs)
+
+	// '5' is in the second row and second column
+	test.ExpectedSuccess(t, hc.Handle(input.KeypadDown, '5'))
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+
+	// the column does not change until the row has settled. the write to
+	// SWCHA is serviced in the first cycle
+	writeRIOT(t, vcs, swcha, 0xd0)
+	stepRIOT(vcs, settleDelay-2)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+	stepRIOT(vcs, 1)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x00)
+
+	// writing the same value again does not restart the delay
+	writeRIOT(t, vcs, swcha, 0xd0)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x00)
+
+	// the same delay applies when the row stops being driven
+	writeRIOT(t, vcs, swcha, 0xf0)
+	stepRIOT(vcs, settleDelay-2)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x00)
+	stepRIOT(vcs, 1)
+	test.Equate(t, readInput(t, vcs, inpt1), 0x80)
+}
+
+func TestKeypadSettleDDR(t *testing.T) {
+	vcs := newVCS(t)
+	hc := vcs.RIOT.Input.HandController0
+	plugKeypad(t, vcs)
+
+	// '*' is in the fourth row and first column
+	test.ExpectedSuccess(t, hc.Handle(input.KeypadDown, '*'))
+	driveRows(t, vcs, 0xf0)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+
+	// setting the DDR bit for the fourth row to input means the row is no
+	// longer driven. as with a write to SWCHA, the column does not change
+	// until the delay has elapsed
+	writeRIOT(t, vcs, swacnt, 0x70)
+	stepRIOT(vcs, settleDelay-2)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+	stepRIOT(vcs, 1)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x80)
+
+	// and the same when it is set to output again
+	writeRIOT(t, vcs, swacnt, 0xf0)
+	stepRIOT(vcs, settleDelay-2)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x80)
+	stepRIOT(vcs, 1)
+	test.Equate(t, readInput(t, vcs, inpt0), 0x00)
+}
+
+func TestKeypadLayoutString(t *testing.T) {
+	for i, s := range input.KeypadLayoutList {
+		test.Equate(t, input.KeypadLayout(i).String(), s)
+	}
+	test.Equate(t, input.KeypadLayout(len(input.KeypadLayoutList)).String(), "unknown (3)")
+}
@@ -0,0 +1,71 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package input
+
+// the lightGun type implements the Atari XG-1 light gun, as used by Sentinel
+// and Shooting Arcade.
+//
+// the trigger of the XG-1 is wired to pin one of the controller port (the
+// joystick up direction) and the light sensor is wired to pin six (the
+// joystick fire button). the sensor therefore uses the INPT4/INPT5 latch in
+// the same way as the fire button.
+//
+// the gun is aimed with the x and y values. the values are fractions of the
+// visible screen, with 0.0 being the left/top edge and 1.0 being the
+// right/bottom edge. it is up to the VCS to decide when the electron beam is
+// at the aim point and to call SenseLight() accordingly.
+type lightGun struct {
+	x float32
+	y float32
+
+	// whether the sensor is currently detecting light
+	lit bool
+}
+
+// LightGunAim returns the current aim point of the light gun, as fractions of
+// the visible screen. The ok value is false if the controller is not a light
+// gun.
+func (hc *HandController) LightGunAim() (x float32, y float32, ok bool) {
+	if hc.which != LightGunType {
+		return 0, 0, false
+	}
+	return hc.lightGun.x, hc.lightGun.y, true
+}
+
+// SenseLight should be called for every video cycle that the controller is a
+// light gun. The lit argument indicates whether the light sensor can see a
+// bright pixel being drawn by the electron beam.
+//
+// Once the sensor has detected light, the INPT4/INPT5 register will remain
+// low until the latch is released if the fire button latch bit in VBLANK is
+// set.
+func (hc *HandController) SenseLight(lit bool) {
+	if hc.which != LightGunType || lit == hc.lightGun.lit {
+		return
+	}
+
+	hc.lightGun.lit = lit
+
+	if lit {
+		hc.mem.tia.InputDeviceWrite(hc.stick.buttonReg, stickButtonOn, 0x00)
+	} else if !hc.control.latchFireButton {
+		hc.mem.tia.InputDeviceWrite(hc.stick.buttonReg, stickButtonOff, 0x00)
+	}
+}
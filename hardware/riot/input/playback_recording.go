@@ -24,6 +24,14 @@ package input
 //
 // Intended for playback of controller events previously recorded to a file on
 // disk but usable for many purposes I suspect. For example, AI control.
+//
+// Note that the controller type is never recorded explicitly. The
+// HandController switches type on receipt of an Event specific to that type
+// (eg. GenesisButtonC or PaddleSet), exactly as it did when the Events were
+// first recorded. Recordings made before the Genesis and Booster Grip types
+// were added therefore play back unchanged. Events for the extra buttons of
+// those controllers take bool data, like the joystick Events, and so need no
+// special handling by Playback implementations.
 type Playback interface {
 	// note the type restrictions on EventData in the type definition's
 	// commentary
@@ -22,6 +22,7 @@ package riot
 import (
 	"strings"
 
+	"github.com/jetsetilly/gopher2600/errors"
 	"github.com/jetsetilly/gopher2600/hardware/memory/bus"
 	"github.com/jetsetilly/gopher2600/hardware/riot/input"
 	"github.com/jetsetilly/gopher2600/hardware/riot/timer"
@@ -77,3 +78,32 @@ func (riot *RIOT) Step() {
 	riot.Timer.Step()
 	riot.Input.Step()
 }
+
+// riotState is the type returned by SaveState()
+type riotState struct {
+	timer interface{}
+	input interface{}
+}
+
+// SaveState notes and returns the current state of the RIOT
+func (riot *RIOT) SaveState() interface{} {
+	return riotState{
+		timer: riot.Timer.SaveState(),
+		input: riot.Input.SaveState(),
+	}
+}
+
+// RestoreState returns the RIOT to a state previously returned by SaveState()
+func (riot *RIOT) RestoreState(state interface{}) error {
+	s, ok := state.(riotState)
+	if !ok {
+		return errors.New(errors.StateError, "not a riot state")
+	}
+
+	err := riot.Timer.RestoreState(s.timer)
+	if err != nil {
+		return err
+	}
+
+	return riot.Input.RestoreState(s.input)
+}
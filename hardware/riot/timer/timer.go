@@ -22,6 +22,7 @@ package timer
 import (
 	"fmt"
 
+	"github.com/jetsetilly/gopher2600/errors"
 	"github.com/jetsetilly/gopher2600/hardware/memory/addresses"
 	"github.com/jetsetilly/gopher2600/hardware/memory/bus"
 )
@@ -224,3 +225,19 @@ func (tmr *Timer) SetInterval(interval string) bool {
 
 	return false
 }
+
+// SaveState notes and returns the current state of the timer
+func (tmr *Timer) SaveState() interface{} {
+	return *tmr
+}
+
+// RestoreState returns the timer to a state previously returned by
+// SaveState()
+func (tmr *Timer) RestoreState(state interface{}) error {
+	s, ok := state.(Timer)
+	if !ok {
+		return errors.New(errors.StateError, "not a timer state")
+	}
+	*tmr = s
+	return nil
+}
@@ -45,16 +45,31 @@ func (vcs *VCS) Run(continueCheck func() (bool, error)) error {
 			return err
 		}
 
+		err = vcs.senseLight()
+		if err != nil {
+			return err
+		}
+
 		vcs.CPU.RdyFlg, err = vcs.TIA.Step(false)
 		if err != nil {
 			return err
 		}
 
+		err = vcs.senseLight()
+		if err != nil {
+			return err
+		}
+
 		vcs.CPU.RdyFlg, err = vcs.TIA.Step(true)
 		if err != nil {
 			return err
 		}
 
+		err = vcs.senseLight()
+		if err != nil {
+			return err
+		}
+
 		vcs.RIOT.Step()
 
 		return nil
@@ -0,0 +1,89 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package hardware
+
+import (
+	"github.com/jetsetilly/gopher2600/errors"
+)
+
+// State is a snapshot of the entire VCS, including the television, as
+// returned by SaveState(). The cartridge data itself is not part of the state,
+// only the cartridge's RAM and bank selection.
+type State struct {
+	cpu  interface{}
+	mem  interface{}
+	riot interface{}
+	tia  interface{}
+	tv   interface{}
+}
+
+// SaveState notes and returns the current state of the VCS. The state can only
+// be saved between CPU instructions.
+func (vcs *VCS) SaveState() (*State, error) {
+	var err error
+
+	s := &State{}
+
+	s.cpu, err = vcs.CPU.SaveState()
+	if err != nil {
+		return nil, err
+	}
+
+	s.tia = vcs.TIA.SaveState()
+	s.mem = vcs.Mem.SaveState()
+	s.riot = vcs.RIOT.SaveState()
+	s.tv = vcs.TV.SaveState()
+
+	return s, nil
+}
+
+// RestoreState returns the VCS to a state previously returned by SaveState().
+// The components of the VCS are altered in place so any references to them
+// remain valid.
+func (vcs *VCS) RestoreState(s *State) error {
+	if s == nil {
+		return errors.New(errors.StateError, "no state to restore")
+	}
+
+	// the CPU is restored first because it will refuse if it is in the middle
+	// of an instruction. we don't want to have altered anything else if that
+	// happens
+	err := vcs.CPU.RestoreState(s.cpu)
+	if err != nil {
+		return err
+	}
+
+	err = vcs.Mem.RestoreState(s.mem)
+	if err != nil {
+		return err
+	}
+
+	err = vcs.RIOT.RestoreState(s.riot)
+	if err != nil {
+		return err
+	}
+
+	err = vcs.TIA.RestoreState(s.tia)
+	if err != nil {
+		return err
+	}
+
+	return vcs.TV.RestoreState(s.tv)
+}
@@ -0,0 +1,112 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package hardware_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// a short program that increments a RAM location every scanline and pokes
+// the TIA with the result. the writes to RESP0 leave events pending in the TIA
+var stateProgram = []uint8{
+	0xe6, 0x80, // INC $80
+	0x85, 0x02, // STA WSYNC
+	0xa5, 0x80, // LDA $80
+	0x85, 0x09, // STA COLUBK
+	0x85, 0x10, // STA RESP0
+	0x4c, 0x00, 0xf0, // JMP $f000
+}
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_state")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	rom := make([]uint8, 4096)
+	copy(rom, stateProgram)
+	rom[0xffc] = 0x00
+	rom[0xffd] = 0xf0
+	filename := filepath.Join(dir, "state.bin")
+	err = ioutil.WriteFile(filename, rom, 0644)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	err = vcs.AttachCartridge(cartridgeloader.Loader{Filename: filename, Format: "AUTO"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// describe the machine after every instruction
+	run := func(n int) []string {
+		t.Helper()
+		s := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			test.ExpectedSuccess(t, vcs.Step(nil))
+			ram, _ := vcs.Mem.Read(0x80)
+			fr, _ := vcs.TV.GetState(television.ReqFramenum)
+			sl, _ := vcs.TV.GetState(television.ReqScanline)
+			hp, _ := vcs.TV.GetState(television.ReqHorizPos)
+			s = append(s, fmt.Sprintf("%s %02x %d %d %d %v %s %s", vcs.CPU, ram, fr, sl, hp,
+				vcs.TV.GetLastSignal(), vcs.TIA.Delay, vcs.TIA.Video.Player0.Delay))
+		}
+		return s
+	}
+
+	run(1000)
+
+	// the program is such that there will be pending TIA events
+	state, err := vcs.SaveState()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	a := run(5000)
+	test.ExpectedSuccess(t, vcs.RestoreState(state))
+	b := run(5000)
+
+	test.Equate(t, len(a), len(b))
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("instruction %d differs after restore: %s != %s", i, a[i], b[i])
+		}
+	}
+}
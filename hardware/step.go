@@ -74,6 +74,11 @@ func (vcs *VCS) Step(videoCycleCallback func() error) error {
 			return err
 		}
 
+		err = vcs.senseLight()
+		if err != nil {
+			return err
+		}
+
 		err = videoCycleCallback()
 		if err != nil {
 			return err
@@ -85,6 +90,11 @@ func (vcs *VCS) Step(videoCycleCallback func() error) error {
 			return err
 		}
 
+		err = vcs.senseLight()
+		if err != nil {
+			return err
+		}
+
 		err = videoCycleCallback()
 		if err != nil {
 			return err
@@ -95,6 +105,11 @@ func (vcs *VCS) Step(videoCycleCallback func() error) error {
 		if err != nil {
 			return err
 		}
+
+		err = vcs.senseLight()
+		if err != nil {
+			return err
+		}
 		err = videoCycleCallback()
 		if err != nil {
 			return err
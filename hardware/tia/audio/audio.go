@@ -22,6 +22,8 @@ package audio
 import (
 	"math/rand"
 	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
 )
 
 // SampleFreq represents the number of samples generated per second. This is
@@ -140,3 +142,19 @@ func (au *Audio) Mix() (bool, uint8) {
 	// !TODO: simulate analogue sound generation
 	return true, au.channel0.actualVol + au.channel1.actualVol
 }
+
+// SaveState notes and returns the current state of the audio sub-system
+func (au *Audio) SaveState() interface{} {
+	return *au
+}
+
+// RestoreState returns the audio sub-system to a state previously returned by
+// SaveState()
+func (au *Audio) RestoreState(state interface{}) error {
+	s, ok := state.(Audio)
+	if !ok {
+		return errors.New(errors.StateError, "not an audio state")
+	}
+	*au = s
+	return nil
+}
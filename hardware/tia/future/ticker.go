@@ -22,6 +22,8 @@ package future
 import (
 	"container/list"
 	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
 )
 
 // the number of elements in the list of events. through observation it has
@@ -116,3 +118,55 @@ func (tck *Ticker) drop(ev *Event) {
 	// wrong. it is okay to panic.
 	panic("cannot drop an event that is not in the list of active events")
 }
+
+// tickerState is the type returned by SaveState()
+type tickerState struct {
+	// the events in the order they appear in the pool and a copy of each
+	// event's fields
+	events []*Event
+	values []Event
+
+	// the position of the active sentinal in the pool
+	sentinal int
+}
+
+// SaveState notes and returns the current state of the ticker, including any
+// pending events.
+//
+// Payloads are saved by reference so the state should only be restored to the
+// same Ticker and only while the objects that scheduled the events are still
+// in use. In other words, objects with pending events must themselves be
+// restored in place.
+func (tck *Ticker) SaveState() interface{} {
+	s := tickerState{}
+	for e := tck.pool.Front(); e != nil; e = e.Next() {
+		if e == tck.activeSentinal {
+			s.sentinal = len(s.events)
+		}
+		ev := e.Value.(*Event)
+		s.events = append(s.events, ev)
+		s.values = append(s.values, *ev)
+	}
+	return s
+}
+
+// RestoreState returns the ticker to a state previously returned by
+// SaveState(). References to events that were pending when the state was
+// saved are valid once again.
+func (tck *Ticker) RestoreState(state interface{}) error {
+	s, ok := state.(tickerState)
+	if !ok {
+		return errors.New(errors.StateError, "not a ticker state")
+	}
+
+	tck.pool.Init()
+	for i, ev := range s.events {
+		*ev = s.values[i]
+		e := tck.pool.PushBack(ev)
+		if i == s.sentinal {
+			tck.activeSentinal = e
+		}
+	}
+
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jetsetilly/gopher2600/errors"
 	"github.com/jetsetilly/gopher2600/hardware/memory/bus"
 	"github.com/jetsetilly/gopher2600/hardware/riot/input"
 	"github.com/jetsetilly/gopher2600/hardware/tia/audio"
@@ -152,6 +153,50 @@ func NewTIA(tv television.Television, mem bus.ChipBus, vblankBits *input.VBlankB
 	return &tia, nil
 }
 
+// tiaState is the type returned by SaveState()
+type tiaState struct {
+	tia   TIA
+	hsync polycounter.Polycounter
+	delay interface{}
+	video interface{}
+	audio interface{}
+}
+
+// SaveState notes and returns the current state of the TIA, including the
+// video and audio sub-systems and any pending events
+func (tia *TIA) SaveState() interface{} {
+	return tiaState{
+		tia:   *tia,
+		hsync: *tia.hsync,
+		delay: tia.Delay.SaveState(),
+		video: tia.Video.SaveState(),
+		audio: tia.Audio.SaveState(),
+	}
+}
+
+// RestoreState returns the TIA to a state previously returned by SaveState()
+func (tia *TIA) RestoreState(state interface{}) error {
+	s, ok := state.(tiaState)
+	if !ok {
+		return errors.New(errors.StateError, "not a tia state")
+	}
+
+	err := tia.Video.RestoreState(s.video)
+	if err != nil {
+		return err
+	}
+
+	err = tia.Audio.RestoreState(s.audio)
+	if err != nil {
+		return err
+	}
+
+	*tia = s.tia
+	*tia.hsync = s.hsync
+
+	return tia.Delay.RestoreState(s.delay)
+}
+
 // UpdateTIA checks for side effects in the TIA sub-system.
 //
 // Returns true if ChipData has *not* been serviced.
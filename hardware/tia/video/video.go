@@ -20,6 +20,7 @@
 package video
 
 import (
+	"github.com/jetsetilly/gopher2600/errors"
 	"github.com/jetsetilly/gopher2600/hardware/memory/addresses"
 	"github.com/jetsetilly/gopher2600/hardware/memory/bus"
 	"github.com/jetsetilly/gopher2600/hardware/tia/future"
@@ -106,6 +107,96 @@ func NewVideo(mem bus.ChipBus,
 	return vd, nil
 }
 
+// videoState is the type returned by SaveState(). the polycounters and
+// tickers are referenced by the sprites and so are saved separately
+type videoState struct {
+	collisions collisions
+	playfield  playfield
+	player0    playerSprite
+	player1    playerSprite
+	missile0   missileSprite
+	missile1   missileSprite
+	ball       ballSprite
+
+	player0Position  polycounter.Polycounter
+	player1Position  polycounter.Polycounter
+	missile0Position polycounter.Polycounter
+	missile1Position polycounter.Polycounter
+	ballPosition     polycounter.Polycounter
+
+	player0Delay  interface{}
+	player1Delay  interface{}
+	missile0Delay interface{}
+	missile1Delay interface{}
+	ballDelay     interface{}
+}
+
+// SaveState notes and returns the current state of the video sub-system,
+// including any pending sprite events
+func (vd *Video) SaveState() interface{} {
+	return videoState{
+		collisions:       *vd.collisions,
+		playfield:        *vd.Playfield,
+		player0:          *vd.Player0,
+		player1:          *vd.Player1,
+		missile0:         *vd.Missile0,
+		missile1:         *vd.Missile1,
+		ball:             *vd.Ball,
+		player0Position:  *vd.Player0.position,
+		player1Position:  *vd.Player1.position,
+		missile0Position: *vd.Missile0.position,
+		missile1Position: *vd.Missile1.position,
+		ballPosition:     *vd.Ball.position,
+		player0Delay:     vd.Player0.Delay.SaveState(),
+		player1Delay:     vd.Player1.Delay.SaveState(),
+		missile0Delay:    vd.Missile0.Delay.SaveState(),
+		missile1Delay:    vd.Missile1.Delay.SaveState(),
+		ballDelay:        vd.Ball.Delay.SaveState(),
+	}
+}
+
+// RestoreState returns the video sub-system to a state previously returned by
+// SaveState(). Sprites are altered in place so references to them (and
+// between them) remain valid. This is important because pending events refer
+// to the sprites that scheduled them.
+func (vd *Video) RestoreState(state interface{}) error {
+	s, ok := state.(videoState)
+	if !ok {
+		return errors.New(errors.StateError, "not a video state")
+	}
+
+	*vd.collisions = s.collisions
+	*vd.Playfield = s.playfield
+	*vd.Player0 = s.player0
+	*vd.Player1 = s.player1
+	*vd.Missile0 = s.missile0
+	*vd.Missile1 = s.missile1
+	*vd.Ball = s.ball
+	*vd.Player0.position = s.player0Position
+	*vd.Player1.position = s.player1Position
+	*vd.Missile0.position = s.missile0Position
+	*vd.Missile1.position = s.missile1Position
+	*vd.Ball.position = s.ballPosition
+
+	for _, d := range []struct {
+		tck   *future.Ticker
+		state interface{}
+	}{
+		{tck: vd.Player0.Delay, state: s.player0Delay},
+		{tck: vd.Player1.Delay, state: s.player1Delay},
+		{tck: vd.Missile0.Delay, state: s.missile0Delay},
+		{tck: vd.Missile1.Delay, state: s.missile1Delay},
+		{tck: vd.Ball.Delay, state: s.ballDelay},
+	} {
+		err := d.tck.RestoreState(d.state)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // RSYNC adjusts the debugging information of the sprites when an RSYNC is
 // triggered
 func (vd *Video) RSYNC(adjustment int) {
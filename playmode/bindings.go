@@ -0,0 +1,462 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package playmode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/gui"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/paths"
+)
+
+// the location of the key bindings file
+const bindingsFile = "keyBindings"
+
+// BindingAction is the action performed by a key binding
+type BindingAction string
+
+// List of valid BindingActions.
+//
+// The controller and panel actions are performed by the Bindings type
+// directly. The emulator actions are passed to the ActionHandler function
+// given to the Keyboard.EventHandler() function. Whether an emulator action is
+// supported or not depends on the mode of the emulator.
+const (
+	// controller actions. the state of the key (up or down) is passed to the
+	// controller
+	BindP0Up         BindingAction = "P0 UP"
+	BindP0Down       BindingAction = "P0 DOWN"
+	BindP0Left       BindingAction = "P0 LEFT"
+	BindP0Right      BindingAction = "P0 RIGHT"
+	BindP0Fire       BindingAction = "P0 FIRE"
+	BindP0ButtonC    BindingAction = "P0 BUTTONC"
+	BindP0Trigger    BindingAction = "P0 TRIGGER"
+	BindP0Booster    BindingAction = "P0 BOOSTER"
+	BindP0PaddleFire BindingAction = "P0 PADDLEFIRE"
+	BindP1Up         BindingAction = "P1 UP"
+	BindP1Down       BindingAction = "P1 DOWN"
+	BindP1Left       BindingAction = "P1 LEFT"
+	BindP1Right      BindingAction = "P1 RIGHT"
+	BindP1Fire       BindingAction = "P1 FIRE"
+	BindP1ButtonC    BindingAction = "P1 BUTTONC"
+	BindP1Trigger    BindingAction = "P1 TRIGGER"
+	BindP1Booster    BindingAction = "P1 BOOSTER"
+	BindP1PaddleFire BindingAction = "P1 PADDLEFIRE"
+
+	// panel actions. select and reset are held for as long as the key is
+	// down. the other panel actions toggle the switch when the key is pressed
+	BindPanelSelect BindingAction = "PANEL SELECT"
+	BindPanelReset  BindingAction = "PANEL RESET"
+	BindPanelColor  BindingAction = "PANEL COLOR"
+	BindPanelP0Pro  BindingAction = "PANEL P0PRO"
+	BindPanelP1Pro  BindingAction = "PANEL P1PRO"
+
+	// emulator actions. these are performed only when the key is pressed
+	BindPause      BindingAction = "PAUSE"
+	BindReset      BindingAction = "RESET"
+	BindScreenshot BindingAction = "SCREENSHOT"
+	BindSave       BindingAction = "SAVE"
+	BindLoad       BindingAction = "LOAD"
+	BindCropping   BindingAction = "CROPPING"
+	BindAltColors  BindingAction = "ALTCOLORS"
+	BindOverlay    BindingAction = "OVERLAY"
+	BindScaleUp    BindingAction = "SCALEUP"
+	BindScaleDown  BindingAction = "SCALEDOWN"
+	BindCheats     BindingAction = "CHEATS"
+)
+
+// the input events for each controller action
+var controllerActions = map[BindingAction]struct {
+	player int
+	event  input.Event
+}{
+	BindP0Up:         {0, input.Up},
+	BindP0Down:       {0, input.Down},
+	BindP0Left:       {0, input.Left},
+	BindP0Right:      {0, input.Right},
+	BindP0Fire:       {0, input.Fire},
+	BindP0ButtonC:    {0, input.GenesisButtonC},
+	BindP0Trigger:    {0, input.BoosterGripTrigger},
+	BindP0Booster:    {0, input.BoosterGripBooster},
+	BindP0PaddleFire: {0, input.PaddleFire},
+	BindP1Up:         {1, input.Up},
+	BindP1Down:       {1, input.Down},
+	BindP1Left:       {1, input.Left},
+	BindP1Right:      {1, input.Right},
+	BindP1Fire:       {1, input.Fire},
+	BindP1ButtonC:    {1, input.GenesisButtonC},
+	BindP1Trigger:    {1, input.BoosterGripTrigger},
+	BindP1Booster:    {1, input.BoosterGripBooster},
+	BindP1PaddleFire: {1, input.PaddleFire},
+}
+
+// the list of emulator actions
+var emulatorActions = []BindingAction{
+	BindPause, BindReset, BindScreenshot, BindSave, BindLoad,
+	BindCropping, BindAltColors, BindOverlay, BindScaleUp, BindScaleDown,
+	BindCheats,
+}
+
+// isValid returns true if the action is one of the valid BindingActions
+func (act BindingAction) isValid() bool {
+	if _, ok := controllerActions[act]; ok {
+		return true
+	}
+
+	switch act {
+	case BindPanelSelect, BindPanelReset, BindPanelColor, BindPanelP0Pro, BindPanelP1Pro:
+		return true
+	}
+
+	for _, a := range emulatorActions {
+		if a == act {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ActionHandler implementations perform the emulator actions of a key
+// binding. Returns true if the action is supported, false otherwise.
+type ActionHandler func(act BindingAction) (bool, error)
+
+// Key identifies a key on the host keyboard and the modifier held with it
+type Key struct {
+	Key string
+	Mod gui.KeyMod
+}
+
+// the string representation of the modifier keys, as used in the bindings file
+var keyModNames = map[gui.KeyMod]string{
+	gui.KeyModShift: "SHIFT",
+	gui.KeyModCtrl:  "CTRL",
+	gui.KeyModAlt:   "ALT",
+}
+
+func (k Key) String() string {
+	if m, ok := keyModNames[k.Mod]; ok {
+		return fmt.Sprintf("%s+%s", m, k.Key)
+	}
+	return k.Key
+}
+
+// ParseKey converts a string of the form used in the bindings file to a Key.
+// The string is the key name, as reported by the GUI, with an optional
+// modifier prefix. For example:
+//
+//	Space
+//	CTRL+R
+//
+// Key names are not case sensitive.
+func ParseKey(s string) Key {
+	s = strings.ToUpper(s)
+	for mod, name := range keyModNames {
+		prefix := name + "+"
+		if len(s) > len(prefix) && strings.HasPrefix(s, prefix) {
+			return Key{Key: s[len(prefix):], Mod: mod}
+		}
+	}
+	return Key{Key: s, Mod: gui.KeyModNone}
+}
+
+// Bindings maps keys on the host keyboard to actions. The bindings are read
+// from a file in the resource path and are shared by the play mode and by the
+// debugger.
+type Bindings struct {
+	keys map[Key]BindingAction
+}
+
+// the default bindings, in the same format as the bindings file
+var defaultBindings = []string{
+	"F1 = PANEL SELECT",
+	"F2 = PANEL RESET",
+	"F3 = PANEL COLOR",
+	"F4 = PANEL P0PRO",
+	"F5 = PANEL P1PRO",
+	"LEFT = P0 LEFT",
+	"RIGHT = P0 RIGHT",
+	"UP = P0 UP",
+	"DOWN = P0 DOWN",
+	"SPACE = P0 FIRE",
+	"P = PAUSE",
+	"CTRL+R = RESET",
+	"F6 = SCREENSHOT",
+	"F7 = SAVE",
+	"F8 = LOAD",
+	"F9 = CHEATS",
+	"F10 = OVERLAY",
+	"F11 = ALTCOLORS",
+	"F12 = CROPPING",
+	"= = SCALEUP",
+	"+ = SCALEUP",
+	"- = SCALEDOWN",
+}
+
+// DefaultBindings returns the bindings used when no bindings file exists
+func DefaultBindings() *Bindings {
+	b := &Bindings{
+		keys: make(map[Key]BindingAction),
+	}
+
+	// the default bindings are known to be good so we can ignore the error
+	_ = b.read(strings.NewReader(strings.Join(defaultBindings, "\n")))
+
+	return b
+}
+
+// LoadBindings reads the bindings file from the resource path. If the file
+// does not exist then the default bindings are returned.
+func LoadBindings() (*Bindings, error) {
+	pth, err := paths.ResourcePath("", bindingsFile)
+	if err != nil {
+		return nil, errors.New(errors.BindingsError, err)
+	}
+
+	f, err := os.Open(pth)
+	if err != nil {
+		switch err.(type) {
+		case *os.PathError:
+			// path errors are okay. we'll just use the defaults and a new
+			// file will be created when the bindings are changed
+			return DefaultBindings(), nil
+		}
+		return nil, errors.New(errors.BindingsError, err)
+	}
+	defer f.Close()
+
+	b := &Bindings{
+		keys: make(map[Key]BindingAction),
+	}
+
+	err = b.read(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Save the bindings to the file in the resource path
+func (b *Bindings) Save() error {
+	pth, err := paths.ResourcePath("", bindingsFile)
+	if err != nil {
+		return errors.New(errors.BindingsError, err)
+	}
+
+	f, err := os.Create(pth)
+	if err != nil {
+		return errors.New(errors.BindingsError, err)
+	}
+	defer f.Close()
+
+	return b.write(f)
+}
+
+// the separator between the key and the action in the bindings file. key
+// names can contain spaces (eg. "Keypad 7") so we can't simply split the line
+// into fields
+const bindingSep = " = "
+
+// the bindings file is a simple line based format. blank lines and lines
+// beginning with # are ignored. every other line is of the form:
+//
+//	<key> = <action>
+func (b *Bindings) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+
+	ln := 0
+	for scanner.Scan() {
+		ln++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// the action never contains the separator but the key might (the
+		// equals key) so we split at the last instance of the separator
+		i := strings.LastIndex(line, bindingSep)
+		if i < 1 {
+			return errors.New(errors.BindingsError, fmt.Sprintf("expected <key>%s<action> [line %d]", bindingSep, ln))
+		}
+
+		err := b.Set(ParseKey(line[:i]), strings.TrimSpace(line[i+len(bindingSep):]))
+		if err != nil {
+			return errors.New(errors.BindingsError, fmt.Sprintf("%v [line %d]", err, ln))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return errors.New(errors.BindingsError, err)
+	}
+
+	return nil
+}
+
+func (b *Bindings) write(w io.Writer) error {
+	s := strings.Builder{}
+	s.WriteString("# key bindings\n")
+	for _, l := range b.List() {
+		s.WriteString(l)
+		s.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, s.String())
+	if err != nil {
+		return errors.New(errors.BindingsError, err)
+	}
+
+	return nil
+}
+
+// List returns the bindings as a sorted list of strings, in the same format
+// as the bindings file
+func (b *Bindings) List() []string {
+	l := make([]string, 0, len(b.keys))
+	for k, act := range b.keys {
+		l = append(l, fmt.Sprintf("%s%s%s", k, bindingSep, act))
+	}
+	sort.Strings(l)
+	return l
+}
+
+// Set binds the key to the action. The action string is normalised before
+// checking that it is a valid BindingAction.
+func (b *Bindings) Set(key Key, action string) error {
+	act := BindingAction(strings.ToUpper(strings.Join(strings.Fields(action), " ")))
+	if !act.isValid() {
+		return errors.New(errors.BindingsError, fmt.Sprintf("unrecognised action (%s)", action))
+	}
+	b.keys[key] = act
+	return nil
+}
+
+// Remove any binding for the key. Returns false if the key was not bound.
+func (b *Bindings) Remove(key Key) bool {
+	if _, ok := b.keys[key]; !ok {
+		return false
+	}
+	delete(b.keys, key)
+	return true
+}
+
+// Keyboard routes keyboard events from the GUI to the VCS, according to the
+// Bindings.
+//
+// The bindings are loaded with LoadBindings() when the first keyboard event
+// is received, unless they have already been set.
+type Keyboard struct {
+	Bindings *Bindings
+
+	// the action performed by keys that are currently held down. when the key
+	// is released the same action is released regardless of the modifier keys
+	// held at that time
+	held map[string]BindingAction
+}
+
+// NewKeyboard is the preferred method of initialisation for the Keyboard type
+func NewKeyboard() *Keyboard {
+	return &Keyboard{
+		held: make(map[string]BindingAction),
+	}
+}
+
+// EventHandler handles keypresses sent from a GUI. Controller and panel
+// actions are performed on the VCS directly. Emulator actions are passed to
+// the ActionHandler, which may be nil. Returns true if key has been handled,
+// false otherwise.
+//
+// Keys that have not been bound are checked against the keys for the keypad
+// layouts of each hand controller.
+//
+// For reasons of consistency, this handler is used by the debugger too.
+func (kb *Keyboard) EventHandler(ev gui.EventKeyboard, vcs *hardware.VCS, actions ActionHandler) (bool, error) {
+	if kb.Bindings == nil {
+		var err error
+		kb.Bindings, err = LoadBindings()
+		if err != nil {
+			return true, err
+		}
+	}
+
+	var act BindingAction
+	var ok bool
+	var down bool
+
+	if ev.Down {
+		act, ok = kb.Bindings.keys[Key{Key: strings.ToUpper(ev.Key), Mod: ev.Mod}]
+		if ok {
+			kb.held[ev.Key] = act
+			down = true
+		}
+	} else {
+		act, ok = kb.held[ev.Key]
+		if ok {
+			delete(kb.held, ev.Key)
+		}
+	}
+
+	if !ok {
+		return keypadEventHandler(ev, vcs)
+	}
+
+	if c, ok := controllerActions[act]; ok {
+		if c.player == 0 {
+			return true, vcs.HandController0.Handle(c.event, down)
+		}
+		return true, vcs.HandController1.Handle(c.event, down)
+	}
+
+	switch act {
+	case BindPanelSelect:
+		return true, vcs.Panel.Handle(input.PanelSelect, down)
+	case BindPanelReset:
+		return true, vcs.Panel.Handle(input.PanelReset, down)
+	}
+
+	// remaining actions are performed only when the key is pressed
+	if !down {
+		return true, nil
+	}
+
+	switch act {
+	case BindPanelColor:
+		return true, vcs.Panel.Handle(input.PanelToggleColor, nil)
+	case BindPanelP0Pro:
+		return true, vcs.Panel.Handle(input.PanelTogglePlayer0Pro, nil)
+	case BindPanelP1Pro:
+		return true, vcs.Panel.Handle(input.PanelTogglePlayer1Pro, nil)
+	}
+
+	if actions == nil {
+		return false, nil
+	}
+
+	return actions(act)
+}
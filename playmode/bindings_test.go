@@ -0,0 +1,110 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package playmode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/gui"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestBindingsFile(t *testing.T) {
+	def := DefaultBindings()
+
+	b := &strings.Builder{}
+	test.ExpectedSuccess(t, def.write(b))
+
+	m := &Bindings{keys: make(map[Key]BindingAction)}
+	test.ExpectedSuccess(t, m.read(strings.NewReader(b.String())))
+	test.Equate(t, strings.Join(m.List(), "\n"), strings.Join(def.List(), "\n"))
+
+	// the equals key is a valid key name
+	test.Equate(t, string(m.keys[Key{Key: "="}]), string(BindScaleUp))
+
+	// modifiers and case insensitivity
+	test.ExpectedSuccess(t, m.read(strings.NewReader("shift+keypad 7 = p1   fire\n")))
+	test.Equate(t, string(m.keys[Key{Key: "KEYPAD 7", Mod: gui.KeyModShift}]), string(BindP1Fire))
+
+	// unknown actions
+	test.ExpectedFailure(t, m.read(strings.NewReader("A = P2 FIRE\n")))
+
+	// missing separator
+	test.ExpectedFailure(t, m.read(strings.NewReader("A P0 FIRE\n")))
+}
+
+func TestKeyboardEvents(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	kb := NewKeyboard()
+	kb.Bindings = DefaultBindings()
+
+	swcha := func() uint8 {
+		t.Helper()
+		v, err := vcs.Mem.Read(0x0280)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return v
+	}
+
+	var performed []BindingAction
+	actions := func(act BindingAction) (bool, error) {
+		performed = append(performed, act)
+		return true, nil
+	}
+
+	// rebind the left direction
+	test.ExpectedSuccess(t, kb.Bindings.Set(ParseKey("CTRL+J"), "p0 left"))
+
+	_, err = kb.EventHandler(gui.EventKeyboard{Key: "j", Mod: gui.KeyModCtrl, Down: true}, vcs, actions)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xb0)
+
+	// the key is released even though the modifier has already been released
+	_, err = kb.EventHandler(gui.EventKeyboard{Key: "j", Down: false}, vcs, actions)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xf0)
+
+	// emulator actions are passed to the action handler only when the key
+	// is pressed
+	_, err = kb.EventHandler(gui.EventKeyboard{Key: "R", Mod: gui.KeyModCtrl, Down: true}, vcs, actions)
+	test.ExpectedSuccess(t, err)
+	_, err = kb.EventHandler(gui.EventKeyboard{Key: "R", Down: false}, vcs, actions)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, len(performed), 1)
+	test.Equate(t, string(performed[0]), string(BindReset))
+
+	// unbound keys are not handled
+	handled, err := kb.EventHandler(gui.EventKeyboard{Key: "K", Down: true}, vcs, actions)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, handled, false)
+}
@@ -20,14 +20,28 @@
 package playmode
 
 import (
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
 	"github.com/jetsetilly/gopher2600/gui"
 	"github.com/jetsetilly/gopher2600/hardware"
 	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/screenshot"
+	"github.com/jetsetilly/gopher2600/television"
 )
 
 // MouseMotionEventHandler handles mouse events sent from a GUI. Returns true if key
 // has been handled, false otherwise.
+//
+// The mouse controls the paddle unless a light gun is plugged into the left
+// player's port, in which case the mouse aims the light gun.
 func MouseMotionEventHandler(ev gui.EventMouseMotion, vcs *hardware.VCS) (bool, error) {
+	if _, _, ok := vcs.RIOT.Input.HandController0.LightGunAim(); ok {
+		err := vcs.HandController0.Handle(input.LightGunX, ev.X)
+		if err != nil {
+			return true, err
+		}
+		return true, vcs.HandController0.Handle(input.LightGunY, ev.Y)
+	}
+
 	return true, vcs.HandController0.Handle(input.PaddleSet, ev.X)
 }
 
@@ -39,6 +53,12 @@ func MouseButtonEventHandler(ev gui.EventMouseButton, vcs *hardware.VCS, scr gui
 
 	switch ev.Button {
 	case gui.MouseButtonLeft:
+		if _, _, ok := vcs.RIOT.Input.HandController0.LightGunAim(); ok {
+			err = vcs.HandController0.Handle(input.LightGunTrigger, ev.Down)
+			handled = true
+			break // switch ev.Button
+		}
+
 		if ev.Down {
 			err = vcs.HandController0.Handle(input.PaddleFire, true)
 		} else {
@@ -51,160 +71,76 @@ func MouseButtonEventHandler(ev gui.EventMouseButton, vcs *hardware.VCS, scr gui
 	return handled, err
 }
 
-// KeyboardEventHandler handles keypresses sent from a GUI. Returns true if
-// key has been handled, false otherwise.
+// keypadKeys maps keys on the host keyboard to keys on the emulated keypad
+// for each keypad layout. the first map in the array is for the left player and
+// the second map is for the right player.
 //
-// For reasons of consistency, this handler is used by the debugger too.
-func KeyboardEventHandler(ev gui.EventKeyboard, vcs *hardware.VCS) (bool, error) {
-	var handled bool
-	var err error
-
-	if ev.Down && ev.Mod == gui.KeyModNone {
-		switch ev.Key {
-		// panel
-		case "F1":
-			err = vcs.Panel.Handle(input.PanelSelect, true)
-			handled = true
-		case "F2":
-			err = vcs.Panel.Handle(input.PanelReset, true)
-			handled = true
-		case "F3":
-			err = vcs.Panel.Handle(input.PanelToggleColor, nil)
-			handled = true
-		case "F4":
-			err = vcs.Panel.Handle(input.PanelTogglePlayer0Pro, nil)
-			handled = true
-		case "F5":
-			err = vcs.Panel.Handle(input.PanelTogglePlayer1Pro, nil)
-			handled = true
-
-		// joystick
-		case "Left":
-			err = vcs.HandController0.Handle(input.Left, true)
-			handled = true
-		case "Right":
-			err = vcs.HandController0.Handle(input.Right, true)
-			handled = true
-		case "Up":
-			err = vcs.HandController0.Handle(input.Up, true)
-			handled = true
-		case "Down":
-			err = vcs.HandController0.Handle(input.Down, true)
-			handled = true
-		case "Space":
-			err = vcs.HandController0.Handle(input.Fire, true)
-			handled = true
-
-		// keypad (left player)
-		case "1", "2", "3":
-			err = vcs.HandController0.Handle(input.KeypadDown, rune(ev.Key[0]))
-			handled = true
-		case "Q":
-			err = vcs.HandController0.Handle(input.KeypadDown, '4')
-			handled = true
-		case "W":
-			err = vcs.HandController0.Handle(input.KeypadDown, '5')
-			handled = true
-		case "E":
-			err = vcs.HandController0.Handle(input.KeypadDown, '6')
-			handled = true
-		case "A":
-			err = vcs.HandController0.Handle(input.KeypadDown, '7')
-			handled = true
-		case "S":
-			err = vcs.HandController0.Handle(input.KeypadDown, '8')
-			handled = true
-		case "D":
-			err = vcs.HandController0.Handle(input.KeypadDown, '9')
-			handled = true
-		case "Z":
-			err = vcs.HandController0.Handle(input.KeypadDown, '*')
-			handled = true
-		case "X":
-			err = vcs.HandController0.Handle(input.KeypadDown, '0')
-			handled = true
-		case "C":
-			err = vcs.HandController0.Handle(input.KeypadDown, '#')
-			handled = true
-
-		// keypad (right player)
-		case "4":
-			err = vcs.HandController1.Handle(input.KeypadDown, '1')
-			handled = true
-		case "5":
-			err = vcs.HandController1.Handle(input.KeypadDown, '2')
-			handled = true
-		case "6":
-			err = vcs.HandController1.Handle(input.KeypadDown, '3')
-			handled = true
-		case "R":
-			err = vcs.HandController1.Handle(input.KeypadDown, '4')
-			handled = true
-		case "T":
-			err = vcs.HandController1.Handle(input.KeypadDown, '5')
-			handled = true
-		case "Y":
-			err = vcs.HandController1.Handle(input.KeypadDown, '6')
-			handled = true
-		case "F":
-			err = vcs.HandController1.Handle(input.KeypadDown, '7')
-			handled = true
-		case "G":
-			err = vcs.HandController1.Handle(input.KeypadDown, '8')
-			handled = true
-		case "H":
-			err = vcs.HandController1.Handle(input.KeypadDown, '9')
-			handled = true
-		case "V":
-			err = vcs.HandController1.Handle(input.KeypadDown, '*')
-			handled = true
-		case "B":
-			err = vcs.HandController1.Handle(input.KeypadDown, '0')
-			handled = true
-		case "N":
-			err = vcs.HandController1.Handle(input.KeypadDown, '#')
-			handled = true
-		}
-	} else {
-		switch ev.Key {
-		// panel
-		case "F1":
-			err = vcs.Panel.Handle(input.PanelSelect, false)
-			handled = true
-		case "F2":
-			err = vcs.Panel.Handle(input.PanelReset, false)
-			handled = true
-
-		// josytick
-		case "Left":
-			err = vcs.HandController0.Handle(input.Left, false)
-			handled = true
-		case "Right":
-			err = vcs.HandController0.Handle(input.Right, false)
-			handled = true
-		case "Up":
-			err = vcs.HandController0.Handle(input.Up, false)
-			handled = true
-		case "Down":
-			err = vcs.HandController0.Handle(input.Down, false)
-			handled = true
-		case "Space":
-			err = vcs.HandController0.Handle(input.Fire, false)
-			handled = true
-
-		// keypad (left player)
-		case "1", "2", "3", "Q", "W", "E", "A", "S", "D", "Z", "X", "C":
-			err = vcs.HandController0.Handle(input.KeypadUp, nil)
-			handled = true
+// some combinations of layouts for the left and right player share host keys.
+// in those instances the left player takes precedence.
+var keypadKeys = map[input.KeypadLayout][2]map[string]rune{
+	input.KeypadStandard: {
+		{
+			"1": '1', "2": '2', "3": '3',
+			"Q": '4', "W": '5', "E": '6',
+			"A": '7', "S": '8', "D": '9',
+			"Z": '*', "X": '0', "C": '#',
+		},
+		{
+			"4": '1', "5": '2', "6": '3',
+			"R": '4', "T": '5', "Y": '6',
+			"F": '7', "G": '8', "H": '9',
+			"V": '*', "B": '0', "N": '#',
+		},
+	},
+
+	// the touch pad is arranged like a telephone so the rows of the host's
+	// numeric keypad are reversed
+	input.KeypadTouchPad: {
+		{
+			"Keypad 7": '1', "Keypad 8": '2', "Keypad 9": '3',
+			"Keypad 4": '4', "Keypad 5": '5', "Keypad 6": '6',
+			"Keypad 1": '7', "Keypad 2": '8', "Keypad 3": '9',
+			"Keypad .": '*', "Keypad 0": '0', "Keypad Enter": '#',
+		},
+		{
+			"4": '1', "5": '2', "6": '3',
+			"R": '4', "T": '5', "Y": '6',
+			"F": '7', "G": '8', "H": '9',
+			"V": '*', "B": '0', "N": '#',
+		},
+	},
+
+	// the numbered keys of the kid's controller map to the same numbers on
+	// the host keyboard
+	input.KeypadKids: {
+		{
+			"1": '1', "2": '2', "3": '3',
+			"4": '4', "5": '5', "6": '6',
+			"7": '7', "8": '8', "9": '9',
+			"[": '*', "0": '0', "]": '#',
+		},
+		{
+			"Keypad 7": '1', "Keypad 8": '2', "Keypad 9": '3',
+			"Keypad 4": '4', "Keypad 5": '5', "Keypad 6": '6',
+			"Keypad 1": '7', "Keypad 2": '8', "Keypad 3": '9',
+			"Keypad .": '*', "Keypad 0": '0', "Keypad Enter": '#',
+		},
+	},
+}
 
-		// keypad (right player)
-		case "4", "5", "6", "R", "T", "Y", "F", "G", "H", "V", "B", "N":
-			err = vcs.HandController1.Handle(input.KeypadUp, nil)
-			handled = true
+// keypadEventHandler handles keypresses for the keypad controllers. Returns
+// true if key has been handled, false otherwise.
+func keypadEventHandler(ev gui.EventKeyboard, vcs *hardware.VCS) (bool, error) {
+	for i, hc := range []*input.HandController{vcs.RIOT.Input.HandController0, vcs.RIOT.Input.HandController1} {
+		if key, ok := keypadKeys[hc.KeypadLayout()][i][ev.Key]; ok {
+			if ev.Down && ev.Mod == gui.KeyModNone {
+				return true, hc.Handle(input.KeypadDown, key)
+			}
+			return true, hc.Handle(input.KeypadUp, key)
 		}
 	}
 
-	return handled, err
+	return false, nil
 }
 
 func (pl *playmode) guiEventHandler(ev gui.Event) (bool, error) {
@@ -212,7 +148,7 @@ func (pl *playmode) guiEventHandler(ev gui.Event) (bool, error) {
 	case gui.EventQuit:
 		return false, nil
 	case gui.EventKeyboard:
-		_, err := KeyboardEventHandler(ev, pl.vcs)
+		_, err := pl.keyboard.EventHandler(ev, pl.vcs, pl.actionHandler)
 		return err == nil, err
 	case gui.EventMouseButton:
 		_, err := MouseButtonEventHandler(ev, pl.vcs, pl.scr)
@@ -220,19 +156,95 @@ func (pl *playmode) guiEventHandler(ev gui.Event) (bool, error) {
 	case gui.EventMouseMotion:
 		_, err := MouseMotionEventHandler(ev, pl.vcs)
 		return err == nil, err
+	case gui.EventGamepadConnected, gui.EventGamepadDisconnected, gui.EventGamepadButton, gui.EventGamepadAxis:
+		_, err := pl.gamepads.EventHandler(ev, pl.vcs)
+		return err == nil, err
 	}
 
 	return true, nil
 }
 
+// actionHandler performs the emulator actions of the key bindings that are
+// meaningful in play mode
+func (pl *playmode) actionHandler(act BindingAction) (bool, error) {
+	switch act {
+	case BindPause:
+		pl.paused = !pl.paused
+		return true, nil
+	case BindReset:
+		if pl.transcript {
+			return true, nil
+		}
+		return true, pl.vcs.Reset()
+	case BindScreenshot:
+		return true, pl.saveScreenshot()
+	case BindSave:
+		state, err := pl.vcs.SaveState()
+		if err != nil {
+			return true, err
+		}
+		pl.savedState = state
+		return true, nil
+	case BindLoad:
+		// like resetting, restoring a state would cause a recording or
+		// playback to go out of sync
+		if pl.transcript || pl.savedState == nil {
+			return true, nil
+		}
+		return true, pl.vcs.RestoreState(pl.savedState)
+	case BindCheats:
+		if pl.cheats == nil {
+			return true, nil
+		}
+		return true, pl.cheats.SetEnabled(!pl.cheats.IsEnabled())
+	}
+
+	return false, nil
+}
+
+// saveScreenshot saves the current tv frame using a unique filename
+func (pl *playmode) saveScreenshot() error {
+	name := cartridgeloader.Loader{Filename: pl.vcs.Mem.Cart.Filename}.ShortName()
+	return pl.screenshot.Save(screenshot.UniqueFilename(name), screenshot.Options{})
+}
+
 func (pl *playmode) eventHandler() (bool, error) {
+	// the requested frame has been completed once the television has moved
+	// onto the next frame
+	if pl.screenshotAtFrame > 0 {
+		fn, err := pl.vcs.TV.GetState(television.ReqFramenum)
+		if err != nil {
+			return false, err
+		}
+		if fn > pl.screenshotAtFrame {
+			return false, pl.saveScreenshot()
+		}
+	}
+
 	select {
 	case <-pl.intChan:
 		return false, nil
 	case ev := <-pl.guiChan:
-		return pl.guiEventHandler(ev)
+		cont, err := pl.guiEventHandler(ev)
+		if !cont || err != nil {
+			return cont, err
+		}
 	default:
 	}
 
+	// while the emulation is paused we wait for events rather than returning
+	// to the emulation loop
+	for pl.paused {
+		select {
+		case <-pl.intChan:
+			return false, nil
+		case ev := <-pl.guiChan:
+			cont, err := pl.guiEventHandler(ev)
+			if !cont || err != nil {
+				return cont, err
+			}
+		}
+	}
+
 	return true, nil
 }
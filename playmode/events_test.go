@@ -0,0 +1,179 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package playmode
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/gui"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestSaveLoadActions(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	pl := &playmode{vcs: vcs}
+
+	ram := func() uint8 {
+		t.Helper()
+		v, err := vcs.Mem.Read(0x0080)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return v
+	}
+
+	// loading before anything has been saved does nothing
+	test.ExpectedSuccess(t, vcs.Mem.Write(0x0080, 0x11))
+	handled, err := pl.actionHandler(BindLoad)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, handled, true)
+	test.Equate(t, int(ram()), 0x11)
+
+	handled, err = pl.actionHandler(BindSave)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, handled, true)
+
+	test.ExpectedSuccess(t, vcs.Mem.Write(0x0080, 0x22))
+	_, err = pl.actionHandler(BindLoad)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(ram()), 0x11)
+
+	// the saved state is not restored during a recording or playback
+	pl.transcript = true
+	test.ExpectedSuccess(t, vcs.Mem.Write(0x0080, 0x22))
+	_, err = pl.actionHandler(BindLoad)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(ram()), 0x22)
+}
+
+func TestKeypadLayouts(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the keys of the keypad in the order of the key matrix
+	const matrix = "123456789*0#"
+
+	// the host keys expected for each layout and player, in matrix order
+	expected := map[input.KeypadLayout][2][]string{
+		input.KeypadStandard: {
+			{"1", "2", "3", "Q", "W", "E", "A", "S", "D", "Z", "X", "C"},
+			{"4", "5", "6", "R", "T", "Y", "F", "G", "H", "V", "B", "N"},
+		},
+		input.KeypadTouchPad: {
+			{"Keypad 7", "Keypad 8", "Keypad 9", "Keypad 4", "Keypad 5", "Keypad 6",
+				"Keypad 1", "Keypad 2", "Keypad 3", "Keypad .", "Keypad 0", "Keypad Enter"},
+			{"4", "5", "6", "R", "T", "Y", "F", "G", "H", "V", "B", "N"},
+		},
+		input.KeypadKids: {
+			{"1", "2", "3", "4", "5", "6", "7", "8", "9", "[", "0", "]"},
+			{"Keypad 7", "Keypad 8", "Keypad 9", "Keypad 4", "Keypad 5", "Keypad 6",
+				"Keypad 1", "Keypad 2", "Keypad 3", "Keypad .", "Keypad 0", "Keypad Enter"},
+		},
+	}
+	test.Equate(t, len(keypadKeys), len(expected))
+
+	// the TIA inputs for the columns of each player's keypad
+	columns := [2][3]uint16{{0x08, 0x09, 0x0c}, {0x0a, 0x0b, 0x0d}}
+
+	// drive a single row of the player's keypad and wait for the columns to
+	// settle. the settle delay is a little under 480 cycles
+	driveRow := func(player int, row int) {
+		t.Helper()
+		v := uint8(0xff)
+		if player == 0 {
+			v ^= 0x10 << row
+		} else {
+			v ^= 0x01 << row
+		}
+		test.ExpectedSuccess(t, vcs.Mem.Write(0x0280, v))
+		for i := 0; i < 480; i++ {
+			vcs.RIOT.Step()
+		}
+	}
+
+	column := func(player int, col int) int {
+		t.Helper()
+		v, err := vcs.Mem.Read(columns[player][col])
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return int(v & 0x80)
+	}
+
+	// setting the DDR to output switches both controllers to keypads
+	test.ExpectedSuccess(t, vcs.Mem.Write(0x0281, 0xff))
+	vcs.RIOT.Step()
+
+	hcs := []*input.HandController{vcs.RIOT.Input.HandController0, vcs.RIOT.Input.HandController1}
+
+	for layout, players := range expected {
+		for _, hc := range hcs {
+			hc.SetKeypadLayout(layout)
+		}
+
+		for player, keys := range players {
+			test.Equate(t, len(keypadKeys[layout][player]), len(matrix))
+
+			for i, host := range keys {
+				row := i / 3
+				col := i % 3
+
+				if keypadKeys[layout][player][host] != rune(matrix[i]) {
+					t.Errorf("%s: player %d: %s is not mapped to %c", layout, player, host, matrix[i])
+					continue // for i
+				}
+
+				handled, err := keypadEventHandler(gui.EventKeyboard{Key: host, Down: true}, vcs)
+				test.ExpectedSuccess(t, err)
+				test.Equate(t, handled, true)
+
+				driveRow(player, row)
+				if column(player, col) != 0x00 {
+					t.Errorf("%s: player %d: %s did not press %c", layout, player, host, matrix[i])
+				}
+
+				_, err = keypadEventHandler(gui.EventKeyboard{Key: host, Down: false}, vcs)
+				test.ExpectedSuccess(t, err)
+				if column(player, col) != 0x80 {
+					t.Errorf("%s: player %d: %s did not release %c", layout, player, host, matrix[i])
+				}
+			}
+		}
+	}
+}
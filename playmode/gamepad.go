@@ -0,0 +1,509 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package playmode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/gui"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
+	"github.com/jetsetilly/gopher2600/paths"
+)
+
+// the location of the gamepad mapping file
+const gamepadMappingFile = "gamepadMapping"
+
+// GamepadAction is the action performed by a gamepad button or axis
+type GamepadAction string
+
+// List of valid GamepadActions. Button actions can be assigned to both
+// buttons and axes. Axis actions can only be assigned to axes.
+const (
+	GamepadNoAction GamepadAction = "NONE"
+
+	// button actions
+	GamepadUp         GamepadAction = "UP"
+	GamepadDown       GamepadAction = "DOWN"
+	GamepadLeft       GamepadAction = "LEFT"
+	GamepadRight      GamepadAction = "RIGHT"
+	GamepadFire       GamepadAction = "FIRE"
+	GamepadButtonC    GamepadAction = "BUTTONC"
+	GamepadTrigger    GamepadAction = "TRIGGER"
+	GamepadBooster    GamepadAction = "BOOSTER"
+	GamepadPaddleFire GamepadAction = "PADDLEFIRE"
+	GamepadSelect     GamepadAction = "SELECT"
+	GamepadReset      GamepadAction = "RESET"
+
+	// axis actions
+	GamepadStickHoriz GamepadAction = "STICKHORIZ"
+	GamepadStickVert  GamepadAction = "STICKVERT"
+	GamepadPaddle     GamepadAction = "PADDLE"
+)
+
+func (act GamepadAction) isButtonAction() bool {
+	switch act {
+	case GamepadUp, GamepadDown, GamepadLeft, GamepadRight, GamepadFire,
+		GamepadButtonC, GamepadTrigger, GamepadBooster, GamepadPaddleFire,
+		GamepadSelect, GamepadReset:
+		return true
+	}
+	return false
+}
+
+func (act GamepadAction) isAxisAction() bool {
+	switch act {
+	case GamepadStickHoriz, GamepadStickVert, GamepadPaddle:
+		return true
+	}
+	return false
+}
+
+// GamepadMapping specifies what action each button and axis of a gamepad
+// performs. The same mapping is used for all gamepads.
+type GamepadMapping struct {
+	Buttons map[gui.GamepadButton]GamepadAction
+	Axes    map[gui.GamepadAxis]GamepadAction
+
+	// axis movement smaller than the deadzone is ignored when the axis is
+	// assigned to a stick action or to a button action
+	Deadzone float32
+}
+
+// DefaultGamepadMapping returns the mapping used when no mapping file exists.
+//
+// The B, X and Y buttons are not assigned to an action by default. The
+// BUTTONC, TRIGGER and BOOSTER actions switch the hand controller to the
+// Genesis gamepad or Booster Grip type, which would be surprising to the user
+// playing a joystick game. The user can opt in by editing the mapping file.
+func DefaultGamepadMapping() *GamepadMapping {
+	return &GamepadMapping{
+		Buttons: map[gui.GamepadButton]GamepadAction{
+			gui.GamepadButtonA:             GamepadFire,
+			gui.GamepadButtonB:             GamepadNoAction,
+			gui.GamepadButtonX:             GamepadNoAction,
+			gui.GamepadButtonY:             GamepadNoAction,
+			gui.GamepadButtonBack:          GamepadSelect,
+			gui.GamepadButtonStart:         GamepadReset,
+			gui.GamepadButtonLeftShoulder:  GamepadPaddleFire,
+			gui.GamepadButtonRightShoulder: GamepadPaddleFire,
+			gui.GamepadButtonDPadUp:        GamepadUp,
+			gui.GamepadButtonDPadDown:      GamepadDown,
+			gui.GamepadButtonDPadLeft:      GamepadLeft,
+			gui.GamepadButtonDPadRight:     GamepadRight,
+		},
+		Axes: map[gui.GamepadAxis]GamepadAction{
+			gui.GamepadAxisLeftX:        GamepadStickHoriz,
+			gui.GamepadAxisLeftY:        GamepadStickVert,
+			gui.GamepadAxisRightX:       GamepadPaddle,
+			gui.GamepadAxisTriggerRight: GamepadFire,
+		},
+		Deadzone: 0.25,
+	}
+}
+
+// LoadGamepadMapping reads the gamepad mapping file from the resource path. If
+// the file does not exist then the default mapping is written to the file and
+// returned.
+func LoadGamepadMapping() (*GamepadMapping, error) {
+	pth, err := paths.ResourcePath("", gamepadMappingFile)
+	if err != nil {
+		return nil, errors.New(errors.GamepadMappingError, err)
+	}
+
+	f, err := os.Open(pth)
+	if err != nil {
+		switch err.(type) {
+		case *os.PathError:
+			// path errors are okay. use the default mapping and create a new
+			// file that the user can edit
+			m := DefaultGamepadMapping()
+			return m, m.Save()
+		}
+		return nil, errors.New(errors.GamepadMappingError, err)
+	}
+	defer f.Close()
+
+	m := &GamepadMapping{
+		Buttons: make(map[gui.GamepadButton]GamepadAction),
+		Axes:    make(map[gui.GamepadAxis]GamepadAction),
+	}
+
+	err = m.read(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Save the gamepad mapping to the file in the resource path
+func (m *GamepadMapping) Save() error {
+	pth, err := paths.ResourcePath("", gamepadMappingFile)
+	if err != nil {
+		return errors.New(errors.GamepadMappingError, err)
+	}
+
+	f, err := os.Create(pth)
+	if err != nil {
+		return errors.New(errors.GamepadMappingError, err)
+	}
+	defer f.Close()
+
+	return m.write(f)
+}
+
+// the gamepad mapping file is a simple line based format. blank lines and
+// lines beginning with # are ignored. every other line is one of:
+//
+//	deadzone <amount>
+//	button <button> <action>
+//	axis <axis> <action>
+func (m *GamepadMapping) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+
+	ln := 0
+	for scanner.Scan() {
+		ln++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		flds := strings.Fields(line)
+
+		switch strings.ToLower(flds[0]) {
+		case "deadzone":
+			if len(flds) != 2 {
+				return errors.New(errors.GamepadMappingError, fmt.Sprintf("wrong number of fields [line %d]", ln))
+			}
+			f, err := strconv.ParseFloat(flds[1], 32)
+			if err != nil || f < 0.0 || f >= 1.0 {
+				return errors.New(errors.GamepadMappingError, fmt.Sprintf("deadzone must be between 0.0 and 1.0 [line %d]", ln))
+			}
+			m.Deadzone = float32(f)
+
+		case "button":
+			if len(flds) != 3 {
+				return errors.New(errors.GamepadMappingError, fmt.Sprintf("wrong number of fields [line %d]", ln))
+			}
+			act := GamepadAction(strings.ToUpper(flds[2]))
+			if !act.isButtonAction() && act != GamepadNoAction {
+				return errors.New(errors.GamepadMappingError, fmt.Sprintf("%s is not a button action [line %d]", act, ln))
+			}
+			m.Buttons[gui.GamepadButton(strings.ToUpper(flds[1]))] = act
+
+		case "axis":
+			if len(flds) != 3 {
+				return errors.New(errors.GamepadMappingError, fmt.Sprintf("wrong number of fields [line %d]", ln))
+			}
+			act := GamepadAction(strings.ToUpper(flds[2]))
+			if !act.isButtonAction() && !act.isAxisAction() && act != GamepadNoAction {
+				return errors.New(errors.GamepadMappingError, fmt.Sprintf("%s is not an axis action [line %d]", act, ln))
+			}
+			m.Axes[gui.GamepadAxis(strings.ToUpper(flds[1]))] = act
+
+		default:
+			return errors.New(errors.GamepadMappingError, fmt.Sprintf("unrecognised entry (%s) [line %d]", flds[0], ln))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return errors.New(errors.GamepadMappingError, err)
+	}
+
+	return nil
+}
+
+func (m *GamepadMapping) write(w io.Writer) error {
+	s := strings.Builder{}
+
+	s.WriteString("# gamepad mapping\n")
+	s.WriteString(fmt.Sprintf("deadzone %.2f\n", m.Deadzone))
+
+	buttons := make([]string, 0, len(m.Buttons))
+	for b := range m.Buttons {
+		buttons = append(buttons, string(b))
+	}
+	sort.Strings(buttons)
+	for _, b := range buttons {
+		s.WriteString(fmt.Sprintf("button %s %s\n", b, m.Buttons[gui.GamepadButton(b)]))
+	}
+
+	axes := make([]string, 0, len(m.Axes))
+	for a := range m.Axes {
+		axes = append(axes, string(a))
+	}
+	sort.Strings(axes)
+	for _, a := range axes {
+		s.WriteString(fmt.Sprintf("axis %s %s\n", a, m.Axes[gui.GamepadAxis(a)]))
+	}
+
+	_, err := io.WriteString(w, s.String())
+	if err != nil {
+		return errors.New(errors.GamepadMappingError, err)
+	}
+
+	return nil
+}
+
+// the number of players that can be controlled by a gamepad
+const numGamepadPlayers = 2
+
+// Gamepads routes gamepad events from the GUI to the VCS, according to the
+// GamepadMapping. The first gamepad to be connected controls the left player
+// and the second gamepad controls the right player.
+//
+// The mapping is loaded with LoadGamepadMapping() when the first gamepad
+// event is received, unless it has already been set.
+type Gamepads struct {
+	Mapping *GamepadMapping
+
+	players [numGamepadPlayers]gamepadPlayer
+}
+
+// the state of the gamepad controlling a player
+type gamepadPlayer struct {
+	connected bool
+	id        gui.GamepadID
+
+	// the sources (buttons and axes) currently pressing each action. more than
+	// one source can press the same action, for example the dpad and the
+	// stick, so an action is only released when there are no more sources
+	pressed map[GamepadAction]map[string]bool
+
+	// paddle axes are ignored until they have first been moved outside of
+	// the deadzone. this prevents the resting stick from switching the hand
+	// controller to the paddle type
+	paddleActive bool
+}
+
+// NewGamepads is the preferred method of initialisation for the Gamepads type
+func NewGamepads() *Gamepads {
+	gp := &Gamepads{}
+	for i := range gp.players {
+		gp.players[i].pressed = make(map[GamepadAction]map[string]bool)
+	}
+	return gp
+}
+
+// EventHandler handles gamepad events sent from a GUI. Returns true if the
+// event has been handled, false otherwise.
+//
+// For reasons of consistency, this handler is used by the debugger too.
+func (gp *Gamepads) EventHandler(ev gui.Event, vcs *hardware.VCS) (bool, error) {
+	switch ev.(type) {
+	case gui.EventGamepadConnected, gui.EventGamepadDisconnected, gui.EventGamepadButton, gui.EventGamepadAxis:
+		if gp.Mapping == nil {
+			var err error
+			gp.Mapping, err = LoadGamepadMapping()
+			if err != nil {
+				return true, err
+			}
+		}
+	default:
+		return false, nil
+	}
+
+	switch ev := ev.(type) {
+	case gui.EventGamepadConnected:
+		for i := range gp.players {
+			if gp.players[i].connected && gp.players[i].id == ev.ID {
+				return true, nil
+			}
+		}
+		for i := range gp.players {
+			if !gp.players[i].connected {
+				gp.players[i].connected = true
+				gp.players[i].id = ev.ID
+				gp.players[i].paddleActive = false
+				break // for loop
+			}
+		}
+		return true, nil
+
+	case gui.EventGamepadDisconnected:
+		p := gp.player(ev.ID)
+		if p < 0 {
+			return true, nil
+		}
+
+		// release everything the gamepad was pressing
+		for act := range gp.players[p].pressed {
+			if len(gp.players[p].pressed[act]) > 0 {
+				gp.players[p].pressed[act] = nil
+				if err := gp.perform(vcs, p, act, false); err != nil {
+					return true, err
+				}
+			}
+		}
+		gp.players[p].connected = false
+
+		return true, nil
+
+	case gui.EventGamepadButton:
+		p := gp.player(ev.ID)
+		if p < 0 {
+			return true, nil
+		}
+
+		act, ok := gp.Mapping.Buttons[ev.Button]
+		if !ok || !act.isButtonAction() {
+			return true, nil
+		}
+
+		return true, gp.press(vcs, p, act, string(ev.Button), ev.Down)
+
+	case gui.EventGamepadAxis:
+		p := gp.player(ev.ID)
+		if p < 0 {
+			return true, nil
+		}
+
+		act, ok := gp.Mapping.Axes[ev.Axis]
+		if !ok {
+			return true, nil
+		}
+
+		src := string(ev.Axis)
+		dead := gp.Mapping.Deadzone
+
+		switch act {
+		case GamepadStickHoriz:
+			if err := gp.press(vcs, p, GamepadLeft, src, ev.Amount < -dead); err != nil {
+				return true, err
+			}
+			return true, gp.press(vcs, p, GamepadRight, src, ev.Amount > dead)
+
+		case GamepadStickVert:
+			if err := gp.press(vcs, p, GamepadUp, src, ev.Amount < -dead); err != nil {
+				return true, err
+			}
+			return true, gp.press(vcs, p, GamepadDown, src, ev.Amount > dead)
+
+		case GamepadPaddle:
+			if !gp.players[p].paddleActive {
+				if ev.Amount > -dead && ev.Amount < dead {
+					return true, nil
+				}
+				gp.players[p].paddleActive = true
+			}
+
+			// the range of the trigger axes is already 0.0 to 1.0. the
+			// stick axes need to be scaled
+			v := ev.Amount
+			if ev.Axis != gui.GamepadAxisTriggerLeft && ev.Axis != gui.GamepadAxisTriggerRight {
+				v = (v + 1.0) / 2.0
+			}
+
+			return true, gp.handController(vcs, p).Handle(input.PaddleSet, v)
+
+		default:
+			if act.isButtonAction() {
+				return true, gp.press(vcs, p, act, src, ev.Amount > dead || ev.Amount < -dead)
+			}
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// returns the player being controlled by the gamepad. returns -1 if the
+// gamepad is not controlling a player
+func (gp *Gamepads) player(id gui.GamepadID) int {
+	for i := range gp.players {
+		if gp.players[i].connected && gp.players[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (gp *Gamepads) handController(vcs *hardware.VCS, player int) input.Port {
+	if player == 0 {
+		return vcs.HandController0
+	}
+	return vcs.HandController1
+}
+
+// press or release the action on behalf of the source. the action is only
+// performed if the pressed state of the action changes
+func (gp *Gamepads) press(vcs *hardware.VCS, player int, act GamepadAction, src string, down bool) error {
+	srcs := gp.players[player].pressed[act]
+	wasPressed := len(srcs) > 0
+
+	if down {
+		if srcs == nil {
+			srcs = make(map[string]bool)
+			gp.players[player].pressed[act] = srcs
+		}
+		srcs[src] = true
+	} else {
+		delete(srcs, src)
+	}
+
+	if wasPressed == (len(srcs) > 0) {
+		return nil
+	}
+
+	return gp.perform(vcs, player, act, down)
+}
+
+// perform the action on the VCS
+func (gp *Gamepads) perform(vcs *hardware.VCS, player int, act GamepadAction, down bool) error {
+	hc := gp.handController(vcs, player)
+
+	switch act {
+	case GamepadUp:
+		return hc.Handle(input.Up, down)
+	case GamepadDown:
+		return hc.Handle(input.Down, down)
+	case GamepadLeft:
+		return hc.Handle(input.Left, down)
+	case GamepadRight:
+		return hc.Handle(input.Right, down)
+	case GamepadFire:
+		return hc.Handle(input.Fire, down)
+	case GamepadButtonC:
+		return hc.Handle(input.GenesisButtonC, down)
+	case GamepadTrigger:
+		return hc.Handle(input.BoosterGripTrigger, down)
+	case GamepadBooster:
+		return hc.Handle(input.BoosterGripBooster, down)
+	case GamepadPaddleFire:
+		return hc.Handle(input.PaddleFire, down)
+	case GamepadSelect:
+		return vcs.Panel.Handle(input.PanelSelect, down)
+	case GamepadReset:
+		return vcs.Panel.Handle(input.PanelReset, down)
+	}
+
+	return nil
+}
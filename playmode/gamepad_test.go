@@ -0,0 +1,175 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package playmode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/gui"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+func TestGamepadMappingFile(t *testing.T) {
+	def := DefaultGamepadMapping()
+
+	b := &strings.Builder{}
+	test.ExpectedSuccess(t, def.write(b))
+
+	m := &GamepadMapping{
+		Buttons: make(map[gui.GamepadButton]GamepadAction),
+		Axes:    make(map[gui.GamepadAxis]GamepadAction),
+	}
+	test.ExpectedSuccess(t, m.read(strings.NewReader(b.String())))
+
+	if m.Deadzone != def.Deadzone {
+		t.Errorf("deadzone not preserved (%f - wanted %f)", m.Deadzone, def.Deadzone)
+	}
+	for k, v := range def.Buttons {
+		if m.Buttons[k] != v {
+			t.Errorf("button %s not preserved (%s - wanted %s)", k, m.Buttons[k], v)
+		}
+	}
+	for k, v := range def.Axes {
+		if m.Axes[k] != v {
+			t.Errorf("axis %s not preserved (%s - wanted %s)", k, m.Axes[k], v)
+		}
+	}
+
+	// axis actions cannot be assigned to buttons
+	test.ExpectedFailure(t, m.read(strings.NewReader("button A PADDLE\n")))
+
+	// unknown actions
+	test.ExpectedFailure(t, m.read(strings.NewReader("axis LEFTX FOO\n")))
+
+	// out of range deadzone
+	test.ExpectedFailure(t, m.read(strings.NewReader("deadzone 1.5\n")))
+}
+
+func TestGamepadEvents(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	gp := NewGamepads()
+	gp.Mapping = DefaultGamepadMapping()
+
+	swcha := func() uint8 {
+		t.Helper()
+		v, err := vcs.Mem.Read(0x0280)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return v
+	}
+
+	// events from a gamepad that has not been connected are ignored
+	_, err = gp.EventHandler(gui.EventGamepadButton{ID: 5, Button: gui.GamepadButtonDPadLeft, Down: true}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xf0)
+
+	_, err = gp.EventHandler(gui.EventGamepadConnected{ID: 5}, vcs)
+	test.ExpectedSuccess(t, err)
+
+	// dpad left and left stick pressed together
+	_, err = gp.EventHandler(gui.EventGamepadButton{ID: 5, Button: gui.GamepadButtonDPadLeft, Down: true}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xb0)
+
+	_, err = gp.EventHandler(gui.EventGamepadAxis{ID: 5, Axis: gui.GamepadAxisLeftX, Amount: -0.9}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xb0)
+
+	// releasing the dpad does not release the stick
+	_, err = gp.EventHandler(gui.EventGamepadButton{ID: 5, Button: gui.GamepadButtonDPadLeft, Down: false}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xb0)
+
+	// stick movement within the deadzone releases the stick
+	_, err = gp.EventHandler(gui.EventGamepadAxis{ID: 5, Axis: gui.GamepadAxisLeftX, Amount: -0.1}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xf0)
+
+	// disconnecting the gamepad releases everything
+	_, err = gp.EventHandler(gui.EventGamepadAxis{ID: 5, Axis: gui.GamepadAxisLeftY, Amount: -1.0}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xe0)
+
+	_, err = gp.EventHandler(gui.EventGamepadDisconnected{ID: 5}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(swcha()&0xf0), 0xf0)
+}
+
+func TestGamepadExtraButtons(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vcs, err := hardware.NewVCS(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	gp := NewGamepads()
+	gp.Mapping = DefaultGamepadMapping()
+
+	// the Genesis button C is read through INPT1. the input reads high when
+	// the button is released but only if the hand controller is of the Genesis
+	// type
+	inpt1 := func() uint8 {
+		t.Helper()
+		v, err := vcs.Mem.Read(0x0009)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return v
+	}
+
+	_, err = gp.EventHandler(gui.EventGamepadConnected{ID: 0}, vcs)
+	test.ExpectedSuccess(t, err)
+
+	// the B button does nothing with the default mapping. in particular, it
+	// does not switch the hand controller to the Genesis type
+	_, err = gp.EventHandler(gui.EventGamepadButton{ID: 0, Button: gui.GamepadButtonB, Down: true}, vcs)
+	test.ExpectedSuccess(t, err)
+	_, err = gp.EventHandler(gui.EventGamepadButton{ID: 0, Button: gui.GamepadButtonB, Down: false}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(inpt1()&0x80), 0x00)
+
+	// the user has opted in to the Genesis gamepad
+	gp.Mapping.Buttons[gui.GamepadButtonB] = GamepadButtonC
+
+	_, err = gp.EventHandler(gui.EventGamepadButton{ID: 0, Button: gui.GamepadButtonB, Down: true}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(inpt1()&0x80), 0x00)
+
+	_, err = gp.EventHandler(gui.EventGamepadButton{ID: 0, Button: gui.GamepadButtonB, Down: false}, vcs)
+	test.ExpectedSuccess(t, err)
+	test.Equate(t, int(inpt1()&0x80), 0x80)
+}
@@ -26,24 +26,51 @@ import (
 	"time"
 
 	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/cheats"
 	"github.com/jetsetilly/gopher2600/errors"
 	"github.com/jetsetilly/gopher2600/gui"
 	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/hardware/riot/input"
 	"github.com/jetsetilly/gopher2600/patch"
 	"github.com/jetsetilly/gopher2600/recorder"
+	"github.com/jetsetilly/gopher2600/screenshot"
 	"github.com/jetsetilly/gopher2600/setup"
 	"github.com/jetsetilly/gopher2600/television"
 )
 
 type playmode struct {
-	vcs     *hardware.VCS
-	scr     gui.GUI
-	intChan chan os.Signal
-	guiChan chan gui.Event
+	vcs      *hardware.VCS
+	scr      gui.GUI
+	intChan  chan os.Signal
+	guiChan  chan gui.Event
+	gamepads *Gamepads
+	keyboard *Keyboard
+
+	// the emulation is paused by the PAUSE key binding
+	paused bool
+
+	// resetting the VCS would cause a recording or a playback to go out of
+	// sync so the RESET key binding is disabled in those instances
+	transcript bool
+
+	// cheats for the attached cartridge. nil if cheats are not available
+	// because of a recording or playback
+	cheats *cheats.Cheats
+
+	// keeps a copy of the tv frame for the SCREENSHOT key binding
+	screenshot *screenshot.Screenshot
+
+	// the state saved by the SAVE key binding and restored by the LOAD key
+	// binding. nil if no state has been saved
+	savedState *hardware.State
+
+	// if screenshotAtFrame is greater than zero then a screenshot is saved
+	// when that frame has been completed and the emulation ends
+	screenshotAtFrame int
 }
 
 // Play is a quick of setting up a playable instance of the emulator.
-func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording bool, cartload cartridgeloader.Loader, patchFile string) error {
+func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording bool, cartload cartridgeloader.Loader, patchFile string, keypadLayout input.KeypadLayout, lightGun bool, screenshotAtFrame int) error {
 	var transcript string
 
 	// if supplied cartridge name is actually a playback file then set
@@ -64,6 +91,11 @@ func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording
 		return errors.New(errors.PlayError, err)
 	}
 
+	// the keypad layout only affects how host keys map to the keypad so it
+	// can be applied to both ports regardless of what is plugged in
+	vcs.RIOT.Input.HandController0.SetKeypadLayout(keypadLayout)
+	vcs.RIOT.Input.HandController1.SetKeypadLayout(keypadLayout)
+
 	// note that we attach the cartridge in three different branches below,
 	// depending on
 
@@ -143,11 +175,37 @@ func Play(tv television.Television, scr gui.GUI, showOnStable bool, newRecording
 		}
 	}
 
+	// the light gun is aimed with the mouse. it can not be detected
+	// automatically so it must be plugged in explicitly
+	if lightGun {
+		vcs.RIOT.Input.HandController0.SwitchType(input.LightGunType)
+	}
+
 	pl := &playmode{
-		vcs:     vcs,
-		scr:     scr,
-		intChan: make(chan os.Signal, 1),
-		guiChan: make(chan gui.Event, 2),
+		vcs:               vcs,
+		scr:               scr,
+		intChan:           make(chan os.Signal, 1),
+		guiChan:           make(chan gui.Event, 2),
+		gamepads:          NewGamepads(),
+		keyboard:          NewKeyboard(),
+		transcript:        transcript != "",
+		screenshotAtFrame: screenshotAtFrame,
+	}
+
+	pl.screenshot, err = screenshot.NewScreenshot(tv)
+	if err != nil {
+		return errors.New(errors.PlayError, err)
+	}
+
+	// cheats would cause a recording or a playback to go out of sync so they
+	// are only applied during normal play
+	if !pl.transcript {
+		pl.cheats = cheats.NewCheats(vcs)
+		err = pl.cheats.Load()
+		if err != nil {
+			// continuing because the game is still playable without cheats
+			fmt.Printf("* %s\n", err)
+		}
 	}
 
 	// connect gui
@@ -0,0 +1,32 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package postprocess implements CRT effects on the CPU, for use when a GPU
+// is not available or not wanted. For example, when saving screenshots or
+// videos, or when using the sdlplay GUI.
+//
+// The Processor type applies the effects to a complete frame. The Stage type
+// wraps a Processor in a television.PixelRenderer and sits between the
+// television and the renderers that want to see the processed frame. Because
+// the effects work on complete frames, renderers attached to a Stage see each
+// frame one frame later than they otherwise would.
+//
+// The effects use integer arithmetic so that the output for a given input and
+// set of parameters is the same on every platform.
+package postprocess
@@ -0,0 +1,144 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package postprocess
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
+)
+
+// Params control the strength of each effect. All values are in the range
+// 0.0 to 1.0. A value of zero disables the effect.
+type Params struct {
+	// darkening of every other row of the image
+	Scanlines float64
+
+	// the amount each pixel is mixed with its horizontal neighbours
+	Blur float64
+
+	// the amount the previous frame shows through the current frame. the
+	// previous frame is decayed by this amount every frame and is visible
+	// only where it is brighter than the current frame
+	Persistence float64
+
+	// the amount the current frame is mixed with the previous frame. a value
+	// of 0.5 smooths sprites that have been multiplexed by flickering
+	Blend float64
+
+	// the strength of the glow around bright pixels and the luminance above
+	// which a pixel is considered bright
+	Bloom          float64
+	BloomThreshold float64
+}
+
+// DefaultParams are a reasonable set of parameters for general use
+var DefaultParams = Params{
+	Scanlines:      0.3,
+	Blur:           0.25,
+	Persistence:    0.4,
+	Bloom:          0.3,
+	BloomThreshold: 0.7,
+}
+
+// the names of the parameters as used by ParseParams() and String()
+const (
+	nameScanlines      = "scanlines"
+	nameBlur           = "blur"
+	namePersistence    = "persistence"
+	nameBlend          = "blend"
+	nameBloom          = "bloom"
+	nameBloomThreshold = "bloomthreshold"
+)
+
+func (p Params) String() string {
+	return fmt.Sprintf("%s=%.2f,%s=%.2f,%s=%.2f,%s=%.2f,%s=%.2f,%s=%.2f",
+		nameScanlines, p.Scanlines,
+		nameBlur, p.Blur,
+		namePersistence, p.Persistence,
+		nameBlend, p.Blend,
+		nameBloom, p.Bloom,
+		nameBloomThreshold, p.BloomThreshold)
+}
+
+// ParseParams creates a Params instance from a string of comma separated
+// name=value pairs. For example:
+//
+//	scanlines=0.3,blur=0.5,blend=0.5
+//
+// Parameters that are not named are zero. The string "default" returns
+// DefaultParams.
+func ParseParams(s string) (Params, error) {
+	var p Params
+
+	if strings.ToLower(strings.TrimSpace(s)) == "default" {
+		return DefaultParams, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return Params{}, errors.New(errors.PostProcessError, fmt.Sprintf("parameter has no value (%s)", pair))
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil || v < 0.0 || v > 1.0 {
+			return Params{}, errors.New(errors.PostProcessError, fmt.Sprintf("parameter value must be between 0.0 and 1.0 (%s)", pair))
+		}
+
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case nameScanlines:
+			p.Scanlines = v
+		case nameBlur:
+			p.Blur = v
+		case namePersistence:
+			p.Persistence = v
+		case nameBlend:
+			p.Blend = v
+		case nameBloom:
+			p.Bloom = v
+		case nameBloomThreshold:
+			p.BloomThreshold = v
+		default:
+			return Params{}, errors.New(errors.PostProcessError, fmt.Sprintf("unknown parameter (%s)", kv[0]))
+		}
+	}
+
+	return p, nil
+}
+
+// weight converts a parameter value to an integer in the range 0 to 256
+func weight(v float64) int {
+	if v <= 0.0 {
+		return 0
+	}
+	if v >= 1.0 {
+		return 256
+	}
+	return int(math.Round(v * 256))
+}
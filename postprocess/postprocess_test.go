@@ -0,0 +1,222 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package postprocess_test
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/postprocess"
+	"github.com/jetsetilly/gopher2600/screenshot"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// run "go test -update" to recreate the golden images after an intentional
+// change to the effects
+var update = flag.Bool("update", false, "update golden images")
+
+// testFrame creates a deterministic test image. the odd argument changes the
+// position of the sprite-like block so that consecutive frames differ in the
+// way that flicker-multiplexed sprites do.
+func testFrame(odd bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			// vertical bars of primary colors on a dark background
+			c := color.RGBA{R: 16, G: 16, B: 48, A: 255}
+			switch (x / 4) % 4 {
+			case 1:
+				c = color.RGBA{R: 200, G: 40, B: 40, A: 255}
+			case 3:
+				c = color.RGBA{R: 40, G: 200, B: 40, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	// a bright block that moves between frames
+	bx := 8
+	if odd {
+		bx = 40
+	}
+	for y := 12; y < 20; y++ {
+		for x := bx; x < bx+12; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: 250, G: 250, B: 250, A: 255})
+		}
+	}
+
+	return img
+}
+
+func compareGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+
+	filename := filepath.Join("testdata", name+".png")
+
+	var b bytes.Buffer
+	err := png.Encode(&b, img)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if *update {
+		err = ioutil.WriteFile(filename, b.Bytes(), 0644)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	golden, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("%s: image size (%v) does not match golden image (%v)", name, img.Bounds(), golden.Bounds())
+	}
+
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			g := color.RGBAModel.Convert(golden.At(x, y)).(color.RGBA)
+			if g != img.RGBAAt(x, y) {
+				t.Fatalf("%s: pixel at %d,%d (%v) does not match golden image (%v)", name, x, y, img.RGBAAt(x, y), g)
+			}
+		}
+	}
+}
+
+func TestEffects(t *testing.T) {
+	tests := []struct {
+		name   string
+		params postprocess.Params
+	}{
+		{"none", postprocess.Params{}},
+		{"scanlines", postprocess.Params{Scanlines: 0.5}},
+		{"blur", postprocess.Params{Blur: 1.0}},
+		{"persistence", postprocess.Params{Persistence: 0.5}},
+		{"blend", postprocess.Params{Blend: 0.5}},
+		{"bloom", postprocess.Params{Bloom: 1.0, BloomThreshold: 0.5}},
+		{"default", postprocess.DefaultParams},
+	}
+
+	for _, tst := range tests {
+		pr := postprocess.NewProcessor(tst.params)
+
+		// the effects that use the previous frame need more than one frame.
+		// the source frames should not be altered by processing
+		src := testFrame(false)
+		pr.Process(src)
+		if !bytes.Equal(src.Pix, testFrame(false).Pix) {
+			t.Errorf("%s: source image altered by processing", tst.name)
+		}
+		compareGolden(t, tst.name, pr.Process(testFrame(true)))
+	}
+
+	// no effects should mean no change
+	pr := postprocess.NewProcessor(postprocess.Params{})
+	if !bytes.Equal(pr.Process(testFrame(true)).Pix, testFrame(true).Pix) {
+		t.Errorf("processing with no effects changed the image")
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	p, err := postprocess.ParseParams("scanlines=0.3, blur=0.5,BLEND=1")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if p != (postprocess.Params{Scanlines: 0.3, Blur: 0.5, Blend: 1.0}) {
+		t.Errorf("unexpected parameters (%s)", p)
+	}
+
+	p, err = postprocess.ParseParams("default")
+	if err != nil || p != postprocess.DefaultParams {
+		t.Errorf("default parameters not parsed")
+	}
+
+	for _, s := range []string{"scanlines", "scanlines=2.0", "foo=0.5", "blur=x"} {
+		_, err = postprocess.ParseParams(s)
+		if err == nil {
+			t.Errorf("expected error parsing %q", s)
+		}
+	}
+
+	// the string representation of the parameters can be parsed
+	p, err = postprocess.ParseParams(postprocess.DefaultParams.String())
+	if err != nil || p != postprocess.DefaultParams {
+		t.Errorf("parameters not recreated from string (%s)", postprocess.DefaultParams)
+	}
+}
+
+func TestStage(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	st, err := postprocess.NewStage(tv, postprocess.Params{Scanlines: 1.0})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the stage is used in place of the television
+	scr, err := screenshot.NewScreenshot(st)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for f := 0; f < 3; f++ {
+		for sl := 0; sl < tv.GetSpec().ScanlinesTotal; sl++ {
+			for hp := 0; hp < television.HorizClksScanline; hp++ {
+				err := tv.Signal(television.SignalAttributes{
+					VSync: sl < 3,
+					HSync: hp >= 16 && hp < 36,
+					Pixel: 0x0e,
+				})
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+		}
+	}
+
+	// with full scanline darkening every other row of the full frame is
+	// black
+	img := scr.Image(screenshot.Options{Overscan: true, HBlank: true})
+	x := img.Bounds().Dx() / 2
+	for y := 100; y < 104; y++ {
+		c := img.RGBAAt(x, y)
+		black := c.R == 0 && c.G == 0 && c.B == 0
+		if black != (y%2 == 1) {
+			t.Errorf("unexpected color on row %d (%v)", y, c)
+		}
+	}
+}
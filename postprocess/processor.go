@@ -0,0 +1,250 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package postprocess
+
+import (
+	"image"
+)
+
+// the number of pixels either side of a bright pixel that are affected by
+// bloom
+const bloomRadius = 2
+
+// Processor applies the effects described by Params to a sequence of frames.
+// Some effects depend on the previous frame so the frames should be processed
+// in order.
+type Processor struct {
+	Params
+
+	// the previous unprocessed frame. used for frame blending
+	prevRaw *image.RGBA
+
+	// the previous frame after frame blending and persistence have been
+	// applied. used for persistence
+	prevPersist *image.RGBA
+
+	// working buffers
+	bright []int
+	glow   []int
+}
+
+// NewProcessor is the preferred method of initialisation for the Processor
+// type
+func NewProcessor(params Params) *Processor {
+	return &Processor{Params: params}
+}
+
+// Reset forgets the previous frame
+func (pr *Processor) Reset() {
+	pr.prevRaw = nil
+	pr.prevPersist = nil
+}
+
+// Process a frame. The source image is not altered and the returned image is
+// newly allocated.
+func (pr *Processor) Process(src *image.RGBA) *image.RGBA {
+	b := src.Bounds()
+
+	// previous frames are of no use if the frame size has changed
+	if pr.prevRaw != nil && pr.prevRaw.Bounds() != b {
+		pr.Reset()
+	}
+
+	// copy source to the image that will be returned. the pixels are copied
+	// such that the image has an origin of zero
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		i := src.PixOffset(b.Min.X, b.Min.Y+y)
+		copy(dst.Pix[y*dst.Stride:y*dst.Stride+b.Dx()*4], src.Pix[i:i+b.Dx()*4])
+	}
+
+	raw := image.NewRGBA(dst.Bounds())
+	copy(raw.Pix, dst.Pix)
+
+	pr.blend(dst)
+	pr.persistence(dst)
+	pr.blur(dst)
+	pr.bloom(dst)
+	pr.scanlines(dst)
+
+	pr.prevRaw = raw
+
+	return dst
+}
+
+// mix the previous frame into the current frame
+func (pr *Processor) blend(dst *image.RGBA) {
+	w := weight(pr.Blend)
+	if w == 0 || pr.prevRaw == nil {
+		return
+	}
+
+	for i := range dst.Pix {
+		if i%4 == 3 {
+			continue
+		}
+		dst.Pix[i] = uint8((int(dst.Pix[i])*(256-w) + int(pr.prevRaw.Pix[i])*w) >> 8)
+	}
+}
+
+// decay the previous frame and keep whichever is brighter of the decayed pixel
+// and the current pixel
+func (pr *Processor) persistence(dst *image.RGBA) {
+	w := weight(pr.Persistence)
+	if w == 0 {
+		pr.prevPersist = nil
+		return
+	}
+
+	if pr.prevPersist != nil {
+		for i := range dst.Pix {
+			if i%4 == 3 {
+				continue
+			}
+			decay := uint8((int(pr.prevPersist.Pix[i]) * w) >> 8)
+			if decay > dst.Pix[i] {
+				dst.Pix[i] = decay
+			}
+		}
+	}
+
+	pr.prevPersist = image.NewRGBA(dst.Bounds())
+	copy(pr.prevPersist.Pix, dst.Pix)
+}
+
+// mix every pixel with its horizontal neighbours
+func (pr *Processor) blur(dst *image.RGBA) {
+	// the weight of each neighbour. at maximum blur each of the three pixels
+	// contribute equally
+	w := weight(pr.Blur) * 85 / 256
+	if w == 0 {
+		return
+	}
+
+	width := dst.Bounds().Dx()
+	row := make([]uint8, width*4)
+
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		line := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+		copy(row, line)
+		for x := 0; x < width; x++ {
+			l := x - 1
+			if l < 0 {
+				l = 0
+			}
+			r := x + 1
+			if r >= width {
+				r = width - 1
+			}
+			for c := 0; c < 3; c++ {
+				v := int(row[x*4+c])*(256-2*w) + (int(row[l*4+c])+int(row[r*4+c]))*w
+				line[x*4+c] = uint8(v >> 8)
+			}
+		}
+	}
+}
+
+// add a glow around bright pixels
+func (pr *Processor) bloom(dst *image.RGBA) {
+	w := weight(pr.Bloom)
+	if w == 0 {
+		return
+	}
+
+	threshold := weight(pr.BloomThreshold) * 255 / 256
+	width := dst.Bounds().Dx()
+	height := dst.Bounds().Dy()
+	n := width * height * 3
+
+	if len(pr.bright) != n {
+		pr.bright = make([]int, n)
+		pr.glow = make([]int, n)
+	}
+
+	// bright pass
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := dst.Pix[y*dst.Stride+x*4:]
+			luma := (77*int(p[0]) + 150*int(p[1]) + 29*int(p[2])) >> 8
+			i := (y*width + x) * 3
+			for c := 0; c < 3; c++ {
+				if luma > threshold {
+					pr.bright[i+c] = int(p[c])
+				} else {
+					pr.bright[i+c] = 0
+				}
+			}
+		}
+	}
+
+	// box blur of the bright pass, first horizontally and then vertically
+	const taps = bloomRadius*2 + 1
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for c := 0; c < 3; c++ {
+				sum := 0
+				for k := x - bloomRadius; k <= x+bloomRadius; k++ {
+					if k >= 0 && k < width {
+						sum += pr.bright[(y*width+k)*3+c]
+					}
+				}
+				pr.glow[(y*width+x)*3+c] = sum / taps
+			}
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for c := 0; c < 3; c++ {
+				sum := 0
+				for k := y - bloomRadius; k <= y+bloomRadius; k++ {
+					if k >= 0 && k < height {
+						sum += pr.glow[(k*width+x)*3+c]
+					}
+				}
+				i := y*dst.Stride + x*4 + c
+				v := int(dst.Pix[i]) + (sum/taps*w)>>8
+				if v > 255 {
+					v = 255
+				}
+				dst.Pix[i] = uint8(v)
+			}
+		}
+	}
+}
+
+// darken every other row
+func (pr *Processor) scanlines(dst *image.RGBA) {
+	w := weight(pr.Scanlines)
+	if w == 0 {
+		return
+	}
+
+	width := dst.Bounds().Dx()
+	for y := 1; y < dst.Bounds().Dy(); y += 2 {
+		line := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+		for i := range line {
+			if i%4 == 3 {
+				continue
+			}
+			line[i] = uint8((int(line[i]) * (256 - w)) >> 8)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package postprocess
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// the number of scanlines in addition to the specification's total that the
+// frame has room for. a television in CRT mode can produce frames that are
+// slightly longer than the specification allows
+const extraScanlines = 16
+
+// Stage is an implementation of the television.PixelRenderer interface. It
+// collects a frame from the television, processes it, and then sends the
+// processed frame to its own list of renderers.
+//
+// Stage embeds the Television interface and can be used in place of the
+// television when creating renderers. Renderers added with AddPixelRenderer()
+// will be attached to the Stage rather than the television.
+type Stage struct {
+	television.Television
+
+	proc *Processor
+
+	renderers []television.PixelRenderer
+
+	frame *image.RGBA
+
+	// the highest scanline seen in the current frame
+	lastScanline int
+}
+
+// NewStage is the preferred method of initialisation for the Stage type. The
+// new instance is added to the television's list of pixel renderers.
+func NewStage(tv television.Television, params Params) (*Stage, error) {
+	st := &Stage{
+		Television: tv,
+		proc:       NewProcessor(params),
+	}
+	st.allocate()
+
+	tv.AddPixelRenderer(st)
+
+	return st, nil
+}
+
+// allocate frame large enough for the current specification. an existing frame
+// that is large enough is kept
+func (st *Stage) allocate() {
+	h := st.GetSpec().ScanlinesTotal + extraScanlines
+	if st.frame != nil && st.frame.Bounds().Dy() >= h {
+		return
+	}
+	st.frame = image.NewRGBA(image.Rect(0, 0, television.HorizClksScanline, h))
+}
+
+// SetParams changes the parameters of the effects. Takes effect from the next
+// frame.
+func (st *Stage) SetParams(params Params) {
+	st.proc.Params = params
+}
+
+// GetParams returns the parameters currently in use
+func (st *Stage) GetParams() Params {
+	return st.proc.Params
+}
+
+// AddPixelRenderer adds a renderer to the stage's list of renderers. The
+// renderer will receive the processed frame.
+func (st *Stage) AddPixelRenderer(r television.PixelRenderer) {
+	st.renderers = append(st.renderers, r)
+}
+
+// Resize implements television.PixelRenderer interface
+func (st *Stage) Resize(topScanline, visibleScanlines int) error {
+	st.allocate()
+	for _, r := range st.renderers {
+		err := r.Resize(topScanline, visibleScanlines)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewFrame implements television.PixelRenderer interface
+//
+// The frame that has just completed is processed and sent to the stage's
+// renderers, followed by the NewFrame() call itself.
+func (st *Stage) NewFrame(frameNum int) error {
+	// the number of scanlines to process. this is usually the number of
+	// scanlines in the specification but can be more if the television has
+	// produced a longer frame. using the same number of scanlines every frame
+	// is important for the effects that depend on the previous frame
+	h := st.GetSpec().ScanlinesTotal
+	if st.lastScanline >= h {
+		h = st.lastScanline + 1
+	}
+	if h > st.frame.Bounds().Dy() {
+		h = st.frame.Bounds().Dy()
+	}
+
+	dst := st.proc.Process(st.frame.SubImage(image.Rect(0, 0, television.HorizClksScanline, h)).(*image.RGBA))
+
+	for _, r := range st.renderers {
+		for y := 0; y < h; y++ {
+			err := r.NewScanline(y)
+			if err != nil {
+				return err
+			}
+			for x := 0; x < television.HorizClksScanline; x++ {
+				c := dst.RGBAAt(x, y)
+				err := r.SetPixel(x, y, c.R, c.G, c.B, false)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		err := r.NewFrame(frameNum)
+		if err != nil {
+			return err
+		}
+	}
+
+	st.lastScanline = 0
+	st.allocate()
+
+	return nil
+}
+
+// NewScanline implements television.PixelRenderer interface
+func (st *Stage) NewScanline(_ int) error {
+	return nil
+}
+
+// SetPixel implements television.PixelRenderer interface
+func (st *Stage) SetPixel(x, y int, red, green, blue byte, vblank bool) error {
+	// pixels are black during VBLANK. the renderers attached to the stage are
+	// never told of VBLANK
+	if vblank {
+		red, green, blue = 0, 0, 0
+	}
+	st.frame.SetRGBA(x, y, color.RGBA{R: red, G: green, B: blue, A: 255})
+	if y > st.lastScanline {
+		st.lastScanline = y
+	}
+	return nil
+}
+
+// SetAltPixel implements television.PixelRenderer interface
+//
+// Alternative pixels are not processed and are sent to the stage's renderers
+// immediately.
+func (st *Stage) SetAltPixel(x, y int, red, green, blue byte, vblank bool) error {
+	for _, r := range st.renderers {
+		err := r.SetAltPixel(x, y, red, green, blue, vblank)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EndRendering implements television.PixelRenderer interface
+func (st *Stage) EndRendering() error {
+	for _, r := range st.renderers {
+		err := r.EndRendering()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
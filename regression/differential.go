@@ -0,0 +1,271 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package regression
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/jetsetilly/gopher2600/cartridgeloader"
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware"
+	"github.com/jetsetilly/gopher2600/recorder"
+	"github.com/jetsetilly/gopher2600/setup"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// the number of frames to compare in a differential run of a cartridge if no
+// number has been specified
+const defaultDiffFrames = 100
+
+// DiffSetup specifies how one of the emulations in a differential run should
+// differ from the other. Empty fields take the value from the playback file
+// or, if a cartridge is being run, the AUTO value.
+type DiffSetup struct {
+	TVSpec     string
+	CartFormat string
+}
+
+func (ds DiffSetup) String() string {
+	return fmt.Sprintf("tv=%s cartformat=%s", ds.TVSpec, ds.CartFormat)
+}
+
+// frameHash is a television.FrameRenderer that creates a digest of the color
+// signals of the most recent frame. unlike digest.Video the digest does not
+// depend on the television's palette, or on any previous frame, meaning that
+// emulations using different television specifications can be compared frame
+// by frame.
+type frameHash struct {
+	hash [sha1.Size]byte
+	buf  []byte
+}
+
+func (fh *frameHash) RenderFrame(frame *television.Frame) error {
+	fh.buf = fh.buf[:0]
+	for _, p := range frame.Pixels[:frame.Scanlines*television.HorizClksScanline] {
+		fh.buf = append(fh.buf, byte(p), byte(p>>8))
+	}
+	fh.hash = sha1.Sum(fh.buf)
+	return nil
+}
+
+func (fh *frameHash) Resize(_, _ int) error                            { return nil }
+func (fh *frameHash) NewFrame(_ int) error                             { return nil }
+func (fh *frameHash) NewScanline(_ int) error                          { return nil }
+func (fh *frameHash) SetPixel(_, _ int, _, _, _ byte, _ bool) error    { return nil }
+func (fh *frameHash) SetAltPixel(_, _ int, _, _, _ byte, _ bool) error { return nil }
+func (fh *frameHash) EndRendering() error                              { return nil }
+
+// one of the two emulations in a differential run
+type diffEmulation struct {
+	config DiffSetup
+	tv     television.Television
+	vcs    *hardware.VCS
+	digest *frameHash
+	plb    *recorder.Playback
+
+	// the emulation has been powered off by the playback
+	ended bool
+}
+
+func newDiffEmulation(filename string, ds DiffSetup) (*diffEmulation, error) {
+	emu := &diffEmulation{config: ds, digest: &frameHash{}}
+
+	cartload := cartridgeloader.Loader{
+		Filename: filename,
+		Format:   "AUTO",
+	}
+
+	var err error
+
+	if recorder.IsPlaybackFile(filename) {
+		emu.plb, err = recorder.NewPlayback(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		// the emulations are not expected to match the recording
+		emu.plb.DisableChecks = true
+
+		cartload = emu.plb.CartLoad
+		if emu.config.TVSpec == "" {
+			emu.config.TVSpec = emu.plb.TVSpec
+		}
+	}
+
+	if emu.config.TVSpec == "" {
+		emu.config.TVSpec = "AUTO"
+	}
+	if emu.config.CartFormat == "" {
+		emu.config.CartFormat = cartload.Format
+	}
+	cartload.Format = emu.config.CartFormat
+
+	emu.tv, err = television.NewTelevision(emu.config.TVSpec)
+	if err != nil {
+		return nil, err
+	}
+	emu.tv.SetFPSCap(false)
+	emu.tv.AddPixelRenderer(emu.digest)
+
+	// both emulations must start from the same random state
+	rand.Seed(1)
+
+	emu.vcs, err = hardware.NewVCS(emu.tv)
+	if err != nil {
+		emu.tv.End()
+		return nil, err
+	}
+
+	if emu.plb != nil {
+		err = emu.plb.AttachToVCS(emu.vcs)
+		if err != nil {
+			emu.tv.End()
+			return nil, err
+		}
+
+		// not using setup.AttachCartridge for playbacks. see the comment in
+		// PlaybackRegression.regress()
+		err = emu.vcs.AttachCartridge(cartload)
+	} else {
+		err = setup.AttachCartridge(emu.vcs, cartload)
+	}
+	if err != nil {
+		emu.tv.End()
+		return nil, err
+	}
+
+	return emu, nil
+}
+
+// run the emulation for a single frame
+func (emu *diffEmulation) step() error {
+	err := emu.vcs.RunForFrameCount(1, nil)
+	if err != nil {
+		if errors.Is(err, errors.PowerOff) {
+			emu.ended = true
+			return nil
+		}
+		return err
+	}
+
+	if emu.plb != nil {
+		emu.ended, err = emu.plb.EndFrame()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RegressDifferential runs two emulations of the same cartridge, or playback
+// file, in lockstep. The emulations differ according to the two DiffSetup
+// arguments. A playback file provides the same input to both emulations.
+//
+// A digest of the color signals in each frame is compared and the frames in
+// which the two emulations diverge are reported. Because the digest does not
+// depend on the television's palette, emulations using different television
+// specifications can be compared.
+//
+// The emulations are run for numFrames frames or, if numFrames is zero, until
+// the end of the playback file. Returns false if the emulations diverge.
+func RegressDifferential(output io.Writer, filename string, a DiffSetup, b DiffSetup, numFrames int) (bool, error) {
+	// tests must be determinate. reseed with clock on completion
+	defer rand.Seed(int64(time.Now().Second()))
+
+	if output == nil {
+		return false, errors.New(errors.PanicError, "RegressDifferential()", "io.Writer should not be nil (use nopWriter)")
+	}
+
+	emuA, err := newDiffEmulation(filename, a)
+	if err != nil {
+		return false, errors.New(errors.RegressionDiffError, err)
+	}
+	defer emuA.tv.End()
+
+	emuB, err := newDiffEmulation(filename, b)
+	if err != nil {
+		return false, errors.New(errors.RegressionDiffError, err)
+	}
+	defer emuB.tv.End()
+
+	if numFrames == 0 && emuA.plb == nil {
+		numFrames = defaultDiffFrames
+	}
+
+	output.Write([]byte(fmt.Sprintf("A: %s\nB: %s\n", emuA.config, emuB.config)))
+
+	// the first frame of the current divergence. -1 if the emulations
+	// currently match
+	divergeFrom := -1
+	numDiverge := 0
+
+	reportDivergence := func(to int) {
+		if divergeFrom == to {
+			output.Write([]byte(fmt.Sprintf("frame %d diverges\n", divergeFrom)))
+		} else {
+			output.Write([]byte(fmt.Sprintf("frames %d-%d (%d) diverge\n", divergeFrom, to, to-divergeFrom+1)))
+		}
+	}
+
+	frame := 0
+	for ; numFrames == 0 || frame < numFrames; frame++ {
+		err = emuA.step()
+		if err != nil {
+			return false, errors.New(errors.RegressionDiffError, fmt.Sprintf("A: %v", err))
+		}
+
+		err = emuB.step()
+		if err != nil {
+			return false, errors.New(errors.RegressionDiffError, fmt.Sprintf("B: %v", err))
+		}
+
+		if emuA.digest.hash != emuB.digest.hash {
+			numDiverge++
+			if divergeFrom == -1 {
+				divergeFrom = frame
+			}
+		} else if divergeFrom != -1 {
+			reportDivergence(frame - 1)
+			divergeFrom = -1
+		}
+
+		if emuA.ended || emuB.ended {
+			frame++
+			break
+		}
+	}
+
+	if divergeFrom != -1 {
+		reportDivergence(frame - 1)
+	}
+
+	if numDiverge == 0 {
+		output.Write([]byte(fmt.Sprintf("differential: no divergence in %d frames\n", frame)))
+		return true, nil
+	}
+
+	output.Write([]byte(fmt.Sprintf("differential: %d of %d frames diverge\n", numDiverge, frame)))
+	return false, nil
+}
@@ -0,0 +1,126 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package regression_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/regression"
+	"github.com/jetsetilly/gopher2600/test"
+)
+
+// an 8k cartridge that draws complete frames with a background color read
+// from address $f800. the same program is in both 4k banks but the color is
+// different in each. which color is used therefore depends on the cartridge
+// format: an F8 cartridge starts in the first bank while the last 2k of a 3F
+// cartridge is always the last 2k of the file
+var diffProgram = []uint8{
+	0xa9, 0x02, // frame: LDA #$02
+	0x85, 0x00, // STA VSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0x85, 0x02, // STA WSYNC
+	0xa9, 0x00, // LDA #$00
+	0x85, 0x00, // STA VSYNC
+	0x85, 0x01, // STA VBLANK
+	0xad, 0x00, 0xf8, // LDA $f800
+	0x85, 0x09, // STA COLUBK
+	0xa2, 0x00, // LDX #$00
+	0x85, 0x02, // line: STA WSYNC
+	0xca,       // DEX
+	0xd0, 0xfb, // BNE line
+	0x4c, 0x00, 0xfc, // JMP frame
+}
+
+var diffColors = []uint8{0x0e, 0x44}
+
+// runs the differential test in a temporary directory, so that nothing is
+// left behind in the resource path, and returns the output
+func runDifferential(t *testing.T, a regression.DiffSetup, b regression.DiffSetup) (bool, string) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "differential")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.Chdir(wd)
+
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	rom := make([]uint8, 8192)
+	for b, col := range diffColors {
+		bank := rom[b*4096 : (b+1)*4096]
+		copy(bank[0xc00:], diffProgram)
+		bank[0x800] = col
+		bank[0xffc] = 0x00
+		bank[0xffd] = 0xfc
+	}
+	filename := filepath.Join(dir, "diff.bin")
+	err = ioutil.WriteFile(filename, rom, 0644)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	output := &strings.Builder{}
+	ok, err := regression.RegressDifferential(output, filename, a, b, 10)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	return ok, output.String()
+}
+
+func TestDifferentialMatch(t *testing.T) {
+	a := regression.DiffSetup{TVSpec: "NTSC", CartFormat: "F8"}
+	b := regression.DiffSetup{TVSpec: "NTSC", CartFormat: "F8"}
+	ok, output := runDifferential(t, a, b)
+	test.Equate(t, ok, true)
+	test.Equate(t, strings.HasSuffix(output, "differential: no divergence in 10 frames\n"), true)
+
+	// the comparison does not depend on the television's palette
+	b = regression.DiffSetup{TVSpec: "PAL", CartFormat: "F8"}
+	ok, _ = runDifferential(t, a, b)
+	test.Equate(t, ok, true)
+}
+
+func TestDifferentialDiverge(t *testing.T) {
+	a := regression.DiffSetup{TVSpec: "NTSC", CartFormat: "F8"}
+	b := regression.DiffSetup{TVSpec: "NTSC", CartFormat: "3F"}
+	ok, output := runDifferential(t, a, b)
+	test.Equate(t, ok, false)
+
+	// the first frame is not affected because the color register isn't
+	// written until after the first VSYNC
+	test.Equate(t, strings.Contains(output, "frames 1-9 (9) diverge\n"), true)
+	test.Equate(t, strings.HasSuffix(output, "differential: 9 of 10 frames diverge\n"), true)
+}
@@ -0,0 +1,26 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package screenshot implements a television.PixelRenderer that keeps a copy
+// of the television's frame and which can save that frame to disk as a PNG
+// file. It needs no display and so can be used in any emulation mode.
+//
+// A copy of the frame is taken whenever a frame is completed. A screenshot
+// taken part way through a frame is therefore of the previous, complete frame.
+package screenshot
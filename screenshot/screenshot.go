@@ -0,0 +1,219 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"time"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// the width of a VCS pixel in relation to its height. the actual width of a
+// pixel is also factored by the specification's AspectBias
+const pixelWidth = 2.0
+
+// the number of scanlines in addition to the specification's total that the
+// frame has room for. a television in CRT mode can produce frames that are
+// slightly longer than the specification allows
+const extraScanlines = 16
+
+// Options control which parts of the frame are included in a screenshot
+type Options struct {
+	// include the scanlines outside of the visible screen
+	Overscan bool
+
+	// include the horizontal blanking period at the start of every scanline
+	HBlank bool
+
+	// use the alternative (debugging) colors rather than the normal colors
+	Alt bool
+}
+
+// Screenshot is an implementation of the television.PixelRenderer interface
+// with an embedded television for convenience
+type Screenshot struct {
+	television.Television
+
+	pixels    *image.RGBA
+	altPixels *image.RGBA
+
+	// copies of pixels and altPixels taken at the end of each frame
+	completed    *image.RGBA
+	altCompleted *image.RGBA
+
+	// the visible area of the screen as reported by the television
+	top     int
+	visible int
+}
+
+// NewScreenshot is the preferred method of initialisation for the Screenshot
+// type. The new instance is added to the television's list of pixel renderers.
+func NewScreenshot(tv television.Television) (*Screenshot, error) {
+	scr := &Screenshot{
+		Television: tv,
+		top:        tv.GetSpec().ScanlineTop,
+		visible:    tv.GetSpec().ScanlinesVisible,
+	}
+	scr.allocate()
+	scr.completed = copyImage(scr.completed, scr.pixels)
+	scr.altCompleted = copyImage(scr.altCompleted, scr.altPixels)
+
+	// register ourselves as a television.Renderer
+	tv.AddPixelRenderer(scr)
+
+	return scr, nil
+}
+
+// allocate images large enough for the current specification. existing
+// images that are large enough are kept
+func (scr *Screenshot) allocate() {
+	h := scr.GetSpec().ScanlinesTotal + extraScanlines
+	if scr.pixels != nil && scr.pixels.Bounds().Dy() >= h {
+		return
+	}
+	scr.pixels = image.NewRGBA(image.Rect(0, 0, television.HorizClksScanline, h))
+	scr.altPixels = image.NewRGBA(image.Rect(0, 0, television.HorizClksScanline, h))
+}
+
+// Resize implements television.PixelRenderer interface
+func (scr *Screenshot) Resize(topScanline, visibleScanlines int) error {
+	scr.top = topScanline
+	scr.visible = visibleScanlines
+	scr.allocate()
+	return nil
+}
+
+// copy src to dst. a new dst is allocated if it is nil or if it is not the
+// same size as src
+func copyImage(dst *image.RGBA, src *image.RGBA) *image.RGBA {
+	if dst == nil || dst.Bounds() != src.Bounds() {
+		dst = image.NewRGBA(src.Bounds())
+	}
+	copy(dst.Pix, src.Pix)
+	return dst
+}
+
+// NewFrame implements television.PixelRenderer interface
+func (scr *Screenshot) NewFrame(_ int) error {
+	scr.completed = copyImage(scr.completed, scr.pixels)
+	scr.altCompleted = copyImage(scr.altCompleted, scr.altPixels)
+
+	// the specification may have changed without a call to Resize()
+	scr.allocate()
+	return nil
+}
+
+// NewScanline implements television.PixelRenderer interface
+func (scr *Screenshot) NewScanline(_ int) error {
+	return nil
+}
+
+// SetPixel implements television.PixelRenderer interface
+func (scr *Screenshot) SetPixel(x, y int, red, green, blue byte, vblank bool) error {
+	// the screenshot should look like the television so pixels are black
+	// during VBLANK
+	if vblank {
+		red, green, blue = 0, 0, 0
+	}
+	scr.pixels.SetRGBA(x, y, color.RGBA{R: red, G: green, B: blue, A: 255})
+	return nil
+}
+
+// SetAltPixel implements television.PixelRenderer interface
+func (scr *Screenshot) SetAltPixel(x, y int, red, green, blue byte, vblank bool) error {
+	scr.altPixels.SetRGBA(x, y, color.RGBA{R: red, G: green, B: blue, A: 255})
+	return nil
+}
+
+// EndRendering implements television.PixelRenderer interface
+func (scr *Screenshot) EndRendering() error {
+	return nil
+}
+
+// Image returns a copy of the most recently completed frame. The width of the
+// image is corrected so that the pixels have the correct aspect ratio.
+func (scr *Screenshot) Image(opts Options) *image.RGBA {
+	src := scr.completed
+	if opts.Alt {
+		src = scr.altCompleted
+	}
+
+	// area of the frame to use
+	crop := image.Rect(television.HorizClksHBlank, scr.top, television.HorizClksScanline, scr.top+scr.visible)
+	if opts.HBlank {
+		crop.Min.X = 0
+	}
+	if opts.Overscan {
+		crop.Min.Y = 0
+		crop.Max.Y = scr.GetSpec().ScanlinesTotal
+	}
+	crop = crop.Intersect(src.Bounds())
+
+	// width of each pixel in the screenshot
+	scale := pixelWidth * float64(scr.GetSpec().AspectBias)
+
+	w := int(math.Round(float64(crop.Dx()) * scale))
+	img := image.NewRGBA(image.Rect(0, 0, w, crop.Dy()))
+
+	for y := 0; y < crop.Dy(); y++ {
+		for x := 0; x < w; x++ {
+			sx := crop.Min.X + int(float64(x)/scale)
+			img.SetRGBA(x, y, src.RGBAAt(sx, crop.Min.Y+y))
+		}
+	}
+
+	return img
+}
+
+// Save the most recently completed frame to a PNG file
+func (scr *Screenshot) Save(filename string, opts Options) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.New(errors.ScreenshotError, err)
+	}
+
+	err = png.Encode(f, scr.Image(opts))
+	if err != nil {
+		_ = f.Close()
+		return errors.New(errors.ScreenshotError, err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return errors.New(errors.ScreenshotError, err)
+	}
+
+	return nil
+}
+
+// UniqueFilename returns a filename, based on the name of the cartridge and
+// the current time, that is suitable for a new screenshot
+func UniqueFilename(cartName string) string {
+	n := time.Now()
+	return fmt.Sprintf("screenshot_%s_%04d%02d%02d_%02d%02d%02d.png",
+		cartName, n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second())
+}
@@ -0,0 +1,114 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package screenshot_test
+
+import (
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/screenshot"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+func TestScreenshot(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	scr, err := screenshot.NewScreenshot(tv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// send the number of scanlines to the television, all of a single color
+	signal := func(scanlines int, pixel television.ColorSignal) {
+		t.Helper()
+		for sl := 0; sl < scanlines; sl++ {
+			for hp := 0; hp < television.HorizClksScanline; hp++ {
+				err := tv.Signal(television.SignalAttributes{
+					VSync: sl < 3,
+					HSync: hp >= 16 && hp < 36,
+					Pixel: pixel,
+				})
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+		}
+	}
+
+	// two complete frames of a single color followed by most of a frame of a
+	// different color. the screenshot should be of the last complete frame
+	const pixel = 0x1e
+	signal(tv.GetSpec().ScanlinesTotal, pixel)
+	signal(tv.GetSpec().ScanlinesTotal, pixel)
+	signal(tv.GetSpec().ScanlinesTotal*3/4, 0x84)
+
+	spec := tv.GetSpec()
+	width := func(clks int) int {
+		return int(float32(clks)*2.0*spec.AspectBias + 0.5)
+	}
+
+	img := scr.Image(screenshot.Options{})
+	if img.Bounds().Dx() != width(television.HorizClksVisible) || img.Bounds().Dy() != spec.ScanlinesVisible {
+		t.Errorf("unexpected screenshot size (%v)", img.Bounds())
+	}
+
+	c := img.RGBAAt(img.Bounds().Dx()/2, img.Bounds().Dy()/2)
+	if c.R != spec.Colors[pixel].Red || c.G != spec.Colors[pixel].Green || c.B != spec.Colors[pixel].Blue {
+		t.Errorf("unexpected screenshot color (%v)", c)
+	}
+
+	img = scr.Image(screenshot.Options{Overscan: true, HBlank: true})
+	if img.Bounds().Dx() != width(television.HorizClksScanline) || img.Bounds().Dy() != spec.ScanlinesTotal {
+		t.Errorf("unexpected screenshot size with overscan and hblank (%v)", img.Bounds())
+	}
+
+	// saved file should be a PNG of the same size as the image
+	dir, err := ioutil.TempDir("", "screenshot")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "test.png")
+	err = scr.Save(filename, screenshot.Options{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer f.Close()
+
+	cfg, err := png.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if cfg.Width != width(television.HorizClksVisible) || cfg.Height != spec.ScanlinesVisible {
+		t.Errorf("unexpected size of saved screenshot (%dx%d)", cfg.Width, cfg.Height)
+	}
+}
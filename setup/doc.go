@@ -47,5 +47,5 @@
 //
 //	<DB Key>, television, <SHA-1 Hash>, <tv spec>, notes
 //
-// TV spec should be one of PAL or NTSC (or AUTO)
+// TV spec should be one of NTSC, PAL, SECAM, PAL60 or NTSC50 (or AUTO)
 package setup
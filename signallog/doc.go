@@ -0,0 +1,44 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package signallog records the signal sent from the VCS to the television.
+// Rather than recording the signal for every color clock, only the color
+// clocks at which the HSYNC, VSYNC, VBLANK or CBURST attributes change are
+// recorded. The pixel and audio data in the signal is not recorded.
+//
+// The Logger type is an implementation of the television.Television interface
+// and should be used in place of the television when creating the VCS:
+//
+//	lg, _ := signallog.New(tv, "signals.log")
+//	vcs, _ := hardware.NewVCS(lg)
+//	...
+//	lg.Close()
+//
+// The log file begins with a short header followed by a list of records. Each
+// record is the number of color clocks since the previous record, as an
+// unsigned varint, followed by a single byte containing the new state of the
+// signal attributes. If the top bit of the state byte is set then the record
+// also contains the ID of the television specification, as an unsigned varint
+// length followed by the ID itself. The first record always includes the
+// specification ID.
+//
+// The Reader type reads the records of a log file. The Analyse() function
+// uses the records to work out the structure of each frame and Summarise()
+// writes a summary of that structure.
+package signallog
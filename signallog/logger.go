@@ -0,0 +1,204 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package signallog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// header is the first few bytes of every log file
+const header = "GOPHER2600SIGNALS\x00"
+
+// the bits of the state byte in each record
+const (
+	stateVSync = 0x01 << iota
+	stateVBlank
+	stateCBurst
+	stateHSync
+
+	// not a signal attribute. indicates that the record contains a
+	// specification ID
+	stateSpec = 0x80
+)
+
+// Logger implements the television.Television interface. Signals are passed
+// on to the embedded television and changes to the HSYNC, VSYNC, VBLANK and
+// CBURST attributes are written to the log file.
+type Logger struct {
+	television.Television
+
+	f *os.File
+	w *bufio.Writer
+
+	// the number of color clocks since the previous record
+	clocks uint64
+
+	// the state of the signal attributes at the previous record. initialised
+	// to a value that can not otherwise occur so that the first signal is
+	// always recorded
+	state byte
+
+	// the specification at the previous record
+	spec *television.Specification
+
+	filename string
+}
+
+// New is the preferred method of initialisation for the Logger type. The log
+// file is created immediately.
+func New(tv television.Television, filename string) (*Logger, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, errors.New(errors.SignalLog, err)
+	}
+
+	lg := &Logger{
+		Television: tv,
+		f:          f,
+		w:          bufio.NewWriter(f),
+		state:      0xff,
+		filename:   filename,
+	}
+
+	_, err = lg.w.WriteString(header)
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.New(errors.SignalLog, err)
+	}
+
+	return lg, nil
+}
+
+// UniqueFilename returns a filename that is very likely to be unique. The
+// cartridge name is included in the filename.
+func UniqueFilename(cartName string) string {
+	n := time.Now()
+	return fmt.Sprintf("signals_%s_%04d%02d%02d_%02d%02d%02d.log",
+		cartName, n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second())
+}
+
+// Filename returns the name of the log file
+func (lg *Logger) Filename() string {
+	return lg.filename
+}
+
+// Signal implements the television.Television interface
+func (lg *Logger) Signal(sig television.SignalAttributes) error {
+	err := lg.Television.Signal(sig)
+	if err != nil {
+		return err
+	}
+
+	if lg.w == nil {
+		return nil
+	}
+
+	var state byte
+	if sig.VSync {
+		state |= stateVSync
+	}
+	if sig.VBlank {
+		state |= stateVBlank
+	}
+	if sig.CBurst {
+		state |= stateCBurst
+	}
+	if sig.HSync {
+		state |= stateHSync
+	}
+
+	if state == lg.state {
+		lg.clocks++
+		return nil
+	}
+
+	err = lg.write(state)
+	if err != nil {
+		return errors.New(errors.SignalLog, err)
+	}
+
+	lg.state = state
+	lg.clocks = 1
+
+	return nil
+}
+
+// write record for the new state
+func (lg *Logger) write(state byte) error {
+	var b [binary.MaxVarintLen64]byte
+
+	n := binary.PutUvarint(b[:], lg.clocks)
+	if _, err := lg.w.Write(b[:n]); err != nil {
+		return err
+	}
+
+	// the specification is only checked when the signal changes. a change of
+	// specification will be recorded at the next change of signal
+	spec := lg.GetSpec()
+	if spec != lg.spec {
+		state |= stateSpec
+	}
+
+	if err := lg.w.WriteByte(state); err != nil {
+		return err
+	}
+
+	if spec != lg.spec {
+		n = binary.PutUvarint(b[:], uint64(len(spec.ID)))
+		if _, err := lg.w.Write(b[:n]); err != nil {
+			return err
+		}
+		if _, err := lg.w.WriteString(spec.ID); err != nil {
+			return err
+		}
+		lg.spec = spec
+	}
+
+	return nil
+}
+
+// Close the log file. Signals will continue to be passed to the television
+// but will no longer be logged. The television itself is not ended.
+func (lg *Logger) Close() error {
+	if lg.w == nil {
+		return nil
+	}
+
+	err := lg.w.Flush()
+	lg.w = nil
+	if err != nil {
+		_ = lg.f.Close()
+		return errors.New(errors.SignalLog, err)
+	}
+
+	err = lg.f.Close()
+	if err != nil {
+		return errors.New(errors.SignalLog, err)
+	}
+
+	return nil
+}
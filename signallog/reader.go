@@ -0,0 +1,131 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package signallog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/jetsetilly/gopher2600/errors"
+)
+
+// the longest specification ID we'll accept. anything longer indicates a
+// corrupt log
+const maxSpecIDLen = 16
+
+// Record is a single entry in the signal log
+type Record struct {
+	// the number of color clocks since the start of the log
+	Clock uint64
+
+	VSync  bool
+	VBlank bool
+	CBurst bool
+	HSync  bool
+
+	// the television specification. empty unless the specification has
+	// changed since the previous record
+	Spec string
+}
+
+func (rec Record) String() string {
+	s := fmt.Sprintf("%010d", rec.Clock)
+	for _, a := range []struct {
+		on   bool
+		name string
+	}{
+		{rec.VSync, "VSYNC"},
+		{rec.VBlank, "VBLANK"},
+		{rec.CBurst, "CBURST"},
+		{rec.HSync, "HSYNC"},
+	} {
+		if a.on {
+			s = fmt.Sprintf("%s %s", s, a.name)
+		}
+	}
+	if rec.Spec != "" {
+		s = fmt.Sprintf("%s [%s]", s, rec.Spec)
+	}
+	return s
+}
+
+// Reader reads the records in a signal log
+type Reader struct {
+	r     *bufio.Reader
+	clock uint64
+}
+
+// NewReader is the preferred method of initialisation for the Reader type.
+// Returns an error if the header of the signal log is not correct.
+func NewReader(r io.Reader) (*Reader, error) {
+	rdr := &Reader{r: bufio.NewReader(r)}
+
+	h := make([]byte, len(header))
+	_, err := io.ReadFull(rdr.r, h)
+	if err != nil || string(h) != header {
+		return nil, errors.New(errors.SignalLog, "not a signal log")
+	}
+
+	return rdr, nil
+}
+
+// Next returns the next record in the log. Returns io.EOF when there are no
+// more records.
+func (rdr *Reader) Next() (Record, error) {
+	clocks, err := binary.ReadUvarint(rdr.r)
+	if err != nil {
+		if err == io.EOF {
+			return Record{}, io.EOF
+		}
+		return Record{}, errors.New(errors.SignalLog, err)
+	}
+
+	state, err := rdr.r.ReadByte()
+	if err != nil {
+		return Record{}, errors.New(errors.SignalLog, "truncated record")
+	}
+
+	rdr.clock += clocks
+
+	rec := Record{
+		Clock:  rdr.clock,
+		VSync:  state&stateVSync == stateVSync,
+		VBlank: state&stateVBlank == stateVBlank,
+		CBurst: state&stateCBurst == stateCBurst,
+		HSync:  state&stateHSync == stateHSync,
+	}
+
+	if state&stateSpec == stateSpec {
+		l, err := binary.ReadUvarint(rdr.r)
+		if err != nil || l > maxSpecIDLen {
+			return Record{}, errors.New(errors.SignalLog, "truncated record")
+		}
+		id := make([]byte, l)
+		_, err = io.ReadFull(rdr.r, id)
+		if err != nil {
+			return Record{}, errors.New(errors.SignalLog, "truncated record")
+		}
+		rec.Spec = string(id)
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,147 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package signallog_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/signallog"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// send frames to the television with the specified number of VBLANK and
+// visible scanlines. the remaining scanlines are overscan
+func signalFrames(t *testing.T, tv television.Television, frames int, vblank int, visible int) {
+	t.Helper()
+
+	for f := 0; f < frames; f++ {
+		for sl := 0; sl < tv.GetSpec().ScanlinesTotal; sl++ {
+			for hp := 0; hp < television.HorizClksScanline; hp++ {
+				err := tv.Signal(television.SignalAttributes{
+					VSync:  sl < 3,
+					VBlank: sl < 3+vblank || sl >= 3+vblank+visible,
+					HSync:  hp >= 16 && hp < 32,
+					CBurst: hp >= 36 && hp < 44,
+				})
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+		}
+	}
+}
+
+func TestSignalLog(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	dir, err := ioutil.TempDir("", "signallog")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "test.log")
+
+	lg, err := signallog.New(tv, fn)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	signalFrames(t, lg, 5, 37, 192)
+	signalFrames(t, lg, 5, 40, 180)
+
+	err = lg.Close()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the log only contains changes to the signal
+	rdr, err := signallog.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	rec, err := rdr.Next()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if rec.Clock != 0 || !rec.VSync || !rec.VBlank || rec.Spec != "NTSC" {
+		t.Errorf("unexpected first record (%s)", rec)
+	}
+	rec, err = rdr.Next()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if rec.Clock != 16 || !rec.HSync || rec.Spec != "" {
+		t.Errorf("unexpected second record (%s)", rec)
+	}
+
+	// the first frame begins at the first VSYNC but the frame in progress at
+	// the end of the log is incomplete
+	frames, err := signallog.Analyse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(frames) != 9 {
+		t.Fatalf("expected 9 frames (got %d)", len(frames))
+	}
+
+	expected := signallog.FrameStructure{Spec: "NTSC", VSync: 3, VBlank: 37, Visible: 192, Overscan: 30, Total: 262}
+	if frames[0] != expected {
+		t.Errorf("unexpected frame structure (%s)", frames[0])
+	}
+	if len(frames[0].Deviations()) != 0 {
+		t.Errorf("unexpected deviations (%v)", frames[0].Deviations())
+	}
+
+	expected = signallog.FrameStructure{Spec: "NTSC", VSync: 3, VBlank: 40, Visible: 180, Overscan: 39, Total: 262}
+	if frames[8] != expected {
+		t.Errorf("unexpected frame structure (%s)", frames[8])
+	}
+	if len(frames[8].Deviations()) != 3 {
+		t.Errorf("unexpected deviations (%v)", frames[8].Deviations())
+	}
+
+	out := &strings.Builder{}
+	err = signallog.Summarise(out, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !strings.Contains(out.String(), "9 complete frames, 4 deviate") {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+
+	_, err = signallog.NewReader(strings.NewReader("not a log"))
+	if err == nil {
+		t.Errorf("expected error reading a file that is not a signal log")
+	}
+}
@@ -0,0 +1,232 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package signallog
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// FrameStructure describes the scanlines of a single frame, as found in the
+// signal log. A frame begins with the first scanline in which VSYNC is on.
+//
+// A scanline begins at the start of the HSYNC signal. The state of the signal
+// at that moment decides what sort of scanline it is. Scanlines with VBLANK
+// off are visible. Scanlines with VBLANK on are counted as either VBLANK or
+// overscan, depending on whether they appear before or after the first visible
+// scanline.
+type FrameStructure struct {
+	// the television specification when the frame began
+	Spec string
+
+	VSync    int
+	VBlank   int
+	Visible  int
+	Overscan int
+	Total    int
+
+	// the number of scanlines that were not HorizClksScanline color clocks
+	// long. usually the result of writing to RSYNC
+	Irregular int
+}
+
+func (fs FrameStructure) String() string {
+	s := fmt.Sprintf("vsync %d, vblank %d, visible %d, overscan %d, total %d",
+		fs.VSync, fs.VBlank, fs.Visible, fs.Overscan, fs.Total)
+	if fs.Irregular > 0 {
+		s = fmt.Sprintf("%s, irregular %d", s, fs.Irregular)
+	}
+	return s
+}
+
+// Deviations returns a description of how the frame differs from the
+// frame recommended by the television specification. Returns nil if the frame
+// does not deviate or if the specification is not known.
+func (fs FrameStructure) Deviations() []string {
+	spec, ok := television.GetSpecByID(fs.Spec)
+	if !ok {
+		return nil
+	}
+
+	var dev []string
+
+	for _, c := range []struct {
+		name     string
+		got      int
+		expected int
+	}{
+		{"vsync", fs.VSync, spec.ScanlinesVSync},
+		{"vblank", fs.VBlank, spec.ScanlineTop - spec.ScanlinesVSync},
+		{"visible", fs.Visible, spec.ScanlinesVisible},
+		{"overscan", fs.Overscan, spec.ScanlinesOverscan},
+		{"total", fs.Total, spec.ScanlinesTotal},
+	} {
+		if c.got != c.expected {
+			dev = append(dev, fmt.Sprintf("%s scanlines: %d (expected %d)", c.name, c.got, c.expected))
+		}
+	}
+
+	if fs.Irregular > 0 {
+		dev = append(dev, fmt.Sprintf("irregular scanlines: %d (expected 0)", fs.Irregular))
+	}
+
+	return dev
+}
+
+// analyser builds the list of frame structures from the records in a log
+type analyser struct {
+	frames []FrameStructure
+
+	// the frame currently being built. nil until the first VSYNC
+	frame *FrameStructure
+
+	// the most recent record and specification
+	last Record
+	spec string
+
+	// the clock and signal state at the start of the current scanline
+	lineStarted bool
+	lineStart   uint64
+	lineState   Record
+
+	// whether the previous scanline was a VSYNC scanline
+	vsync bool
+
+	// whether a visible scanline has been seen in the current frame
+	visible bool
+}
+
+func (an *analyser) record(rec Record) {
+	if rec.Spec != "" {
+		an.spec = rec.Spec
+	}
+
+	if rec.HSync && !an.last.HSync {
+		if an.lineStarted {
+			an.endLine(rec.Clock - an.lineStart)
+		}
+		an.lineStarted = true
+		an.lineStart = rec.Clock
+		an.lineState = rec
+	}
+
+	an.last = rec
+}
+
+func (an *analyser) endLine(length uint64) {
+	st := an.lineState
+
+	if st.VSync && !an.vsync {
+		if an.frame != nil {
+			an.frames = append(an.frames, *an.frame)
+		}
+		an.frame = &FrameStructure{Spec: an.spec}
+		an.visible = false
+	}
+	an.vsync = st.VSync
+
+	// scanlines before the first VSYNC are not part of a complete frame
+	if an.frame == nil {
+		return
+	}
+
+	an.frame.Total++
+	if length != television.HorizClksScanline {
+		an.frame.Irregular++
+	}
+
+	switch {
+	case st.VSync:
+		an.frame.VSync++
+	case !st.VBlank:
+		an.frame.Visible++
+		an.visible = true
+	case an.visible:
+		an.frame.Overscan++
+	default:
+		an.frame.VBlank++
+	}
+}
+
+// Analyse the signal log and return the structure of every complete frame.
+// Scanlines before the first VSYNC and the frame that is incomplete at the
+// end of the log are not included.
+func Analyse(r io.Reader) ([]FrameStructure, error) {
+	rdr, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	an := &analyser{}
+
+	for {
+		rec, err := rdr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		an.record(rec)
+	}
+
+	return an.frames, nil
+}
+
+// Summarise the structure of the frames in the signal log. Consecutive frames
+// with the same structure are summarised together.
+func Summarise(output io.Writer, r io.Reader) error {
+	frames, err := Analyse(r)
+	if err != nil {
+		return err
+	}
+
+	deviating := 0
+
+	for i := 0; i < len(frames); {
+		j := i + 1
+		for j < len(frames) && frames[j] == frames[i] {
+			j++
+		}
+
+		if j-i == 1 {
+			output.Write([]byte(fmt.Sprintf("frame %d", i)))
+		} else {
+			output.Write([]byte(fmt.Sprintf("frames %d-%d (%d)", i, j-1, j-i)))
+		}
+		output.Write([]byte(fmt.Sprintf(" %s: %s\n", frames[i].Spec, frames[i])))
+
+		dev := frames[i].Deviations()
+		for _, d := range dev {
+			output.Write([]byte(fmt.Sprintf("\t%s\n", d)))
+		}
+		if len(dev) > 0 {
+			deviating += j - i
+		}
+
+		i = j
+	}
+
+	output.Write([]byte(fmt.Sprintf("%d complete frames, %d deviate from the specification\n", len(frames), deviating)))
+
+	return nil
+}
@@ -30,4 +30,18 @@
 //
 // ReadSymbolFile() will always give addresses the default or canonised symbol.
 // In this way it is a superset of the NewTable() function.
+//
+// Symbols files in several formats are supported. See the symbolsReaders
+// array for the list. Symbols can apply to every cartridge bank or to a single
+// bank. The Get() function of each sub-table will find the correct symbol for
+// an address in a specific bank.
+//
+// Symbols added with AddSymbol() and AddBankSymbol() are user-defined symbols.
+// They can be saved with WriteUserSymbols() and are read automatically by
+// ReadSymbolsFile().
+//
+// ReadSymbolsFile() will also read the listing file for the cartridge, if one
+// exists. The source lines in the listing are available through the Source
+// field of the Table type. Source lines can also be read directly with the
+// ReadListingFile() function.
 package symbols
@@ -22,11 +22,8 @@ package symbols
 import (
 	"fmt"
 	"io/ioutil"
-	"os"
 	"path"
-	"strconv"
 	"strings"
-	"unicode"
 
 	"github.com/jetsetilly/gopher2600/errors"
 )
@@ -38,7 +35,10 @@ import (
 // if the symbols file cannot be opened the symbols file will still contain the
 // canonical vcs symbols file
 //
-// Currently, only symbols files generated by DASM are supported
+// The supported symbols file formats are listed in the symbolsReaders array.
+// The first file found alongside the cartridge file is used. Symbols saved
+// with WriteUserSymbols() are also read and are preferred to all other
+// symbols.
 func ReadSymbolsFile(cartridgeFilename string) (*Table, error) {
 	tbl := &Table{
 		Locations: newTable(),
@@ -46,78 +46,54 @@ func ReadSymbolsFile(cartridgeFilename string) (*Table, error) {
 		Write:     newTable(),
 	}
 
-	// prefer default symbol for an address over any symbol that has been
-	// specified in the symbols file
-	//
-	// deferred function because we want to do this in all instances, even if
-	// there is an error with the symbols file.
-	defer tbl.canoniseTable(true)
-
 	// if this is the empty cartridge then this error is expected. return
 	// the empty symbol table
 	if cartridgeFilename == "" {
+		tbl.canoniseTable(true)
 		return tbl, nil
 	}
 
-	// try to open symbols file
-	symFilename := cartridgeFilename
-	ext := path.Ext(symFilename)
+	// the listing file is optional. if it cannot be read then source level
+	// information is simply not available
+	tbl.Source, _ = ReadListingFile(cartridgeFilename)
 
-	// try to figure out the case of the file extension
-	if ext == ".BIN" {
-		symFilename = fmt.Sprintf("%s.SYM", symFilename[:len(symFilename)-len(ext)])
-	} else {
-		symFilename = fmt.Sprintf("%s.sym", symFilename[:len(symFilename)-len(ext)])
-	}
+	err := tbl.readSymbols(cartridgeFilename)
 
-	sf, err := os.Open(symFilename)
-	if err != nil {
-		return tbl, errors.New(errors.SymbolsFileUnavailable, cartridgeFilename)
-	}
-	defer func() {
-		_ = sf.Close()
-	}()
+	// prefer default symbol for an address over any symbol that has been
+	// specified in the symbols file. we want to do this in all instances, even
+	// if there is an error with the symbols file.
+	tbl.canoniseTable(true)
 
-	sym, err := ioutil.ReadAll(sf)
-	if err != nil {
-		return nil, errors.New(errors.SymbolsFileError, err)
+	// user symbols are preferred over the canonical symbols
+	if uerr := tbl.readUserSymbols(cartridgeFilename); uerr != nil && err == nil {
+		err = uerr
 	}
-	lines := strings.Split(string(sym), "\n")
 
-	// find interesting lines in the symbols file and add to the Table
-	// instance.
-	for _, ln := range lines {
-		// ignore uninteresting lines
-		p := strings.Fields(ln)
-		if len(p) < 2 || p[0] == "---" {
-			continue // for loop
-		}
+	return tbl, err
+}
 
-		// get address
-		address, err := strconv.ParseUint(p[1], 16, 16)
+// readSymbols uses the first symbols file that can be found for the
+// cartridge.
+func (tbl *Table) readSymbols(cartridgeFilename string) error {
+	for _, r := range symbolsReaders {
+		sym, err := ioutil.ReadFile(companionFilename(cartridgeFilename, r.ext))
 		if err != nil {
 			continue // for loop
 		}
 
-		// get symbol
-		symbol := p[0]
-
-		// differentiate between location and other symbols. this is a little
-		// heavy handed, but still, it's better than nothing.
-		if unicode.IsDigit(rune(symbol[0])) {
-			// if symbol begins with a number and a period then it is a location symbol
-			i := strings.Index(symbol, ".")
-			if i != -1 {
-				tbl.Locations.add(uint16(address), symbol[i:], false)
-			}
-		} else {
-			// every non-location symbols is both a read and write symbol.
-			// compar to canonical vcs symbols which are specific to a read or
-			// write context
-			tbl.Read.add(uint16(address), symbol, false)
-			tbl.Write.add(uint16(address), symbol, false)
-		}
+		return r.read(tbl, strings.Split(string(sym), "\n"))
 	}
 
-	return tbl, nil
+	return errors.New(errors.SymbolsFileUnavailable, cartridgeFilename)
+}
+
+// companionFilename returns the name of a file that accompanies the cartridge
+// file, differing only by the extension. the case of the extension follows the
+// case of the cartridge file's extension.
+func companionFilename(cartridgeFilename string, ext string) string {
+	cext := path.Ext(cartridgeFilename)
+	if cext == ".BIN" {
+		ext = strings.ToUpper(ext)
+	}
+	return fmt.Sprintf("%s%s", cartridgeFilename[:len(cartridgeFilename)-len(cext)], ext)
 }
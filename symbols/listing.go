@@ -0,0 +1,237 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+)
+
+// SourceLine is a single line of source code as found in the listing file.
+type SourceLine struct {
+	File string
+	Line int
+
+	// the text of the source line, including any comment. leading and
+	// trailing space is removed
+	Text string
+
+	// the address of the line and the bytes generated by the line. Bytes will
+	// be empty if the line generates no code or data, in which case Address
+	// should be ignored
+	Address uint16
+	Bytes   []uint8
+}
+
+func (sl SourceLine) String() string {
+	return fmt.Sprintf("%s:%d", sl.File, sl.Line)
+}
+
+// Listing is the source code of a cartridge, as found in the listing file
+// produced by the assembler.
+type Listing struct {
+	// the source files in the order in which they first appear in the listing
+	Files []string
+
+	// the lines of each source file in listing order
+	lines map[string][]*SourceLine
+
+	// lines that generate code or data in cartridge space, indexed by address.
+	// addresses are masked with memorymap.AddressMaskCart. for cartridges
+	// with more than one bank an address will occur more than once
+	addresses map[uint16][]*SourceLine
+}
+
+// the start of the line that introduces a new source file. for example:
+//
+//	------- FILE kernel.asm LEVEL 1 PASS 2
+const listingFile = "------- FILE "
+
+// line number, optional uninitialised segment flag and address. the address
+// has five digits when it is not yet known
+var listingLine = regexp.MustCompile(`^\s*(\d+)\s+(U?)([0-9a-fA-F]{4,5})(.*)$`)
+
+// bytes generated by the line. DASM lists at most four bytes and indicates
+// more with an asterisk
+var listingBytes = regexp.MustCompile(`^((?:\s+[0-9a-f]{2})*)\*?(\s.*)?$`)
+
+// ReadListingFile reads the listing file for the specified cartridge.
+//
+// Currently, only listing files generated by DASM are supported.
+func ReadListingFile(cartridgeFilename string) (*Listing, error) {
+	lstFilename := companionFilename(cartridgeFilename, ".lst")
+
+	lf, err := os.Open(lstFilename)
+	if err != nil {
+		return nil, errors.New(errors.ListingFileUnavailable, cartridgeFilename)
+	}
+	defer func() {
+		_ = lf.Close()
+	}()
+
+	lst, err := ioutil.ReadAll(lf)
+	if err != nil {
+		return nil, errors.New(errors.ListingFileError, err)
+	}
+
+	return parseListing(string(lst)), nil
+}
+
+func parseListing(lst string) *Listing {
+	l := &Listing{
+		lines:     make(map[string][]*SourceLine),
+		addresses: make(map[uint16][]*SourceLine),
+	}
+
+	file := ""
+
+	for _, ln := range strings.Split(lst, "\n") {
+		ln = strings.TrimRight(ln, "\r")
+
+		// change of source file
+		if strings.HasPrefix(ln, listingFile) {
+			f := strings.Fields(ln[len(listingFile):])
+			if len(f) > 0 {
+				file = f[0]
+				if _, ok := l.lines[file]; !ok {
+					l.Files = append(l.Files, file)
+				}
+			}
+			continue // for loop
+		}
+
+		m := listingLine.FindStringSubmatch(ln)
+		if m == nil || file == "" {
+			continue // for loop
+		}
+
+		sl := &SourceLine{File: file}
+
+		sl.Line, _ = strconv.Atoi(m[1])
+
+		// the address is not known if it is followed by question marks
+		rest := m[4]
+		known := true
+		if strings.HasPrefix(strings.TrimLeft(rest, " \t"), "????") {
+			rest = strings.TrimLeft(rest, " \t")[4:]
+			known = false
+		}
+
+		address, err := strconv.ParseUint(m[3], 16, 16)
+		known = known && err == nil
+
+		// separate generated bytes from the source text
+		if b := listingBytes.FindStringSubmatch(rest); b != nil {
+			for _, v := range strings.Fields(b[1]) {
+				v, _ := strconv.ParseUint(v, 16, 8)
+				sl.Bytes = append(sl.Bytes, uint8(v))
+			}
+			rest = b[2]
+		}
+
+		sl.Text = strings.TrimSpace(rest)
+
+		// bytes in an uninitialised segment or at an unknown address are of
+		// no interest
+		if !known || m[2] == "U" {
+			sl.Bytes = nil
+		}
+
+		l.lines[file] = append(l.lines[file], sl)
+
+		if len(sl.Bytes) > 0 {
+			sl.Address = uint16(address)
+			if memorymap.IsArea(sl.Address, memorymap.Cartridge) {
+				a := sl.Address & memorymap.AddressMaskCart
+				l.addresses[a] = append(l.addresses[a], sl)
+			}
+		}
+	}
+
+	return l
+}
+
+// LinesAtAddress returns every line that generates code or data at the
+// address. there will be more than one line if the address is used in more
+// than one cartridge bank.
+func (l *Listing) LinesAtAddress(address uint16) []*SourceLine {
+	return l.addresses[address&memorymap.AddressMaskCart]
+}
+
+// normalise filename to the form it takes in the listing. the filename
+// matches if it is the same as the file named in the listing or the same as
+// the base of that filename.
+func (l *Listing) normaliseFile(file string) (string, bool) {
+	if _, ok := l.lines[file]; ok {
+		return file, true
+	}
+	for _, f := range l.Files {
+		if filepath.Base(f) == file {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// FindLine returns the first line at or after the line number in the source
+// file that generates code or data in cartridge space.
+func (l *Listing) FindLine(file string, line int) (*SourceLine, error) {
+	f, ok := l.normaliseFile(file)
+	if !ok {
+		return nil, errors.New(errors.SymbolsError, fmt.Sprintf("no source file named %s", file))
+	}
+
+	for _, sl := range l.lines[f] {
+		if sl.Line >= line && len(sl.Bytes) > 0 && memorymap.IsArea(sl.Address, memorymap.Cartridge) {
+			return sl, nil
+		}
+	}
+
+	return nil, errors.New(errors.SymbolsError, fmt.Sprintf("no code at or after %s:%d", file, line))
+}
+
+// Context returns the lines surrounding the source line in the same source
+// file. the number of lines before and after the source line is specified by
+// the count argument.
+func (l *Listing) Context(sl *SourceLine, count int) []*SourceLine {
+	lines := l.lines[sl.File]
+	for i, s := range lines {
+		if s == sl {
+			from := i - count
+			if from < 0 {
+				from = 0
+			}
+			to := i + count + 1
+			if to > len(lines) {
+				to = len(lines)
+			}
+			return lines[from:to]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/symbols"
+)
+
+func TestListing(t *testing.T) {
+	lst, err := symbols.ReadListingFile("testdata/source.bin")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	if len(lst.Files) != 2 || lst.Files[0] != "source.asm" || lst.Files[1] != "vcs.h" {
+		t.Errorf("unexpected list of source files (%v)", lst.Files)
+	}
+
+	// RAM addresses are not mapped to source lines
+	if l := lst.LinesAtAddress(0x0080); len(l) != 0 {
+		t.Errorf("unexpected source line for RAM address (%s)", l[0])
+	}
+
+	l := lst.LinesAtAddress(0xf004)
+	if len(l) != 1 {
+		t.Fatalf("expected one source line for address 0xf004 (found %d)", len(l))
+	}
+	if l[0].String() != "source.asm:14" {
+		t.Errorf("unexpected source line for address 0xf004 (%s)", l[0])
+	}
+	if l[0].Text != "inc\tcounter\t; next frame" {
+		t.Errorf("unexpected source text for address 0xf004 (%q)", l[0].Text)
+	}
+	if len(l[0].Bytes) != 2 || l[0].Bytes[0] != 0xe6 || l[0].Bytes[1] != 0x80 {
+		t.Errorf("unexpected bytes for address 0xf004 (%v)", l[0].Bytes)
+	}
+
+	// address mirrors are mapped to the same source line
+	if m := lst.LinesAtAddress(0x1008); len(m) != 1 || m[0].String() != "source.asm:16" {
+		t.Errorf("unexpected source line for address 0x1008")
+	}
+
+	// source lines that generate no code resolve to the next line that does
+	sl, err := lst.FindLine("source.asm", 13)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if sl.Address != 0xf004 {
+		t.Errorf("unexpected address for source.asm:13 (%#04x)", sl.Address)
+	}
+
+	if _, err := lst.FindLine("source.asm", 20); err == nil {
+		t.Errorf("expected error for source line with no following code")
+	}
+
+	if _, err := lst.FindLine("kernel.asm", 1); err == nil {
+		t.Errorf("expected error for unknown source file")
+	}
+
+	c := lst.Context(sl, 2)
+	if len(c) != 5 || c[0].Line != 12 || c[4].Line != 16 {
+		t.Errorf("unexpected context for source.asm:14")
+	}
+}
+
+func TestListingWithSymbols(t *testing.T) {
+	syms, _ := symbols.ReadSymbolsFile("testdata/source.bin")
+	if syms.Source == nil {
+		t.Errorf("expected listing to be read alongside symbols")
+	}
+
+	syms, _ = symbols.ReadSymbolsFile("testdata/flappy.bin")
+	if syms.Source != nil {
+		t.Errorf("unexpected listing for cartridge with no listing file")
+	}
+}
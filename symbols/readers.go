@@ -0,0 +1,244 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/hardware/memory/memorymap"
+)
+
+// symbolsReader adds the symbols in a symbols file to the table. the symbols
+// file has already been split into lines. symbols should not be preferred
+// over any existing symbol.
+type symbolsReader func(tbl *Table, lines []string) error
+
+// the supported symbols file formats, in order of preference. a new format
+// can be supported by adding a reader to this list.
+var symbolsReaders = []struct {
+	ext  string
+	read symbolsReader
+}{
+	// DASM symbols file. Stella exports symbols in the same format
+	{ext: ".sym", read: readDASM},
+
+	// ld65 debug file (created with the --dbgfile option)
+	{ext: ".dbg", read: readCA65},
+
+	// VICE style labels file (created by ld65 with the -Ln option)
+	{ext: ".lbl", read: readLabels},
+}
+
+// the size of cartridge banks assumed by readers that can determine the bank
+// of a symbol from its offset in the ROM file
+const bankSize = 4096
+
+// add symbol for a labelled address. the table(s) the symbol is added to
+// depends on whether the address is in cartridge space.
+func (tbl *Table) addLabel(bank int, address uint16, symbol string) {
+	if memorymap.IsArea(address, memorymap.Cartridge) {
+		tbl.Locations.addBanked(bank, address, symbol, false)
+		tbl.Read.addBanked(bank, address, symbol, false)
+	} else {
+		tbl.Read.addBanked(bank, address, symbol, false)
+		tbl.Write.addBanked(bank, address, symbol, false)
+	}
+}
+
+func readDASM(tbl *Table, lines []string) error {
+	// find interesting lines in the symbols file and add to the Table
+	// instance.
+	for _, ln := range lines {
+		// ignore uninteresting lines
+		p := strings.Fields(ln)
+		if len(p) < 2 || p[0] == "---" {
+			continue // for loop
+		}
+
+		// get address
+		address, err := strconv.ParseUint(p[1], 16, 16)
+		if err != nil {
+			continue // for loop
+		}
+
+		// get symbol
+		symbol := p[0]
+
+		// differentiate between location and other symbols. this is a little
+		// heavy handed, but still, it's better than nothing.
+		if unicode.IsDigit(rune(symbol[0])) {
+			// if symbol begins with a number and a period then it is a location symbol
+			i := strings.Index(symbol, ".")
+			if i != -1 {
+				tbl.Locations.add(uint16(address), symbol[i:], false)
+			}
+		} else {
+			// every non-location symbols is both a read and write symbol.
+			// compar to canonical vcs symbols which are specific to a read or
+			// write context
+			tbl.Read.add(uint16(address), symbol, false)
+			tbl.Write.add(uint16(address), symbol, false)
+		}
+	}
+
+	return nil
+}
+
+// parse the fields of a line in an ld65 debug file. for example:
+//
+//	sym	id=0,name="Reset",addrsize=absolute,scope=0,def=1,val=0xF000,seg=0,type=lab
+//
+// returns the type of line and a map of the key/value pairs. quotes are
+// removed from string values.
+func parseCA65Line(ln string) (string, map[string]string) {
+	p := strings.SplitN(strings.TrimSpace(ln), "\t", 2)
+	if len(p) != 2 {
+		return "", nil
+	}
+
+	fields := make(map[string]string)
+
+	quoted := false
+	start := 0
+	s := p[1] + ","
+	for i, c := range s {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				kv := strings.SplitN(s[start:i], "=", 2)
+				if len(kv) == 2 {
+					fields[kv[0]] = strings.Trim(kv[1], "\"")
+				}
+				start = i + 1
+			}
+		}
+	}
+
+	return p[0], fields
+}
+
+func readCA65(tbl *Table, lines []string) error {
+	// segments that have been written to the ROM file. indexed by segment ID
+	type segment struct {
+		start uint64
+		ooffs uint64
+	}
+	segs := make(map[string]segment)
+
+	// the size of the ROM file as far as we can tell from the list of segments
+	romSize := uint64(0)
+
+	for _, ln := range lines {
+		t, f := parseCA65Line(ln)
+		if t != "seg" {
+			continue // for loop
+		}
+
+		// segments without an output offset are not in the ROM file
+		if _, ok := f["ooffs"]; !ok {
+			continue // for loop
+		}
+
+		start, err := strconv.ParseUint(f["start"], 0, 32)
+		if err != nil {
+			return errors.New(errors.SymbolsFileError, fmt.Sprintf("invalid segment start (%s)", f["start"]))
+		}
+		size, err := strconv.ParseUint(f["size"], 0, 32)
+		if err != nil {
+			return errors.New(errors.SymbolsFileError, fmt.Sprintf("invalid segment size (%s)", f["size"]))
+		}
+		ooffs, err := strconv.ParseUint(f["ooffs"], 0, 32)
+		if err != nil {
+			return errors.New(errors.SymbolsFileError, fmt.Sprintf("invalid segment offset (%s)", f["ooffs"]))
+		}
+
+		segs[f["id"]] = segment{start: start, ooffs: ooffs}
+		if ooffs+size > romSize {
+			romSize = ooffs + size
+		}
+	}
+
+	for _, ln := range lines {
+		t, f := parseCA65Line(ln)
+		if t != "sym" {
+			continue // for loop
+		}
+
+		// imports are duplicates of exports and symbols beginning with two
+		// underscores are created by the linker
+		symbol := f["name"]
+		if f["type"] == "imp" || symbol == "" || strings.HasPrefix(symbol, "__") {
+			continue // for loop
+		}
+
+		val, err := strconv.ParseUint(f["val"], 0, 32)
+		if err != nil {
+			continue // for loop
+		}
+		address := uint16(val)
+
+		// labels in segments that have been written to the ROM file. for
+		// cartridges larger than a single bank, the offset in the ROM file
+		// tells us the bank
+		if seg, ok := segs[f["seg"]]; ok && f["type"] == "lab" {
+			bank := AllBanks
+			if romSize > bankSize && val >= seg.start {
+				bank = int((seg.ooffs + val - seg.start) / bankSize)
+			}
+			tbl.addLabel(bank, address, symbol)
+			continue // for loop
+		}
+
+		tbl.Read.add(address, symbol, false)
+		tbl.Write.add(address, symbol, false)
+	}
+
+	return nil
+}
+
+// each line of a VICE style labels file is of the form:
+//
+//	al 00F000 .Reset
+func readLabels(tbl *Table, lines []string) error {
+	for _, ln := range lines {
+		p := strings.Fields(ln)
+		if len(p) < 3 || p[0] != "al" {
+			continue // for loop
+		}
+
+		// VICE includes a memory space prefix
+		a := strings.TrimPrefix(p[1], "C:")
+
+		address, err := strconv.ParseUint(a, 16, 32)
+		if err != nil {
+			continue // for loop
+		}
+
+		tbl.addLabel(AllBanks, uint16(address), strings.TrimPrefix(p[2], "."))
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/symbols"
+)
+
+func TestCA65Symbols(t *testing.T) {
+	syms, err := symbols.ReadSymbolsFile("testdata/banked.bin")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	if s, ok := syms.Read.Get(symbols.AllBanks, 0x80); !ok || s != "lives" {
+		t.Errorf("expected lives symbol for address 0x80")
+	}
+	if s, ok := syms.Read.Get(symbols.AllBanks, 0xc0); !ok || s != "SCREEN_HEIGHT" {
+		t.Errorf("expected SCREEN_HEIGHT symbol for address 0xc0")
+	}
+
+	// the same address has a different label in each bank
+	if s, ok := syms.Locations.Get(0, 0xf000); !ok || s != "Reset" {
+		t.Errorf("expected Reset location for address 0xf000 in bank 0")
+	}
+	if s, ok := syms.Locations.Get(1, 0xf000); !ok || s != "Kernel" {
+		t.Errorf("expected Kernel location for address 0xf000 in bank 1")
+	}
+
+	// symbols created by the linker are ignored
+	if _, _, _, err := syms.SearchSymbol("__ZEROPAGE_LOAD__", symbols.UnspecifiedSymTable); err == nil {
+		t.Errorf("unexpected linker symbol")
+	}
+}
+
+func TestLabelsSymbols(t *testing.T) {
+	syms, err := symbols.ReadSymbolsFile("testdata/labels.bin")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	if s, ok := syms.Write.Get(0, 0x80); !ok || s != "lives" {
+		t.Errorf("expected lives symbol for address 0x80")
+	}
+	if s, ok := syms.Locations.Get(0, 0xf010); !ok || s != "Loop" {
+		t.Errorf("expected Loop location for address 0xf010")
+	}
+	if _, ok := syms.Write.Get(0, 0xf010); ok {
+		t.Errorf("unexpected write symbol for address 0xf010")
+	}
+}
+
+func TestUserSymbols(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopher2600_symbols")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cartridgeFilename := filepath.Join(dir, "user.bin")
+
+	syms, _ := symbols.ReadSymbolsFile(cartridgeFilename)
+	if syms.NumUserSymbols() != 0 {
+		t.Errorf("unexpected user symbols")
+	}
+
+	_ = syms.AddSymbol(symbols.ReadSymTable, 0x81, "score")
+	_ = syms.AddBankSymbol(symbols.LocationSymTable, 1, 0xf100, "kernel")
+	_ = syms.AddSymbol(symbols.WriteSymTable, 0x82, "temp")
+
+	err = syms.RemoveSymbol(symbols.UnspecifiedSymTable, "TEMP")
+	if err != nil {
+		t.Errorf("unexpected error (%s)", err)
+	}
+	if syms.NumUserSymbols() != 2 {
+		t.Errorf("expected two user symbols (found %d)", syms.NumUserSymbols())
+	}
+
+	err = syms.RemoveSymbol(symbols.UnspecifiedSymTable, "temp")
+	if err == nil {
+		t.Errorf("expected error when removing unknown symbol")
+	}
+
+	filename, err := syms.WriteUserSymbols(cartridgeFilename)
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+	if filepath.Base(filename) != "user.user.sym" {
+		t.Errorf("unexpected user symbols filename (%s)", filename)
+	}
+
+	// user symbols are read alongside the cartridge
+	syms, _ = symbols.ReadSymbolsFile(cartridgeFilename)
+	if syms.NumUserSymbols() != 2 {
+		t.Errorf("expected two user symbols (found %d)", syms.NumUserSymbols())
+	}
+	if s, ok := syms.Read.Get(0, 0x81); !ok || s != "score" {
+		t.Errorf("expected score symbol for address 0x81")
+	}
+	if s, ok := syms.Locations.Get(1, 0xf100); !ok || s != "kernel" {
+		t.Errorf("expected kernel location for address 0xf100 in bank 1")
+	}
+	if _, ok := syms.Locations.Get(0, 0xf100); ok {
+		t.Errorf("unexpected location for address 0xf100 in bank 0")
+	}
+}
+
+func TestSearchBanked(t *testing.T) {
+	syms, err := symbols.ReadSymbolsFile("")
+	if err != nil {
+		t.Fatalf("unexpected error (%s)", err)
+	}
+
+	// the same symbol at a different address in several banks. the lowest
+	// bank is always found
+	_ = syms.AddBankSymbol(symbols.LocationSymTable, 3, 0xf300, "kernel")
+	_ = syms.AddBankSymbol(symbols.LocationSymTable, 1, 0xf100, "kernel")
+	_ = syms.AddBankSymbol(symbols.LocationSymTable, 2, 0xf200, "kernel")
+	for i := 0; i < 10; i++ {
+		_, _, addr, err := syms.SearchSymbol("kernel", symbols.UnspecifiedSymTable)
+		if err != nil {
+			t.Fatalf("unexpected error (%s)", err)
+		}
+		if addr != 0xf100 {
+			t.Fatalf("expected kernel symbol at 0xf100 (found %#04x)", addr)
+		}
+	}
+
+	// a symbol that applies to every bank is preferred
+	_ = syms.AddSymbol(symbols.LocationSymTable, 0xf400, "kernel")
+	if _, _, addr, _ := syms.SearchSymbol("kernel", symbols.UnspecifiedSymTable); addr != 0xf400 {
+		t.Errorf("expected kernel symbol at 0xf400 (found %#04x)", addr)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/jetsetilly/gopher2600/errors"
 	"github.com/jetsetilly/gopher2600/hardware/memory/addresses"
 )
 
@@ -34,6 +35,14 @@ type Table struct {
 	Read      *symTable
 	Write     *symTable
 
+	// source code from the assembler's listing file. will be nil if there is
+	// no listing file
+	Source *Listing
+
+	// symbols added with AddSymbol() or AddBankSymbol() or read from the user
+	// symbols file. these are the symbols saved by WriteUserSymbols()
+	user []userSymbol
+
 	// use max width values to help with formatting
 	MaxLocationWidth int
 	MaxSymbolWidth   int
@@ -48,6 +57,71 @@ func NewTable() *Table {
 	return tbl
 }
 
+// AllBanks indicates that a symbol applies to every cartridge bank.
+const AllBanks = -1
+
+// subTable returns the sub-table for the table type. UnspecifiedSymTable is
+// not a valid table for this function.
+func (tbl *Table) subTable(table TableType) (*symTable, error) {
+	switch table {
+	case LocationSymTable:
+		return tbl.Locations, nil
+	case ReadSymTable:
+		return tbl.Read, nil
+	case WriteSymTable:
+		return tbl.Write, nil
+	}
+	return nil, errors.New(errors.SymbolsError, fmt.Sprintf("no %s table", table))
+}
+
+// AddSymbol adds a user-defined symbol to the specified table, replacing any
+// existing symbol at that address. UnspecifiedSymTable is not a valid table
+// for this function.
+func (tbl *Table) AddSymbol(table TableType, addr uint16, symbol string) error {
+	return tbl.AddBankSymbol(table, AllBanks, addr, symbol)
+}
+
+// AddBankSymbol is the same as AddSymbol but the symbol only applies to the
+// specified cartridge bank. The bank can be AllBanks.
+func (tbl *Table) AddBankSymbol(table TableType, bank int, addr uint16, symbol string) error {
+	t, err := tbl.subTable(table)
+	if err != nil {
+		return errors.New(errors.SymbolsError, fmt.Sprintf("cannot add symbol to %s table", table))
+	}
+
+	t.addBanked(bank, addr, symbol, true)
+	tbl.polishTable()
+	tbl.noteUserSymbol(userSymbol{table: table, bank: bank, address: addr, symbol: symbol})
+
+	return nil
+}
+
+// RemoveSymbol removes the symbol from the specified table. If table is
+// UnspecifiedSymTable then the symbol is removed from all tables. The search
+// for the symbol is case-insensitive.
+func (tbl *Table) RemoveSymbol(table TableType, symbol string) error {
+	symbolUpper := strings.ToUpper(symbol)
+
+	removed := false
+	for _, t := range []TableType{LocationSymTable, ReadSymTable, WriteSymTable} {
+		if table == UnspecifiedSymTable || table == t {
+			st, _ := tbl.subTable(t)
+			if st.remove(symbolUpper) {
+				removed = true
+				tbl.forgetUserSymbol(t, symbolUpper)
+			}
+		}
+	}
+
+	if !removed {
+		return errors.New(errors.SymbolUnknown, symbol)
+	}
+
+	tbl.polishTable()
+
+	return nil
+}
+
 // put canonical symbols into table. prefer flag should be true if canonical
 // names are to supercede any existing symbol.
 func (tbl *Table) canoniseTable(prefer bool) {
@@ -82,7 +156,13 @@ func (tbl *Table) polishTable() {
 }
 
 type symTable struct {
-	Symbols  map[uint16]string
+	Symbols map[uint16]string
+
+	// symbols that apply to only one cartridge bank, indexed by bank number. a
+	// banked symbol takes precedence over the entry in the Symbols map for the
+	// same address
+	Banked map[int]map[uint16]string
+
 	idx      []uint16
 	maxWidth int
 }
@@ -90,6 +170,7 @@ type symTable struct {
 func newTable() *symTable {
 	sym := &symTable{
 		Symbols: make(map[uint16]string),
+		Banked:  make(map[int]map[uint16]string),
 		idx:     make([]uint16, 0),
 	}
 	return sym
@@ -100,9 +181,87 @@ func (sym symTable) String() string {
 	for i := range sym.idx {
 		s.WriteString(fmt.Sprintf("%#04x -> %s\n", sym.idx[i], sym.Symbols[sym.idx[i]]))
 	}
+
+	banks := make([]int, 0, len(sym.Banked))
+	for b := range sym.Banked {
+		banks = append(banks, b)
+	}
+	sort.Ints(banks)
+
+	for _, b := range banks {
+		idx := make([]uint16, 0, len(sym.Banked[b]))
+		for a := range sym.Banked[b] {
+			idx = append(idx, a)
+		}
+		sort.Slice(idx, func(i, j int) bool { return idx[i] < idx[j] })
+		for _, a := range idx {
+			s.WriteString(fmt.Sprintf("%#04x -> %s (bank %d)\n", a, sym.Banked[b][a], b))
+		}
+	}
+
 	return s.String()
 }
 
+// Get returns the symbol for the address in the specified bank. A symbol that
+// applies only to that bank is preferred to a symbol that applies to every
+// bank.
+func (sym symTable) Get(bank int, addr uint16) (string, bool) {
+	if s, ok := sym.Banked[bank][addr]; ok {
+		return s, true
+	}
+	s, ok := sym.Symbols[addr]
+	return s, ok
+}
+
+// add symbol for a single bank. if bank is AllBanks then the symbol is added
+// to the Symbols map in the normal way.
+func (sym *symTable) addBanked(bank int, addr uint16, symbol string, prefer bool) {
+	if bank == AllBanks {
+		sym.add(addr, symbol, prefer)
+		return
+	}
+
+	if _, ok := sym.Banked[bank]; !ok {
+		sym.Banked[bank] = make(map[uint16]string)
+	}
+
+	if _, ok := sym.Banked[bank][addr]; ok && !prefer {
+		return
+	}
+	sym.Banked[bank][addr] = symbol
+
+	if len(symbol) > sym.maxWidth {
+		sym.maxWidth = len(symbol)
+	}
+}
+
+// remove every instance of symbol. symbol should be in upper case. returns
+// true if a symbol was removed.
+func (sym *symTable) remove(symbol string) bool {
+	removed := false
+
+	for i := 0; i < len(sym.idx); i++ {
+		a := sym.idx[i]
+		if strings.ToUpper(sym.Symbols[a]) == symbol {
+			delete(sym.Symbols, a)
+			sym.idx = append(sym.idx[:i], sym.idx[i+1:]...)
+			i--
+			removed = true
+		}
+	}
+
+	for b := range sym.Banked {
+		for a, s := range sym.Banked[b] {
+			if strings.ToUpper(s) == symbol {
+				delete(sym.Banked[b], a)
+				removed = true
+			}
+		}
+	}
+
+	return removed
+}
+
 func (sym *symTable) add(addr uint16, symbol string, prefer bool) {
 	// end add procedure with check for max symbol width
 	defer func() {
@@ -129,12 +288,37 @@ func (sym *symTable) add(addr uint16, symbol string, prefer bool) {
 	sort.Sort(sym)
 }
 
+// search for symbol, which should be in upper case. symbols that apply to
+// every bank are searched first and then the banked symbols, lowest bank
+// first. within each group the lowest matching address is returned, so the
+// result is the same every time.
 func (sym symTable) search(symbol string) (uint16, bool) {
-	for k, v := range sym.Symbols {
-		if strings.ToUpper(v) == symbol {
-			return k, true
+	for _, a := range sym.idx {
+		if strings.ToUpper(sym.Symbols[a]) == symbol {
+			return a, true
 		}
 	}
+
+	banks := make([]int, 0, len(sym.Banked))
+	for b := range sym.Banked {
+		banks = append(banks, b)
+	}
+	sort.Ints(banks)
+
+	for _, b := range banks {
+		found := false
+		var addr uint16
+		for a, v := range sym.Banked[b] {
+			if strings.ToUpper(v) == symbol && (!found || a < addr) {
+				addr = a
+				found = true
+			}
+		}
+		if found {
+			return addr, true
+		}
+	}
+
 	return 0, false
 }
 
@@ -0,0 +1,166 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package symbols
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
+)
+
+// the extension of the file in which user symbols are saved. the file is
+// stored alongside the cartridge file
+const userSymbolsExt = ".user.sym"
+
+// userSymbol is a symbol that has been defined by the user rather than read
+// from a symbols file.
+type userSymbol struct {
+	table   TableType
+	bank    int
+	address uint16
+	symbol  string
+}
+
+func (us userSymbol) String() string {
+	s := fmt.Sprintf("%s %#04x %s", us.table, us.address, us.symbol)
+	if us.bank != AllBanks {
+		s = fmt.Sprintf("%s %d", s, us.bank)
+	}
+	return s
+}
+
+// note user symbol, replacing any existing user symbol for the same table,
+// bank and address.
+func (tbl *Table) noteUserSymbol(us userSymbol) {
+	for i, u := range tbl.user {
+		if u.table == us.table && u.bank == us.bank && u.address == us.address {
+			tbl.user[i] = us
+			return
+		}
+	}
+	tbl.user = append(tbl.user, us)
+}
+
+// forget all user symbols in the table with the name. symbol should be in
+// upper case.
+func (tbl *Table) forgetUserSymbol(table TableType, symbol string) {
+	n := tbl.user[:0]
+	for _, u := range tbl.user {
+		if u.table != table || strings.ToUpper(u.symbol) != symbol {
+			n = append(n, u)
+		}
+	}
+	tbl.user = n
+}
+
+// NumUserSymbols returns the number of user-defined symbols in the table.
+func (tbl *Table) NumUserSymbols() int {
+	return len(tbl.user)
+}
+
+// WriteUserSymbols saves the user-defined symbols to a file alongside the
+// cartridge file. Returns the name of the file that has been written.
+func (tbl *Table) WriteUserSymbols(cartridgeFilename string) (string, error) {
+	if cartridgeFilename == "" {
+		return "", errors.New(errors.SymbolsError, "cannot save user symbols without a cartridge")
+	}
+
+	s := strings.Builder{}
+	s.WriteString("; user symbols. table, address, symbol and (optional) bank\n")
+	for _, u := range tbl.user {
+		s.WriteString(u.String())
+		s.WriteString("\n")
+	}
+
+	filename := companionFilename(cartridgeFilename, userSymbolsExt)
+
+	err := ioutil.WriteFile(filename, []byte(s.String()), 0644)
+	if err != nil {
+		return "", errors.New(errors.SymbolsFileError, err)
+	}
+
+	return filename, nil
+}
+
+// readUserSymbols reads the user symbols file for the cartridge. it is not an
+// error for the file to not exist. user symbols are preferred to any existing
+// symbol.
+func (tbl *Table) readUserSymbols(cartridgeFilename string) error {
+	filename := companionFilename(cartridgeFilename, userSymbolsExt)
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.New(errors.SymbolsFileError, err)
+	}
+
+	for i, ln := range strings.Split(string(data), "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" || ln[0] == ';' {
+			continue // for loop
+		}
+
+		p := strings.Fields(ln)
+		if len(p) < 3 || len(p) > 4 {
+			return errors.New(errors.SymbolsFileError, fmt.Sprintf("line %d of %s", i+1, filename))
+		}
+
+		us := userSymbol{bank: AllBanks, symbol: p[2]}
+
+		switch p[0] {
+		case LocationSymTable.String():
+			us.table = LocationSymTable
+		case ReadSymTable.String():
+			us.table = ReadSymTable
+		case WriteSymTable.String():
+			us.table = WriteSymTable
+		default:
+			return errors.New(errors.SymbolsFileError, fmt.Sprintf("line %d of %s", i+1, filename))
+		}
+
+		a, err := strconv.ParseUint(p[1], 0, 16)
+		if err != nil {
+			return errors.New(errors.SymbolsFileError, fmt.Sprintf("line %d of %s", i+1, filename))
+		}
+		us.address = uint16(a)
+
+		if len(p) == 4 {
+			b, err := strconv.Atoi(p[3])
+			if err != nil || b < 0 {
+				return errors.New(errors.SymbolsFileError, fmt.Sprintf("line %d of %s", i+1, filename))
+			}
+			us.bank = b
+		}
+
+		t, _ := tbl.subTable(us.table)
+		t.addBanked(us.bank, us.address, us.symbol, true)
+		tbl.noteUserSymbol(us)
+	}
+
+	tbl.polishTable()
+
+	return nil
+}
@@ -66,6 +66,12 @@ var pal32bit = []uint32{
 	0x000000, 0x282828, 0x505050, 0x747474, 0x949494, 0xb4b4b4, 0xd0d0d0, 0xececec,
 }
 
+// the SECAM television only has eight colors. the color is selected by the
+// luminance bits of the color signal; the hue bits are ignored
+var secam32bit = []uint32{
+	0x000000, 0x2121ff, 0xf03c79, 0xff50ff, 0x7fff00, 0x7fffff, 0xffff3f, 0xffffff,
+}
+
 // this init() function converts the "raw" color values to the RGB components
 func init() {
 	for _, col := range ntsc32bit {
@@ -84,6 +90,17 @@ func init() {
 		PalettePAL = append(PalettePAL, RGB{red, green, blue})
 	}
 
+	// the SECAM palette is the same size as the NTSC and PAL palettes. every
+	// hue has the same eight colors
+	for i := 0; i < len(ntsc32bit); i++ {
+		col := secam32bit[i%len(secam32bit)]
+		red, green, blue := byte((col&0xff0000)>>16), byte((col&0xff00)>>8), byte(col&0xff)
+
+		// repeat color twice in palette
+		PaletteSECAM = append(PaletteSECAM, RGB{red, green, blue})
+		PaletteSECAM = append(PaletteSECAM, RGB{red, green, blue})
+	}
+
 	for _, col := range alt32bit {
 		red, green, blue := byte((col&0xff0000)>>16), byte((col&0xff00)>>8), byte(col&0xff)
 		PaletteAlt = append(PaletteAlt, RGB{red, green, blue})
@@ -0,0 +1,206 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package colors
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+
+	"github.com/jetsetilly/gopher2600/errors"
+)
+
+// the number of distinct colors in a VCS palette. the palettes used by the
+// television have twice as many entries because the color signal is indexed
+// directly and the lowest bit of the color signal is always zero
+const numColors = 128
+
+// LoadPalette reads a palette file from disk. See ReadPalette() for details
+// of the file format.
+func LoadPalette(filename string) (Palette, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.New(errors.PaletteError, err)
+	}
+	defer f.Close()
+
+	return ReadPalette(f)
+}
+
+// ReadPalette reads palette data in the common .pal binary format. A .pal file
+// is a sequence of RGB triplets, one byte per component, with no header. The
+// file can have 128 entries (one for each color) or 256 entries (one for
+// every possible color signal, the odd entries being ignored).
+func ReadPalette(r io.Reader) (Palette, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.New(errors.PaletteError, err)
+	}
+
+	// the number of bytes to skip after every entry
+	var skip int
+
+	switch len(data) {
+	case numColors * 3:
+		skip = 0
+	case numColors * 2 * 3:
+		skip = 3
+	default:
+		return nil, errors.New(errors.PaletteError, fmt.Sprintf("wrong size for a palette file (%d bytes)", len(data)))
+	}
+
+	p := make(Palette, 0, numColors*2)
+	for i := 0; i < len(data); i += 3 + skip {
+		col := RGB{data[i], data[i+1], data[i+2]}
+
+		// repeat color twice in palette
+		p = append(p, col, col)
+	}
+
+	return p, nil
+}
+
+// PaletteParams are the parameters used to generate a palette. The values
+// are in the range 0.0 to 1.0 except for Phase, which is in degrees, and
+// Gamma, which should be greater than zero.
+type PaletteParams struct {
+	// the change in phase between adjacent hues
+	Phase float64
+
+	// the amplitude of the color component
+	Saturation float64
+
+	// the difference in luminance between the darkest and brightest colors
+	Contrast float64
+
+	// the luminance of the darkest colors
+	Brightness float64
+
+	// gamma correction applied to the RGB components. a value of 1.0 means no
+	// correction
+	Gamma float64
+}
+
+func (p PaletteParams) String() string {
+	return fmt.Sprintf("phase=%.1f saturation=%.2f contrast=%.2f brightness=%.2f gamma=%.2f",
+		p.Phase, p.Saturation, p.Contrast, p.Brightness, p.Gamma)
+}
+
+// DefaultNTSCParams are the parameters that generate a palette that is a good
+// approximation of the NTSC palette
+var DefaultNTSCParams = PaletteParams{
+	Phase:      25.7,
+	Saturation: 0.2,
+	Contrast:   0.925,
+	Brightness: 0.0,
+	Gamma:      1.6,
+}
+
+// DefaultPALParams are the parameters that generate a palette that is a good
+// approximation of the PAL palette
+var DefaultPALParams = PaletteParams{
+	Phase:      30.0,
+	Saturation: 0.2,
+	Contrast:   0.925,
+	Brightness: 0.0,
+	Gamma:      1.6,
+}
+
+// the phase angle of the color burst, in degrees, on the UV plane. the color
+// burst is a yellowish color
+const colorBurst = 170.0
+
+// GenerateNTSC creates a palette in the manner of an NTSC television. Hue zero
+// is grey. The remaining hues are spaced evenly around the color wheel,
+// starting with hue one, which is in phase with the color burst.
+func GenerateNTSC(params PaletteParams) Palette {
+	return generate(params, func(hue int) (float64, bool) {
+		if hue == 0 {
+			return 0, false
+		}
+		return colorBurst - float64(hue-1)*params.Phase, true
+	})
+}
+
+// GeneratePAL creates a palette in the manner of a PAL television. Hues zero,
+// one, fourteen and fifteen are grey. The remaining even hues move around the
+// color wheel in one direction from the color burst and the odd hues move in
+// the other direction.
+func GeneratePAL(params PaletteParams) Palette {
+	return generate(params, func(hue int) (float64, bool) {
+		if hue < 2 || hue > 13 {
+			return 0, false
+		}
+		if hue%2 == 0 {
+			return colorBurst - (float64((hue-2)/2)+0.5)*params.Phase, true
+		}
+		return colorBurst + (float64((hue-3)/2)+0.5)*params.Phase, true
+	})
+}
+
+// generate a palette using the phase function, which returns the phase angle
+// in degrees for a hue. the phase function returns false if the hue is grey.
+func generate(params PaletteParams, phase func(hue int) (float64, bool)) Palette {
+	p := make(Palette, 0, numColors*2)
+
+	for hue := 0; hue < 16; hue++ {
+		angle, chroma := phase(hue)
+
+		var u, v float64
+		if chroma {
+			rad := angle * math.Pi / 180.0
+			u = params.Saturation * math.Cos(rad)
+			v = params.Saturation * math.Sin(rad)
+		}
+
+		for lum := 0; lum < 8; lum++ {
+			y := params.Brightness + params.Contrast*float64(lum)/7.0
+
+			// YUV to RGB
+			col := RGB{
+				Red:   component(y+1.140*v, params.Gamma),
+				Green: component(y-0.395*u-0.581*v, params.Gamma),
+				Blue:  component(y+2.032*u, params.Gamma),
+			}
+
+			// repeat color twice in palette
+			p = append(p, col, col)
+		}
+	}
+
+	return p
+}
+
+// component clamps and gamma corrects a color component, returning the value
+// as a byte
+func component(c float64, gamma float64) byte {
+	if c <= 0.0 {
+		return 0
+	}
+	if c >= 1.0 {
+		return 255
+	}
+	if gamma > 0.0 {
+		c = math.Pow(c, 1.0/gamma)
+	}
+	return byte(math.Round(c * 255))
+}
@@ -19,7 +19,11 @@
 
 package television
 
-import "github.com/jetsetilly/gopher2600/television/colors"
+import (
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/television/colors"
+)
 
 // Television defines the operations that can be performed on the conceptual
 // television. Note that the television implementation itself does not present
@@ -92,6 +96,29 @@ type Television interface {
 
 	// Returns a copy of SignalAttributes for reference
 	GetLastSignal() SignalAttributes
+
+	// Set whether the television should behave like a real CRT television
+	// when the VCS signal is out of spec. In CRT mode, the picture will roll
+	// if VSYNC is missing or late and will lose horizontal lock if HSYNC
+	// occurs at an unexpected time. The state of synchronisation can be
+	// checked with the ReqSyncState request.
+	SetCRTMode(enable bool)
+
+	// Set the palette to use for the current specification, replacing the
+	// specification's default palette. The palette is remembered for the
+	// specification and will be used again if the specification changes and
+	// then changes back. A nil palette restores the default.
+	SetPalette(palette colors.Palette) error
+
+	// SaveState notes and returns the current position of the television's
+	// beam along with the information used to synchronise the picture. The
+	// specification, palette and attached renderers are not part of the
+	// state.
+	SaveState() interface{}
+
+	// RestoreState returns the television to a state previously returned by
+	// SaveState()
+	RestoreState(state interface{}) error
 }
 
 // PixelRenderer implementations displays, or otherwise works with, visual
@@ -170,6 +197,75 @@ type PixelRenderer interface {
 	EndRendering() error
 }
 
+// FrameRenderer is an optional interface for PixelRenderer implementations
+// that only need complete frames. Renderers added to a television with
+// AddPixelRenderer() that also implement FrameRenderer will be sent each frame
+// once it has completed.
+//
+// A FrameRenderer will continue to receive Resize(), NewFrame() and
+// EndRendering() calls but will not receive NewScanline(), SetPixel() or
+// SetAltPixel() calls. The completed frame is sent immediately before
+// NewFrame() is called.
+type FrameRenderer interface {
+	// the Frame instance and its buffers are reused by the television and are
+	// only valid for the duration of the call. implementations should copy
+	// anything they need to keep.
+	RenderFrame(frame *Frame) error
+}
+
+// Frame is a completed television frame, as sent to FrameRenderer
+// implementations.
+type Frame struct {
+	FrameNum int
+
+	// the specification in use when the frame was drawn
+	Spec *Specification
+
+	// the top and bottom scanlines of the visible screen, as decided by the
+	// television. these are the same values sent to PixelRenderer.Resize()
+	Top    int
+	Bottom int
+
+	// whether the television was stable when the frame was drawn
+	Stable bool
+
+	// the number of scanlines in the frame
+	Scanlines int
+
+	// the color signal, alternative color and VBLANK state of every pixel in
+	// the frame. each scanline is HorizClksScanline entries long and there
+	// are at least Scanlines scanlines. unlike SetPixel(), the color signal is
+	// not altered by VBLANK
+	Pixels    []ColorSignal
+	AltPixels []colors.AltColor
+	VBlank    []bool
+}
+
+// RGB returns the color of the pixel at the position, using the frame's
+// specification
+func (fr *Frame) RGB(x, y int) colors.RGB {
+	return fr.Spec.getColor(fr.Pixels[y*HorizClksScanline+x])
+}
+
+// set the pixel information for the position, growing the buffers as required
+func (fr *Frame) set(x, y int, sig SignalAttributes) {
+	if x < 0 || x >= HorizClksScanline || y < 0 {
+		return
+	}
+
+	i := y*HorizClksScanline + x
+	if i >= len(fr.Pixels) {
+		n := (y+1)*HorizClksScanline - len(fr.Pixels)
+		fr.Pixels = append(fr.Pixels, make([]ColorSignal, n)...)
+		fr.AltPixels = append(fr.AltPixels, make([]colors.AltColor, n)...)
+		fr.VBlank = append(fr.VBlank, make([]bool, n)...)
+	}
+
+	fr.Pixels[i] = sig.Pixel
+	fr.AltPixels[i] = sig.AltPixel
+	fr.VBlank[i] = sig.VBlank
+}
+
 // AudioMixer implementations work with sound; most probably playing it. An
 // example of an AudioMixer that does not play sound but otherwise works with
 // it is the digest.Audio type.
@@ -218,4 +314,35 @@ const (
 	ReqFramenum StateReq = iota
 	ReqScanline
 	ReqHorizPos
+
+	// the value returned by a ReqSyncState request should be interpreted as
+	// a SyncState value
+	ReqSyncState
+)
+
+// SyncState describes whether the television has lost synchronisation with
+// the VCS signal. The television will only ever lose synchronisation when the
+// CRT mode is on. The value is a bit field and more than one condition can be
+// true at once.
+type SyncState int
+
+// List of SyncState bits
+const (
+	// VSYNC has been missing or late and the picture is rolling
+	SyncVertLost SyncState = 1 << iota
+
+	// HSYNC has occurred at an unexpected time (most probably because of RSYNC)
+	// and the television is pulling the picture back into place
+	SyncHorizLost
 )
+
+func (s SyncState) String() string {
+	t := strings.Builder{}
+	if s&SyncVertLost == SyncVertLost {
+		t.WriteString("ROLLING ")
+	}
+	if s&SyncHorizLost == SyncHorizLost {
+		t.WriteString("HLOCK LOST ")
+	}
+	return strings.TrimSpace(t.String())
+}
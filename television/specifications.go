@@ -19,7 +19,13 @@
 
 package television
 
-import "github.com/jetsetilly/gopher2600/television/colors"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	"github.com/jetsetilly/gopher2600/television/colors"
+)
 
 // Specification is used to define the two television specifications
 type Specification struct {
@@ -71,6 +77,12 @@ type Specification struct {
 
 	// the number of frames per second required by the specification
 	FramesPerSecond float32
+
+	// the function used to generate a palette for the specification and the
+	// parameters that best approximate the default palette. generator is nil
+	// if the specification's palette can not be generated
+	generator     func(colors.PaletteParams) colors.Palette
+	paletteParams colors.PaletteParams
 }
 
 // getColor translates a signals to the color type
@@ -104,12 +116,32 @@ const (
 // show. after this, we must assume that it is a PAL signal
 const maxNTSCscanlines = 276
 
+// SpecList is the list of specification IDs that can be used with SetSpec(),
+// in addition to "AUTO"
+var SpecList = []string{"NTSC", "PAL", "SECAM", "PAL60", "NTSC50"}
+
 // SpecNTSC is the specification for NTSC television types
 var SpecNTSC *Specification
 
 // SpecPAL is the specification for PAL television types
 var SpecPAL *Specification
 
+// SpecSECAM is the specification for SECAM television types. the frame is the
+// same as PAL but the colors are very different
+var SpecSECAM *Specification
+
+// SpecPAL60 is the specification for PAL televisions that are receiving a
+// signal with NTSC timings. many PAL games were released in this form
+var SpecPAL60 *Specification
+
+// SpecNTSC50 is the specification for NTSC televisions that are receiving a
+// signal with PAL timings. this is what an NTSC game will look like if it has
+// been converted to PAL timings but without changing the colors
+var SpecNTSC50 *Specification
+
+// specs maps the specification IDs in SpecList to the specification
+var specs map[string]*Specification
+
 func init() {
 	SpecNTSC = &Specification{
 		ID:                "NTSC",
@@ -140,4 +172,65 @@ func init() {
 
 	SpecPAL.ScanlineTop = SpecPAL.scanlinesVBlank + SpecPAL.ScanlinesVSync
 	SpecPAL.ScanlineBottom = SpecPAL.ScanlinesTotal - SpecPAL.ScanlinesOverscan
+
+	// the remaining specifications are variations of the two above, differing
+	// only in the colors being used
+	SpecSECAM = SpecPAL.withColors("SECAM", colors.PaletteSECAM)
+	SpecPAL60 = SpecNTSC.withColors("PAL60", colors.PalettePAL)
+	SpecNTSC50 = SpecPAL.withColors("NTSC50", colors.PaletteNTSC)
+
+	SpecNTSC.generator = colors.GenerateNTSC
+	SpecNTSC.paletteParams = colors.DefaultNTSCParams
+	SpecNTSC50.generator = colors.GenerateNTSC
+	SpecNTSC50.paletteParams = colors.DefaultNTSCParams
+	SpecPAL.generator = colors.GeneratePAL
+	SpecPAL.paletteParams = colors.DefaultPALParams
+	SpecPAL60.generator = colors.GeneratePAL
+	SpecPAL60.paletteParams = colors.DefaultPALParams
+
+	specs = map[string]*Specification{
+		SpecNTSC.ID:   SpecNTSC,
+		SpecPAL.ID:    SpecPAL,
+		SpecSECAM.ID:  SpecSECAM,
+		SpecPAL60.ID:  SpecPAL60,
+		SpecNTSC50.ID: SpecNTSC50,
+	}
+}
+
+// GetSpecByID returns the specification with the ID. The ID must be one of the
+// entries in SpecList.
+func GetSpecByID(id string) (*Specification, bool) {
+	spec, ok := specs[strings.ToUpper(id)]
+	return spec, ok
+}
+
+// withColors returns a copy of the specification with a different ID and
+// palette
+func (spec Specification) withColors(id string, palette colors.Palette) *Specification {
+	spec.ID = id
+	spec.Colors = palette
+	spec.generator = nil
+	return &spec
+}
+
+// DefaultPaletteParams returns the parameters that generate an approximation
+// of the specification's default palette. Returns false if the specification's
+// palette can not be generated.
+func (spec *Specification) DefaultPaletteParams() (colors.PaletteParams, bool) {
+	return spec.paletteParams, spec.generator != nil
+}
+
+// GeneratePalette creates a palette for the specification using the supplied
+// parameters. The palette can then be used with the SetPalette() function of
+// the Television interface.
+func (spec *Specification) GeneratePalette(params colors.PaletteParams) (colors.Palette, error) {
+	if spec.generator == nil {
+		return nil, errors.New(errors.PaletteError, fmt.Sprintf("cannot generate palette for %s", spec.ID))
+	}
+	return spec.generator(params), nil
+}
+
+// is50Hz returns true if the specification uses the (slower) PAL frame
+func (spec *Specification) is50Hz() bool {
+	return spec.ScanlinesTotal >= maxNTSCscanlines
 }
@@ -44,14 +44,76 @@ const stabilityThreshold = 15
 // but still, it would be nice to have a value with some sort of pedigree
 const excessiveScanlines = 10000
 
+// the number of scanlines either side of the expected end of frame that a
+// television in CRT mode will accept a VSYNC. a VSYNC that occurs earlier than
+// this is ignored. if no VSYNC has occurred by the end of this range then the
+// television will begin a new frame regardless, causing the picture to roll.
+const crtVertLockRange = 12
+
+// the number of color clocks either side of the expected position of the
+// HSYNC that a television in CRT mode will accept it. outside of this range the
+// television loses horizontal lock and can only pull the picture back into
+// place by crtHorizPull color clocks every scanline
+const crtHorizLockRange = 8
+const crtHorizPull = 4
+
+// the value of horizPos at the start of the HSYNC signal
+const horizPosHSync = 16
+
 // for the purposes of frame size detection, we should consider the first
 // handful of frames to be unreliable
 const unreliableFrames = 4
 
+// the number of color pixels that must be seen before the palette cues are
+// used to decide on the television specification
+const paletteCuesThreshold = 100000
+
+// the proportion of color pixels that must use an NTSC-only hue for the
+// palette to be considered NTSC. expressed as a divisor: a value of 4 means
+// that one pixel in four must be NTSC-only
+const paletteCuesNTSC = 4
+
+// paletteCues counts the hues used by the color signal. in the PAL palette,
+// hues 0x1, 0xe and 0xf are the same grey as hue 0x0 and so a ROM written for
+// a PAL television has no reason to use them. in the NTSC palette however,
+// those hues are yellow and orange/brown and are used often.
+type paletteCues struct {
+	// the number of pixels that are not VideoBlack
+	color int
+
+	// the number of pixels that use a hue that is only meaningful in the NTSC
+	// palette
+	ntsc int
+}
+
+func (c *paletteCues) reset() {
+	c.color = 0
+	c.ntsc = 0
+}
+
+func (c *paletteCues) add(pixel ColorSignal) {
+	if pixel == VideoBlack {
+		return
+	}
+
+	c.color++
+
+	switch (pixel >> 4) & 0x0f {
+	case 0x1, 0xe, 0xf:
+		c.ntsc++
+	}
+}
+
+// isNTSC returns true if there are enough cues to say that the ROM was
+// written for the NTSC palette
+func (c paletteCues) isNTSC() bool {
+	return c.color >= paletteCuesThreshold && c.ntsc*paletteCuesNTSC >= c.color
+}
+
 // television is a reference implementation of the Television interface. In all
 // honesty, it's most likely the only implementation required.
 type television struct {
-	// television specification (NTSC or PAL)
+	// television specification (NTSC, PAL, etc.)
 	spec *Specification
 
 	// spec on creation ID is the string that was to ID the television
@@ -64,9 +126,18 @@ type television struct {
 	// appears to be outside of the current spec.
 	//
 	// in practice this means that if auto is true then we start with the NTSC
-	// spec and move to PAL if the number of scanlines exceeds the NTSC maximum
+	// spec and move to PAL if the number of scanlines exceeds the NTSC maximum.
+	// once in the PAL frame, the palette cues decide whether the PAL or
+	// NTSC50 specification should be used
 	auto bool
 
+	// hues used by the color signal while auto is true
+	cues paletteCues
+
+	// user supplied palettes, indexed by specification ID. specifications
+	// not in the map use their default palette
+	palettes map[string]colors.Palette
+
 	// state of the television
 	//	- the current horizontal position. the position where the next pixel will be
 	//  drawn. also used to check we're receiving the correct signals at the
@@ -87,6 +158,14 @@ type television struct {
 	// list of renderer implementations to consult
 	renderers []PixelRenderer
 
+	// the renderers that are sent every pixel and the renderers that are
+	// sent only complete frames. both lists are subsets of the renderers list
+	pixelRenderers []PixelRenderer
+	frameRenderers []FrameRenderer
+
+	// the frame being drawn. only used if there are frame renderers
+	frame Frame
+
 	// list of audio mixers to consult
 	mixers []AudioMixer
 
@@ -118,6 +197,12 @@ type television struct {
 	key    bool
 	keyCol ColorSignal
 
+	// whether to behave like a real CRT television when the VCS signal is out
+	// of spec. the syncState field records the current state of
+	// synchronisation with the signal
+	crt       bool
+	syncState SyncState
+
 	// whether to use the FPS value given in the TV specification
 	fpsFromSpec bool
 
@@ -156,6 +241,8 @@ func NewTelevision(spec string) (Television, error) {
 
 	// empty list of renderers
 	tv.renderers = make([]PixelRenderer, 0)
+	tv.pixelRenderers = make([]PixelRenderer, 0)
+	tv.frameRenderers = make([]FrameRenderer, 0)
 
 	// initialise TVState
 	err = tv.Reset()
@@ -219,12 +306,20 @@ func NewTelevision(spec string) (Television, error) {
 func (tv television) String() string {
 	s := strings.Builder{}
 	s.WriteString(fmt.Sprintf("FR=%04d SL=%03d HP=%03d", tv.frameNum, tv.scanline, tv.horizPos-HorizClksHBlank))
+	if tv.syncState != 0 {
+		s.WriteString(fmt.Sprintf(" %s", tv.syncState))
+	}
 	return s.String()
 }
 
 // AddPixelRenderer implements the Television interface
 func (tv *television) AddPixelRenderer(r PixelRenderer) {
 	tv.renderers = append(tv.renderers, r)
+	if fr, ok := r.(FrameRenderer); ok {
+		tv.frameRenderers = append(tv.frameRenderers, fr)
+	} else {
+		tv.pixelRenderers = append(tv.pixelRenderers, r)
+	}
 }
 
 // AddAudioMixer implements the Television interface
@@ -239,6 +334,7 @@ func (tv *television) Reset() error {
 	tv.scanline = 0
 	tv.vsyncCount = 0
 	tv.lastSignal = SignalAttributes{}
+	tv.syncState = 0
 
 	tv.top = tv.spec.ScanlineTop
 	tv.bottom = tv.spec.ScanlineBottom
@@ -259,7 +355,22 @@ func (tv *television) Signal(sig SignalAttributes) error {
 		tv.horizPos = 0
 		tv.scanline++
 
-		if tv.scanline <= tv.spec.ScanlinesTotal {
+		if tv.crt {
+			// a television in CRT mode will begin a new frame on its own if
+			// there has been no VSYNC for too long
+			if tv.scanline < tv.spec.ScanlinesTotal+crtVertLockRange {
+				err := tv.newScanline(sig.VBlank)
+				if err != nil {
+					return err
+				}
+			} else {
+				tv.syncState |= SyncVertLost
+				err := tv.newFrame()
+				if err != nil {
+					return err
+				}
+			}
+		} else if tv.scanline <= tv.spec.ScanlinesTotal {
 			err := tv.newScanline(sig.VBlank)
 			if err != nil {
 				return err
@@ -295,9 +406,17 @@ func (tv *television) Signal(sig SignalAttributes) error {
 
 	} else if !sig.VSync && tv.lastSignal.VSync {
 		if tv.vsyncCount > 0 {
-			err := tv.newFrame()
-			if err != nil {
-				return err
+			// a television in CRT mode ignores a VSYNC that is too early. the
+			// picture will continue to roll until the VSYNC occurs at the
+			// right time
+			if !tv.crt || tv.scanline >= tv.spec.ScanlinesTotal-crtVertLockRange {
+				tv.syncState &^= SyncVertLost
+				err := tv.newFrame()
+				if err != nil {
+					return err
+				}
+			} else {
+				tv.syncState |= SyncVertLost
 			}
 		}
 	}
@@ -307,15 +426,22 @@ func (tv *television) Signal(sig SignalAttributes) error {
 	// making sure we're at the correct horizPos value.  if horizPos doesn't
 	// equal 16 at the front of the HSYNC or 36 at then back of the HSYNC, then
 	// it indicates that the RSYNC register was used last scanline.
+	//
+	// a television in CRT mode will not jump to the correct horizPos value if
+	// the HSYNC is too far from where it is expected.
 	if sig.HSync && !tv.lastSignal.HSync {
-		tv.horizPos = 16
+		if tv.crt {
+			tv.horizLock()
+		} else {
+			tv.horizPos = horizPosHSync
+		}
 
 		// count vsync lines at start of hsync
 		if sig.VSync || tv.lastSignal.VSync {
 			tv.vsyncCount++
 		}
 	}
-	if !sig.HSync && tv.lastSignal.HSync {
+	if !sig.HSync && tv.lastSignal.HSync && !tv.crt {
 		tv.horizPos = 36
 	}
 
@@ -323,8 +449,8 @@ func (tv *television) Signal(sig SignalAttributes) error {
 
 	// decode color using the alternative color signal
 	col := colors.GetAltColor(sig.AltPixel)
-	for f := range tv.renderers {
-		err := tv.renderers[f].SetAltPixel(tv.horizPos, tv.scanline, col.Red, col.Green, col.Blue, sig.VBlank)
+	for f := range tv.pixelRenderers {
+		err := tv.pixelRenderers[f].SetAltPixel(tv.horizPos, tv.scanline, col.Red, col.Green, col.Blue, sig.VBlank)
 		if err != nil {
 			return err
 		}
@@ -332,8 +458,8 @@ func (tv *television) Signal(sig SignalAttributes) error {
 
 	// decode color using the regular color signal
 	col = tv.spec.getColor(sig.Pixel)
-	for f := range tv.renderers {
-		err := tv.renderers[f].SetPixel(tv.horizPos, tv.scanline,
+	for f := range tv.pixelRenderers {
+		err := tv.pixelRenderers[f].SetPixel(tv.horizPos, tv.scanline,
 			col.Red, col.Green, col.Blue,
 			sig.VBlank)
 		if err != nil {
@@ -341,6 +467,16 @@ func (tv *television) Signal(sig SignalAttributes) error {
 		}
 	}
 
+	// frame renderers are sent the frame once it has completed
+	if len(tv.frameRenderers) > 0 {
+		tv.frame.set(tv.horizPos, tv.scanline, sig)
+	}
+
+	// gather palette cues if tv spec is being decided automatically
+	if tv.auto {
+		tv.cues.add(sig.Pixel)
+	}
+
 	// check for color signal consistency
 	if tv.key && sig.Pixel != VideoBlack {
 		if tv.keyCol == VideoBlack {
@@ -350,8 +486,11 @@ func (tv *television) Signal(sig SignalAttributes) error {
 		}
 	}
 
-	// update resizing event information
-	tv.resizer.check(tv, sig)
+	// update resizing event information. the screen of a television in CRT
+	// mode never resizes
+	if !tv.crt {
+		tv.resizer.check(tv, sig)
+	}
 
 	// mix audio
 	if sig.AudioUpdate {
@@ -369,6 +508,33 @@ func (tv *television) Signal(sig SignalAttributes) error {
 	return nil
 }
 
+// horizLock moves horizPos towards the position expected at the start of the
+// HSYNC signal. if the HSYNC is close enough to the expected position then the
+// television locks on immediately.
+func (tv *television) horizLock() {
+	// the distance to the expected position, taking into account that the
+	// HSYNC may be a little early or a little late
+	d := horizPosHSync - tv.horizPos
+	if d > HorizClksScanline/2 {
+		d -= HorizClksScanline
+	} else if d < -HorizClksScanline/2 {
+		d += HorizClksScanline
+	}
+
+	if d >= -crtHorizLockRange && d <= crtHorizLockRange {
+		tv.horizPos = horizPosHSync
+		tv.syncState &^= SyncHorizLost
+		return
+	}
+
+	tv.syncState |= SyncHorizLost
+	if d > 0 {
+		tv.horizPos += crtHorizPull
+	} else {
+		tv.horizPos -= crtHorizPull
+	}
+}
+
 func (tv *television) newScanline(vblank bool) error {
 	// reset key color check
 	if !vblank {
@@ -377,8 +543,8 @@ func (tv *television) newScanline(vblank bool) error {
 	}
 
 	// notify renderers of new scanline
-	for f := range tv.renderers {
-		err := tv.renderers[f].NewScanline(tv.scanline)
+	for f := range tv.pixelRenderers {
+		err := tv.pixelRenderers[f].NewScanline(tv.scanline)
 		if err != nil {
 			return err
 		}
@@ -387,18 +553,34 @@ func (tv *television) newScanline(vblank bool) error {
 }
 
 func (tv *television) newFrame() error {
+	// send completed frame to frame renderers before anything about the
+	// television changes
+	if len(tv.frameRenderers) > 0 {
+		tv.frame.FrameNum = tv.frameNum
+		tv.frame.Spec = tv.spec
+		tv.frame.Top = tv.top
+		tv.frame.Bottom = tv.bottom
+		tv.frame.Stable = tv.IsStable()
+		tv.frame.Scanlines = tv.scanline + 1
+		if tv.frame.Scanlines*HorizClksScanline > len(tv.frame.Pixels) {
+			tv.frame.Scanlines = len(tv.frame.Pixels) / HorizClksScanline
+		}
+
+		for f := range tv.frameRenderers {
+			err := tv.frameRenderers[f].RenderFrame(&tv.frame)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// reset key color check
 	tv.key = true
 	tv.keyCol = VideoBlack
 
-	// check to see if we should flip to PAL specficiation
-	if tv.auto && tv.spec != SpecPAL &&
-		tv.scanline >= maxNTSCscanlines &&
-		tv.frameNum > unreliableFrames {
-
-		// flip to PAL specifcation
-		tv.SetSpec("PAL")
-		tv.resizer.resize = true
+	// check to see if we should flip to another specification
+	if tv.auto && tv.frameNum > unreliableFrames {
+		tv.autoSpec()
 	}
 
 	// perform resize if necessary
@@ -445,30 +627,32 @@ func (tv *television) GetState(request StateReq) (int, error) {
 		return tv.scanline, nil
 	case ReqHorizPos:
 		return tv.horizPos - HorizClksHBlank, nil
+	case ReqSyncState:
+		return int(tv.syncState), nil
 	}
 }
 
 // SetSpec implements the Television interface
 func (tv *television) SetSpec(spec string) error {
-	switch strings.ToUpper(spec) {
-	case "NTSC":
-		tv.spec = SpecNTSC
-		tv.auto = false
-	case "PAL":
-		tv.spec = SpecPAL
-		tv.auto = false
-	case "AUTO":
+	spec = strings.ToUpper(spec)
+
+	if spec == "AUTO" {
 		tv.auto = true
+		tv.cues.reset()
 
 		// a tv.spec of nil means this is the first call of SetSpec() so
 		// as well as setting the auto flag we need to specify a
 		// specification
 		if tv.spec == nil {
-			tv.spec = SpecNTSC
+			tv.setSpec(SpecNTSC)
 		}
-
-	default:
-		return errors.New(errors.Television, fmt.Sprintf("unsupported tv specifcation (%s)", spec))
+	} else {
+		s, ok := specs[spec]
+		if !ok {
+			return errors.New(errors.Television, fmt.Sprintf("unsupported tv specifcation (%s)", spec))
+		}
+		tv.setSpec(s)
+		tv.auto = false
 	}
 
 	tv.top = tv.spec.ScanlineTop
@@ -477,6 +661,66 @@ func (tv *television) SetSpec(spec string) error {
 	return nil
 }
 
+// setSpec changes the current specification, using the user supplied palette
+// for the specification if there is one
+func (tv *television) setSpec(spec *Specification) {
+	if p, ok := tv.palettes[spec.ID]; ok {
+		s := *spec
+		s.Colors = p
+		spec = &s
+	}
+	tv.spec = spec
+}
+
+// SetPalette implements the Television interface
+func (tv *television) SetPalette(palette colors.Palette) error {
+	if palette == nil {
+		delete(tv.palettes, tv.spec.ID)
+		tv.setSpec(specs[tv.spec.ID])
+		return nil
+	}
+
+	// we index the palette with the color signal so it is vital that the
+	// palette has an entry for every possible signal
+	if len(palette) != len(tv.spec.Colors) {
+		return errors.New(errors.PaletteError, fmt.Sprintf("palette has the wrong number of entries (%d)", len(palette)))
+	}
+
+	if tv.palettes == nil {
+		tv.palettes = make(map[string]colors.Palette)
+	}
+	tv.palettes[tv.spec.ID] = palette
+	tv.setSpec(specs[tv.spec.ID])
+
+	return nil
+}
+
+// autoSpec decides on the specification to use when auto is true. the frame
+// (NTSC or PAL) is decided by the number of scanlines in the frame that has
+// just ended. the palette is decided by the palette cues seen so far.
+//
+// note that there is no way of detecting a SECAM television from the signal.
+// similarly, a PAL60 ROM can not be reliably distinguished from an NTSC ROM
+// that happens not to use the NTSC-only hues. those specifications must be
+// selected explicitly.
+func (tv *television) autoSpec() {
+	spec := tv.spec
+
+	if spec.is50Hz() || tv.scanline >= maxNTSCscanlines {
+		spec = SpecPAL
+		if tv.cues.isNTSC() {
+			spec = SpecNTSC50
+		}
+	}
+
+	if spec.ID != tv.spec.ID {
+		tv.setSpec(spec)
+		tv.top = tv.spec.ScanlineTop
+		tv.bottom = tv.spec.ScanlineBottom
+		tv.resizer.resize = true
+	}
+}
+
 // SpecIDOnCreation implements the Television interface
 func (tv *television) SpecIDOnCreation() string {
 	return tv.specIDOnCreation
@@ -575,11 +819,95 @@ func (tv *television) fpsCalc() {
 	}
 }
 
+// SetCRTMode implements the Television interface
+func (tv *television) SetCRTMode(enable bool) {
+	tv.crt = enable
+	tv.syncState = 0
+}
+
 // GetLastSignal implements the Television interface
 func (tv *television) GetLastSignal() SignalAttributes {
 	return tv.lastSignal
 }
 
+// televisionState is the type returned by SaveState()
+type televisionState struct {
+	specID      string
+	cues        paletteCues
+	horizPos    int
+	frameNum    int
+	scanline    int
+	lastSignal  SignalAttributes
+	vsyncCount  int
+	top         int
+	bottom      int
+	resizer     resizer
+	stabilityCt int
+	outOfSpec   bool
+	key         bool
+	keyCol      ColorSignal
+	syncState   SyncState
+}
+
+// SaveState implements the Television interface
+func (tv *television) SaveState() interface{} {
+	return televisionState{
+		specID:      tv.spec.ID,
+		cues:        tv.cues,
+		horizPos:    tv.horizPos,
+		frameNum:    tv.frameNum,
+		scanline:    tv.scanline,
+		lastSignal:  tv.lastSignal,
+		vsyncCount:  tv.vsyncCount,
+		top:         tv.top,
+		bottom:      tv.bottom,
+		resizer:     tv.resizer,
+		stabilityCt: tv.stabilityCt,
+		outOfSpec:   tv.outOfSpec,
+		key:         tv.key,
+		keyCol:      tv.keyCol,
+		syncState:   tv.syncState,
+	}
+}
+
+// RestoreState implements the Television interface
+func (tv *television) RestoreState(state interface{}) error {
+	s, ok := state.(televisionState)
+	if !ok {
+		return errors.New(errors.Television, "not a television state")
+	}
+
+	// the specification may have been changed automatically since the state
+	// was saved. the specification is restored by ID so that the current
+	// palette for the specification is used
+	specChanged := s.specID != tv.spec.ID
+	if specChanged {
+		tv.setSpec(specs[s.specID])
+	}
+	tv.cues = s.cues
+
+	tv.horizPos = s.horizPos
+	tv.frameNum = s.frameNum
+	tv.scanline = s.scanline
+	tv.lastSignal = s.lastSignal
+	tv.vsyncCount = s.vsyncCount
+	tv.top = s.top
+	tv.bottom = s.bottom
+	tv.resizer = s.resizer
+	if specChanged {
+		// the pixel renderers will have been resized for the other
+		// specification
+		tv.resizer.resize = true
+	}
+	tv.stabilityCt = s.stabilityCt
+	tv.outOfSpec = s.outOfSpec
+	tv.key = s.key
+	tv.keyCol = s.keyCol
+	tv.syncState = s.syncState
+
+	return nil
+}
+
 // resizer abstractifies the information and tasks required to set the
 // television screen to the right size
 type resizer struct {
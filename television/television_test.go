@@ -20,9 +20,11 @@
 package television_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/television/colors"
 )
 
 func TestNewTelevision(t *testing.T) {
@@ -44,8 +46,311 @@ func TestNewTelevision(t *testing.T) {
 		t.Errorf("AUTO spec creation failed")
 	}
 
+	for _, spec := range television.SpecList {
+		tv, err = television.NewTelevision(spec)
+		if tv == nil || err != nil {
+			t.Errorf("%s spec creation failed", spec)
+		} else if tv.GetSpec().ID != spec {
+			t.Errorf("%s spec creation created a %s television", spec, tv.GetSpec().ID)
+		}
+	}
+
 	tv, err = television.NewTelevision("FOO")
 	if tv != nil || err == nil {
 		t.Errorf("'FOO' spec creation unexpectedly succeeded")
 	}
 }
+
+// signal frames with the specified number of scanlines and with every pixel
+// set to the specified color
+func signalFrames(t *testing.T, tv television.Television, frames int, scanlines int, pixel television.ColorSignal) {
+	t.Helper()
+
+	for f := 0; f < frames; f++ {
+		for sl := 0; sl < scanlines; sl++ {
+			for hp := 0; hp < television.HorizClksScanline; hp++ {
+				sig := television.SignalAttributes{
+					VSync: sl < 3,
+					HSync: hp >= 16 && hp < 36,
+					Pixel: pixel,
+				}
+				err := tv.Signal(sig)
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+			}
+		}
+	}
+}
+
+func TestAutoSpec(t *testing.T) {
+	tv, err := television.NewTelevision("AUTO")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tv.SetFPSCap(false)
+
+	// NTSC frame
+	signalFrames(t, tv, 10, 262, 0x1e)
+	if tv.GetSpec().ID != "NTSC" {
+		t.Errorf("expected NTSC spec (got %s)", tv.GetSpec().ID)
+	}
+
+	// PAL frame with NTSC colors
+	signalFrames(t, tv, 10, 312, 0x1e)
+	if tv.GetSpec().ID != "NTSC50" {
+		t.Errorf("expected NTSC50 spec (got %s)", tv.GetSpec().ID)
+	}
+
+	// PAL frame with PAL colors
+	tv, err = television.NewTelevision("AUTO")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tv.SetFPSCap(false)
+
+	signalFrames(t, tv, 10, 312, 0x2e)
+	if tv.GetSpec().ID != "PAL" {
+		t.Errorf("expected PAL spec (got %s)", tv.GetSpec().ID)
+	}
+}
+
+func TestSaveState(t *testing.T) {
+	tv, err := television.NewTelevision("AUTO")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tv.SetFPSCap(false)
+
+	state := tv.SaveState()
+
+	// PAL frame with NTSC colors
+	signalFrames(t, tv, 10, 312, 0x1e)
+	if tv.GetSpec().ID != "NTSC50" {
+		t.Errorf("expected NTSC50 spec (got %s)", tv.GetSpec().ID)
+	}
+
+	// the automatic switch is undone by restoring the state
+	err = tv.RestoreState(state)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if tv.GetSpec().ID != "NTSC" {
+		t.Errorf("expected NTSC spec (got %s)", tv.GetSpec().ID)
+	}
+
+	// the palette cues have been restored too. PAL frames with PAL colors are
+	// not mistaken for NTSC50 because of the NTSC colors seen earlier
+	signalFrames(t, tv, 10, 312, 0x2e)
+	if tv.GetSpec().ID != "PAL" {
+		t.Errorf("expected PAL spec (got %s)", tv.GetSpec().ID)
+	}
+}
+
+func TestCRTMode(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tv.SetFPSCap(false)
+	tv.SetCRTMode(true)
+
+	syncState := func() television.SyncState {
+		t.Helper()
+		s, err := tv.GetState(television.ReqSyncState)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return television.SyncState(s)
+	}
+
+	// frames of the correct length keep the television in sync
+	signalFrames(t, tv, 5, 262, 0x1e)
+	if syncState() != 0 {
+		t.Errorf("unexpected sync state (%s)", syncState())
+	}
+
+	// frames that are too long cause the picture to roll
+	signalFrames(t, tv, 5, 300, 0x1e)
+	if syncState()&television.SyncVertLost != television.SyncVertLost {
+		t.Errorf("expected picture to be rolling")
+	}
+	if sl, _ := tv.GetState(television.ReqScanline); sl >= 262+12 {
+		t.Errorf("scanline should never be beyond the lock range (%d)", sl)
+	}
+
+	// the television will lock on again when the frames are correct. it will
+	// take a while for the picture to roll back into place
+	signalFrames(t, tv, 30, 262, 0x1e)
+	if syncState() != 0 {
+		t.Errorf("unexpected sync state (%s)", syncState())
+	}
+
+	// HSYNC at the wrong time causes the television to lose horizontal lock
+	for hp := 0; hp < television.HorizClksScanline; hp++ {
+		err := tv.Signal(television.SignalAttributes{HSync: hp >= 60 && hp < 80})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	if syncState()&television.SyncHorizLost != television.SyncHorizLost {
+		t.Errorf("expected horizontal lock to be lost")
+	}
+
+	// the picture is pulled back into place
+	signalFrames(t, tv, 1, 262, 0x1e)
+	if syncState()&television.SyncHorizLost == television.SyncHorizLost {
+		t.Errorf("expected horizontal lock to be regained")
+	}
+
+	// the television does not roll if CRT mode is off
+	tv.SetCRTMode(false)
+	signalFrames(t, tv, 5, 300, 0x1e)
+	if syncState() != 0 {
+		t.Errorf("unexpected sync state (%s)", syncState())
+	}
+}
+
+func TestPalette(t *testing.T) {
+	// palette files can have 128 or 256 entries
+	for _, n := range []int{128, 256} {
+		data := make([]byte, n*3)
+		for i := range data {
+			data[i] = byte(i / 3)
+		}
+		p, err := colors.ReadPalette(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error reading palette with %d entries: %v", n, err)
+		}
+		if len(p) != 256 {
+			t.Errorf("palette with %d entries read as %d entries", n, len(p))
+		}
+		if p[2] != p[3] || p[2] == p[0] {
+			t.Errorf("palette with %d entries not read correctly", n)
+		}
+	}
+
+	_, err := colors.ReadPalette(bytes.NewReader(make([]byte, 100)))
+	if err == nil {
+		t.Errorf("expected error reading palette of the wrong size")
+	}
+
+	// hue zero in a generated palette is grey
+	for _, spec := range []*television.Specification{television.SpecNTSC, television.SpecPAL} {
+		params, ok := spec.DefaultPaletteParams()
+		if !ok {
+			t.Fatalf("%s palette should be generatable", spec.ID)
+		}
+		p, err := spec.GeneratePalette(params)
+		if err != nil {
+			t.Fatalf("unexpected error generating %s palette: %v", spec.ID, err)
+		}
+		if len(p) != len(spec.Colors) {
+			t.Errorf("generated %s palette has %d entries", spec.ID, len(p))
+		}
+		for i := 0; i < 16; i++ {
+			if p[i].Red != p[i].Green || p[i].Green != p[i].Blue {
+				t.Errorf("hue zero of generated %s palette is not grey (%v)", spec.ID, p[i])
+			}
+		}
+	}
+
+	if _, ok := television.SpecSECAM.DefaultPaletteParams(); ok {
+		t.Errorf("SECAM palette should not be generatable")
+	}
+
+	// user palettes are remembered for each specification
+	tv, _ := television.NewTelevision("NTSC")
+	p := television.SpecNTSC.Colors
+	user := make(colors.Palette, len(p))
+	err = tv.SetPalette(user)
+	if err != nil {
+		t.Fatalf("unexpected error setting palette: %v", err)
+	}
+	if &tv.GetSpec().Colors[0] != &user[0] {
+		t.Errorf("user palette not in use")
+	}
+
+	_ = tv.SetSpec("PAL")
+	if &tv.GetSpec().Colors[0] != &television.SpecPAL.Colors[0] {
+		t.Errorf("user palette for NTSC used by PAL")
+	}
+
+	_ = tv.SetSpec("NTSC")
+	if &tv.GetSpec().Colors[0] != &user[0] {
+		t.Errorf("user palette not restored")
+	}
+
+	_ = tv.SetPalette(nil)
+	if &tv.GetSpec().Colors[0] != &p[0] {
+		t.Errorf("default palette not restored")
+	}
+
+	if tv.SetPalette(make(colors.Palette, 10)) == nil {
+		t.Errorf("expected error setting palette of the wrong size")
+	}
+}
+
+// renderer that counts calls and records the frames it is sent
+type frameRenderer struct {
+	pixels int
+	frames []television.Frame
+}
+
+func (r *frameRenderer) Resize(_, _ int) error   { return nil }
+func (r *frameRenderer) NewFrame(_ int) error    { return nil }
+func (r *frameRenderer) NewScanline(_ int) error { return nil }
+func (r *frameRenderer) EndRendering() error     { return nil }
+func (r *frameRenderer) SetAltPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+func (r *frameRenderer) SetPixel(_, _ int, _, _, _ byte, _ bool) error {
+	r.pixels++
+	return nil
+}
+
+func (r *frameRenderer) RenderFrame(frame *television.Frame) error {
+	fr := *frame
+	fr.Pixels = append([]television.ColorSignal{}, frame.Pixels...)
+	r.frames = append(r.frames, fr)
+	return nil
+}
+
+func TestFrameRenderer(t *testing.T) {
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tv.SetFPSCap(false)
+
+	r := &frameRenderer{}
+	tv.AddPixelRenderer(r)
+
+	signalFrames(t, tv, 5, 262, 0x1e)
+
+	if r.pixels != 0 {
+		t.Errorf("frame renderer was sent %d pixels", r.pixels)
+	}
+
+	// a frame is completed at the end of each VSYNC. the first of these is
+	// only a few scanlines long
+	if len(r.frames) != 5 {
+		t.Fatalf("expected 5 frames (got %d)", len(r.frames))
+	}
+
+	for i, fr := range r.frames[1:] {
+		if fr.Spec != tv.GetSpec() {
+			t.Errorf("frame %d has the wrong specification", i)
+		}
+		if fr.Scanlines < 262 || len(fr.Pixels) < fr.Scanlines*television.HorizClksScanline {
+			t.Errorf("frame %d is too small (%d scanlines)", i, fr.Scanlines)
+		}
+		if fr.Pixels[100*television.HorizClksScanline+100] != 0x1e {
+			t.Errorf("frame %d has the wrong pixel data", i)
+		}
+		if fr.RGB(100, 100) != tv.GetSpec().Colors[0x1e] {
+			t.Errorf("frame %d has the wrong RGB value", i)
+		}
+	}
+}
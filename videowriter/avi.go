@@ -0,0 +1,332 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package videowriter
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/jetsetilly/gopher2600/errors"
+)
+
+// the maximum size of the movi list. AVI files with a RIFF chunk of more than
+// 2GB are not supported by many media players
+const maxMoviSize = 1<<31 - 1<<20
+
+// AVI flags
+const (
+	avifHasIndex       = 0x10
+	avifIsInterleaved  = 0x100
+	aviifKeyframe      = 0x10
+	mainHeaderSize     = 56
+	streamHeaderSize   = 56
+	bitmapHeaderSize   = 40
+	waveFormatSize     = 18
+	fourccSize         = 4
+	chunkHeaderSize    = 8
+	indexEntrySize     = 16
+	videoFrameRateBase = 1000
+)
+
+// indexEntry is a single entry in the idx1 chunk
+type indexEntry struct {
+	id     string
+	offset uint32
+	size   uint32
+}
+
+// avi writes the RIFF structure of an AVI file with one video stream of
+// uncompressed 24bit frames and one audio stream of 8bit mono PCM. the header
+// fields that depend on the length of the file are written when the file is
+// closed.
+type avi struct {
+	w io.WriteSeeker
+
+	width     int
+	height    int
+	frameRate float32
+	audioRate int
+
+	// the number of bytes in each row of a video frame. rows in a DIB are
+	// padded to a multiple of four bytes
+	rowSize int
+
+	// file positions of fields that are updated on close
+	riffSizePos    int64
+	totalFramesPos int64
+	videoLenPos    int64
+	audioLenPos    int64
+	moviSizePos    int64
+
+	// number of bytes written to the movi list, not including the list header
+	moviSize uint32
+
+	frames  uint32
+	samples uint32
+	index   []indexEntry
+
+	// error from any write operation. once an error has occurred all
+	// subsequent operations do nothing
+	err error
+}
+
+func newAVI(w io.WriteSeeker, width, height int, frameRate float32, audioRate int) (*avi, error) {
+	a := &avi{
+		w:         w,
+		width:     width,
+		height:    height,
+		frameRate: frameRate,
+		audioRate: audioRate,
+		rowSize:   (width*3 + 3) &^ 3,
+	}
+
+	a.writeHeaders()
+
+	return a, a.err
+}
+
+func (a *avi) write(data interface{}) {
+	if a.err != nil {
+		return
+	}
+	a.err = binary.Write(a.w, binary.LittleEndian, data)
+}
+
+func (a *avi) fourcc(id string) {
+	a.write([]byte(id))
+}
+
+// pos returns the current position in the file
+func (a *avi) pos() int64 {
+	if a.err != nil {
+		return 0
+	}
+	p, err := a.w.Seek(0, io.SeekCurrent)
+	a.err = err
+	return p
+}
+
+// patch the uint32 at the file position. the current position is preserved
+func (a *avi) patch(pos int64, v uint32) {
+	cur := a.pos()
+	if a.err != nil {
+		return
+	}
+	if _, a.err = a.w.Seek(pos, io.SeekStart); a.err != nil {
+		return
+	}
+	a.write(v)
+	if a.err != nil {
+		return
+	}
+	_, a.err = a.w.Seek(cur, io.SeekStart)
+}
+
+func (a *avi) writeHeaders() {
+	frameSize := uint32(a.rowSize * a.height)
+	microSecPerFrame := uint32(1000000.0 / a.frameRate)
+
+	a.fourcc("RIFF")
+	a.riffSizePos = a.pos()
+	a.write(uint32(0))
+	a.fourcc("AVI ")
+
+	// the size of the header list is fixed
+	strlVideoSize := fourccSize + chunkHeaderSize + streamHeaderSize + chunkHeaderSize + bitmapHeaderSize
+	strlAudioSize := fourccSize + chunkHeaderSize + streamHeaderSize + chunkHeaderSize + waveFormatSize
+	hdrlSize := fourccSize + chunkHeaderSize + mainHeaderSize +
+		chunkHeaderSize + strlVideoSize + chunkHeaderSize + strlAudioSize
+
+	a.fourcc("LIST")
+	a.write(uint32(hdrlSize))
+	a.fourcc("hdrl")
+
+	// main header
+	a.fourcc("avih")
+	a.write(uint32(mainHeaderSize))
+	a.write(microSecPerFrame)
+	a.write(uint32(float32(frameSize)*a.frameRate) + uint32(a.audioRate))
+	a.write(uint32(0))
+	a.write(uint32(avifHasIndex | avifIsInterleaved))
+	a.totalFramesPos = a.pos()
+	a.write(uint32(0))
+	a.write(uint32(0))
+	a.write(uint32(2))
+	a.write(frameSize)
+	a.write(uint32(a.width))
+	a.write(uint32(a.height))
+	a.write([4]uint32{})
+
+	// video stream
+	a.fourcc("LIST")
+	a.write(uint32(strlVideoSize))
+	a.fourcc("strl")
+	a.fourcc("strh")
+	a.write(uint32(streamHeaderSize))
+	a.fourcc("vids")
+	a.fourcc("DIB ")
+	a.write(uint32(0))
+	a.write(uint16(0))
+	a.write(uint16(0))
+	a.write(uint32(0))
+	a.write(uint32(videoFrameRateBase))
+	a.write(uint32(a.frameRate * videoFrameRateBase))
+	a.write(uint32(0))
+	a.videoLenPos = a.pos()
+	a.write(uint32(0))
+	a.write(frameSize)
+	a.write(int32(-1))
+	a.write(uint32(0))
+	a.write([4]uint16{0, 0, uint16(a.width), uint16(a.height)})
+
+	a.fourcc("strf")
+	a.write(uint32(bitmapHeaderSize))
+	a.write(uint32(bitmapHeaderSize))
+	a.write(int32(a.width))
+	a.write(int32(a.height))
+	a.write(uint16(1))
+	a.write(uint16(24))
+	a.write(uint32(0))
+	a.write(frameSize)
+	a.write([4]uint32{})
+
+	// audio stream
+	a.fourcc("LIST")
+	a.write(uint32(strlAudioSize))
+	a.fourcc("strl")
+	a.fourcc("strh")
+	a.write(uint32(streamHeaderSize))
+	a.fourcc("auds")
+	a.write(uint32(0))
+	a.write(uint32(0))
+	a.write(uint16(0))
+	a.write(uint16(0))
+	a.write(uint32(0))
+	a.write(uint32(1))
+	a.write(uint32(a.audioRate))
+	a.write(uint32(0))
+	a.audioLenPos = a.pos()
+	a.write(uint32(0))
+	a.write(uint32(a.audioRate))
+	a.write(int32(-1))
+	a.write(uint32(1))
+	a.write([4]uint16{})
+
+	a.fourcc("strf")
+	a.write(uint32(waveFormatSize))
+	a.write(uint16(1))
+	a.write(uint16(1))
+	a.write(uint32(a.audioRate))
+	a.write(uint32(a.audioRate))
+	a.write(uint16(1))
+	a.write(uint16(8))
+	a.write(uint16(0))
+
+	// movi list. the size is written on close
+	a.fourcc("LIST")
+	a.moviSizePos = a.pos()
+	a.write(uint32(0))
+	a.fourcc("movi")
+	a.moviSize = fourccSize
+}
+
+// chunk writes a chunk to the movi list and adds it to the index
+func (a *avi) chunk(id string, data []byte) {
+	if a.err != nil {
+		return
+	}
+
+	size := uint32(len(data))
+	padded := size + size&1
+	if uint64(a.moviSize)+uint64(chunkHeaderSize+padded) > maxMoviSize {
+		a.err = errors.New(errors.VideoWriter, "file is too large")
+		return
+	}
+
+	a.index = append(a.index, indexEntry{id: id, offset: a.moviSize, size: size})
+
+	a.fourcc(id)
+	a.write(size)
+	a.write(data)
+	if size&1 == 1 {
+		a.write(uint8(0))
+	}
+
+	a.moviSize += chunkHeaderSize + padded
+}
+
+// videoFrame writes a frame of video. the pix argument is a slice of RGB
+// triplets, with rows ordered top to bottom
+func (a *avi) videoFrame(pix []byte) {
+	// the rows of a DIB with a positive height are ordered bottom to top and
+	// the color components are ordered blue, green, red
+	data := make([]byte, a.rowSize*a.height)
+	for y := 0; y < a.height; y++ {
+		src := pix[y*a.width*3:]
+		dst := data[(a.height-1-y)*a.rowSize:]
+		for x := 0; x < a.width; x++ {
+			dst[x*3] = src[x*3+2]
+			dst[x*3+1] = src[x*3+1]
+			dst[x*3+2] = src[x*3]
+		}
+	}
+	a.chunk("00db", data)
+	if a.err == nil {
+		a.frames++
+	}
+}
+
+// audio writes unsigned 8bit samples
+func (a *avi) audio(samples []byte) {
+	if len(samples) == 0 {
+		return
+	}
+	a.chunk("01wb", samples)
+	if a.err == nil {
+		a.samples += uint32(len(samples))
+	}
+}
+
+// close writes the index and completes the fields in the headers. the
+// underlying writer is not closed
+func (a *avi) close() error {
+	// an error from a previous write is not a reason to not finish the file
+	// correctly. this is particularly important if the file has grown too
+	// large
+	a.err = nil
+
+	a.fourcc("idx1")
+	a.write(uint32(len(a.index) * indexEntrySize))
+	for _, e := range a.index {
+		a.fourcc(e.id)
+		a.write(uint32(aviifKeyframe))
+		a.write(e.offset)
+		a.write(e.size)
+	}
+
+	end := a.pos()
+	a.patch(a.riffSizePos, uint32(end-a.riffSizePos-4))
+	a.patch(a.moviSizePos, a.moviSize)
+	a.patch(a.totalFramesPos, a.frames)
+	a.patch(a.videoLenPos, a.frames)
+	a.patch(a.audioLenPos, a.samples)
+
+	return a.err
+}
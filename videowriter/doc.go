@@ -0,0 +1,33 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+// Package videowriter allows writing of the television's output, both video and
+// audio, to disk as an uncompressed AVI file. No external encoder is required
+// and the resulting file can be played by most media players or converted to
+// another format with a tool such as ffmpeg.
+//
+// The video stream is paced according to the television specification's
+// FramesPerSecond value. Audio is resampled every frame, to the sample rate of
+// the TIA, so that it remains synchronised with the video regardless of the
+// number of scanlines in each frame.
+//
+// Recording begins when the television is stable. The size of the video is
+// decided at that point and does not change for the remainder of the
+// recording.
+package videowriter
@@ -0,0 +1,280 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package videowriter
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/jetsetilly/gopher2600/errors"
+	tiaAudio "github.com/jetsetilly/gopher2600/hardware/tia/audio"
+	"github.com/jetsetilly/gopher2600/screenshot"
+	"github.com/jetsetilly/gopher2600/television"
+)
+
+// VideoWriter implements the television.PixelRenderer and television.AudioMixer
+// interfaces. The video frames are taken from a screenshot.Screenshot.
+type VideoWriter struct {
+	television.Television
+
+	scr *screenshot.Screenshot
+
+	filename string
+	f        *os.File
+	avi      *avi
+
+	// the visible area of the screen decided on when recording begins
+	top    int
+	bottom int
+
+	// audio data collected since the last frame
+	audio []byte
+
+	// the number of audio samples written so far. used to calculate how many
+	// samples are required for every frame
+	samples int
+
+	// the recording is ended when the television is ended or when End() is
+	// called explicitly. a television has no way of removing a renderer or
+	// mixer so the VideoWriter must remain usable but should do nothing
+	ended bool
+
+	// frames and audio are not recorded while the recording is suspended
+	suspended bool
+}
+
+// New is the preferred method of initialisation for the VideoWriter type. The
+// screenshot argument can be nil, in which case a new instance of
+// screenshot.Screenshot will be created. The new instance is added to the
+// television's list of pixel renderers and audio mixers.
+func New(tv television.Television, scr *screenshot.Screenshot, filename string) (*VideoWriter, error) {
+	var err error
+
+	if scr == nil {
+		scr, err = screenshot.NewScreenshot(tv)
+		if err != nil {
+			return nil, errors.New(errors.VideoWriter, err)
+		}
+	}
+
+	vw := &VideoWriter{
+		Television: tv,
+		scr:        scr,
+		filename:   filename,
+		audio:      make([]byte, 0, tiaAudio.SampleFreq),
+	}
+
+	// create file now so that we can report any problems immediately.
+	// writing of the AVI headers is deferred until the television is stable
+	vw.f, err = os.Create(filename)
+	if err != nil {
+		return nil, errors.New(errors.VideoWriter, err)
+	}
+
+	tv.AddPixelRenderer(vw)
+	tv.AddAudioMixer(vw)
+
+	return vw, nil
+}
+
+// Filename returns the name of the file being written to
+func (vw *VideoWriter) Filename() string {
+	return vw.filename
+}
+
+// Frames returns the number of frames written so far
+func (vw *VideoWriter) Frames() int {
+	if vw.avi == nil {
+		return 0
+	}
+	return int(vw.avi.frames)
+}
+
+// start writing to the AVI file. the size of the video is decided by the
+// current television specification
+func (vw *VideoWriter) start() error {
+	spec := vw.GetSpec()
+	vw.top = spec.ScanlineTop
+	vw.bottom = spec.ScanlineBottom
+
+	img := vw.scr.Image(screenshot.Options{Overscan: true})
+
+	var err error
+	vw.avi, err = newAVI(vw.f, img.Bounds().Dx(), vw.bottom-vw.top, spec.FramesPerSecond, tiaAudio.SampleFreq)
+	if err != nil {
+		return errors.New(errors.VideoWriter, err)
+	}
+
+	return nil
+}
+
+// frame writes the most recent frame and the audio that accompanies it
+func (vw *VideoWriter) frame() error {
+	img := vw.scr.Image(screenshot.Options{Overscan: true})
+
+	// copy visible area of image to a frame of the correct size. if the
+	// specification has changed since recording started then the image is
+	// clipped or padded as required
+	w := vw.avi.width
+	h := vw.avi.height
+	pix := make([]byte, w*h*3)
+	for y := 0; y < h; y++ {
+		sy := vw.top + y
+		if sy >= img.Bounds().Dy() {
+			break
+		}
+		for x := 0; x < w && x < img.Bounds().Dx(); x++ {
+			c := img.RGBAAt(x, sy)
+			i := (y*w + x) * 3
+			pix[i] = c.R
+			pix[i+1] = c.G
+			pix[i+2] = c.B
+		}
+	}
+	vw.avi.videoFrame(pix)
+
+	// the number of samples required to keep pace with the video
+	fps := float64(vw.avi.frameRate)
+	n := int(math.Round(float64(vw.avi.frames)*tiaAudio.SampleFreq/fps)) - vw.samples
+
+	// resample audio collected during the frame to the required length. if
+	// no audio has been collected then silence is written
+	samples := make([]byte, n)
+	for i := range samples {
+		if len(vw.audio) == 0 {
+			samples[i] = 0x80
+		} else {
+			samples[i] = vw.audio[i*len(vw.audio)/n]
+		}
+	}
+	vw.avi.audio(samples)
+	vw.samples += n
+	vw.audio = vw.audio[:0]
+
+	return vw.avi.err
+}
+
+// Resize implements television.PixelRenderer interface
+func (vw *VideoWriter) Resize(_, _ int) error {
+	return nil
+}
+
+// NewFrame implements television.PixelRenderer interface
+func (vw *VideoWriter) NewFrame(_ int) error {
+	if vw.ended || vw.suspended {
+		return nil
+	}
+
+	if vw.avi == nil {
+		if !vw.IsStable() {
+			vw.audio = vw.audio[:0]
+			return nil
+		}
+
+		// start recording but don't write the frame because it may not be
+		// a complete frame
+		vw.audio = vw.audio[:0]
+		return vw.start()
+	}
+
+	err := vw.frame()
+	if err != nil {
+		// stop recording if there has been an error. most likely the file
+		// has grown too large
+		_ = vw.End()
+		return err
+	}
+
+	return nil
+}
+
+// NewScanline implements television.PixelRenderer interface
+func (vw *VideoWriter) NewScanline(_ int) error {
+	return nil
+}
+
+// SetPixel implements television.PixelRenderer interface
+func (vw *VideoWriter) SetPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// SetAltPixel implements television.PixelRenderer interface
+func (vw *VideoWriter) SetAltPixel(_, _ int, _, _, _ byte, _ bool) error {
+	return nil
+}
+
+// EndRendering implements television.PixelRenderer interface
+func (vw *VideoWriter) EndRendering() error {
+	return vw.End()
+}
+
+// SetAudio implements the television.AudioMixer interface
+func (vw *VideoWriter) SetAudio(audioData uint8) error {
+	if vw.ended || vw.suspended || vw.avi == nil {
+		return nil
+	}
+	vw.audio = append(vw.audio, audioData)
+	return nil
+}
+
+// EndMixing implements the television.AudioMixer interface
+func (vw *VideoWriter) EndMixing() error {
+	return vw.End()
+}
+
+// Suspend (or resume) the recording. Frames and audio produced by the
+// television while the recording is suspended are discarded.
+func (vw *VideoWriter) Suspend(suspend bool) {
+	vw.suspended = suspend
+}
+
+// End the recording and close the file. It is safe to call this function more
+// than once.
+func (vw *VideoWriter) End() error {
+	if vw.ended {
+		return nil
+	}
+	vw.ended = true
+
+	if vw.avi != nil {
+		err := vw.avi.close()
+		if err != nil {
+			_ = vw.f.Close()
+			return errors.New(errors.VideoWriter, err)
+		}
+	}
+
+	err := vw.f.Close()
+	if err != nil {
+		return errors.New(errors.VideoWriter, err)
+	}
+
+	return nil
+}
+
+// UniqueFilename returns a filename, based on the name of the cartridge and
+// the current time, that is suitable for a new video
+func UniqueFilename(cartName string) string {
+	n := time.Now()
+	return fmt.Sprintf("video_%s_%04d%02d%02d_%02d%02d%02d.avi",
+		cartName, n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second())
+}
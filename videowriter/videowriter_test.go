@@ -0,0 +1,128 @@
+// This file is part of Gopher2600.
+//
+// Gopher2600 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Gopher2600 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Gopher2600.  If not, see <https://www.gnu.org/licenses/>.
+//
+// *** NOTE: all historical versions of this file, as found in any
+// git repository, are also covered by the licence, even when this
+// notice is not present ***
+
+package videowriter_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/gopher2600/television"
+	"github.com/jetsetilly/gopher2600/videowriter"
+)
+
+func TestVideoWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "videowriter")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "test.avi")
+
+	tv, err := television.NewTelevision("NTSC")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	vw, err := videowriter.New(tv, nil, filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	signalFrames := func(frames int) {
+		t.Helper()
+		for f := 0; f < frames; f++ {
+			for sl := 0; sl < tv.GetSpec().ScanlinesTotal; sl++ {
+				for hp := 0; hp < television.HorizClksScanline; hp++ {
+					err := tv.Signal(television.SignalAttributes{
+						VSync:       sl < 3,
+						HSync:       hp >= 16 && hp < 36,
+						Pixel:       0x1e,
+						AudioData:   uint8(sl),
+						AudioUpdate: hp%114 == 0,
+					})
+					if err != nil {
+						t.Fatalf(err.Error())
+					}
+				}
+			}
+		}
+	}
+
+	// enough frames for the television to become stable
+	signalFrames(30)
+
+	frames := vw.Frames()
+	if frames == 0 {
+		t.Fatalf("no frames recorded")
+	}
+
+	// no frames are recorded while the recording is suspended
+	vw.Suspend(true)
+	signalFrames(5)
+	if vw.Frames() != frames {
+		t.Fatalf("frames recorded while recording suspended")
+	}
+	vw.Suspend(false)
+
+	signalFrames(5)
+	if vw.Frames() != frames+5 {
+		t.Fatalf("expected %d frames (got %d)", frames+5, vw.Frames())
+	}
+	frames = vw.Frames()
+
+	err = tv.End()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// ending more than once is allowed
+	err = vw.End()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "AVI " {
+		t.Fatalf("not an AVI file")
+	}
+
+	if int(binary.LittleEndian.Uint32(data[4:8])) != len(data)-8 {
+		t.Errorf("RIFF size does not match file size")
+	}
+
+	// the total frames field of the main AVI header
+	if int(binary.LittleEndian.Uint32(data[48:52])) != frames {
+		t.Errorf("total frames in header does not match number of frames recorded")
+	}
+
+	// the index is the last chunk in the file. each frame has one video and
+	// one audio entry
+	idx := len(data) - 8 - frames*2*16
+	if idx < 0 || string(data[idx:idx+4]) != "idx1" {
+		t.Errorf("index not found at end of file")
+	}
+}